@@ -8,7 +8,10 @@ import (
 	"reflect"
 
 	"github.com/swayops/jlog"
+	"github.com/swayops/sway/internal/disclosure"
 	"github.com/swayops/sway/internal/geo"
+	"github.com/swayops/sway/internal/safety"
+	"github.com/swayops/sway/internal/shipping"
 
 	"github.com/missionMeteora/mandrill"
 	"github.com/oschwald/maxminddb-golang"
@@ -16,6 +19,7 @@ import (
 
 var (
 	ErrInvalidConfig = errors.New("invalid config")
+	ErrGeocoder      = errors.New("no offline geo data was loaded and there's no google geo key")
 )
 
 func New(loc string) (_ *Config, err error) {
@@ -43,8 +47,27 @@ func New(loc string) (_ *Config, err error) {
 		return nil, err
 	}
 
+	gc, err := c.getGeocoder()
+	if err != nil {
+		log.Println("Config error", err)
+		return nil, err
+	}
+	geo.SetGeocoder(gc)
+
+	if err = safety.Configure(c.Safety); err != nil {
+		log.Println("Config error", err)
+		return nil, err
+	}
+
+	if err = disclosure.Configure(c.Disclosure); err != nil {
+		log.Println("Config error", err)
+		return nil, err
+	}
+
 	if c.Sandbox {
 		c.ClickUrl = c.DashURL + "/c/"
+		// Real carriers are registered as we sign up with them
+		shipping.Register(shipping.NewFake(c.Shipping.FakeSecret))
 	} else {
 		c.ClickUrl = c.HomeURL + "/c/"
 	}
@@ -65,6 +88,21 @@ func New(loc string) (_ *Config, err error) {
 	return &c, nil
 }
 
+// getGeocoder chains the offline geocoder with Google.. posts would
+// never get a location without either of them so that's a config error
+func (c *Config) getGeocoder() (geo.Geocoder, error) {
+	offline, err := geo.NewOfflineGeocoder(c.Gazetteer, c.Boundaries)
+	if err != nil {
+		return nil, err
+	}
+
+	if offline.IsEmpty() && c.GoogleGeoKey == "" {
+		return nil, ErrGeocoder
+	}
+
+	return geo.Chain{offline, &geo.GoogleGeocoder{Key: c.GoogleGeoKey}}, nil
+}
+
 func loadJson(fp string, out interface{}) error {
 	f, err := os.Open(fp)
 	if err != nil {
@@ -101,8 +139,20 @@ type Config struct {
 	GeoLocation string            `json:"geoLoc"`
 	GeoDB       *maxminddb.Reader `json:"geoDb"`
 
+	// Reverse geocoding data for post geotags.. Google is only hit
+	// for points the bundled data can't place (if a key is set)
+	Gazetteer    string `json:"gazetteer"`
+	Boundaries   string `json:"boundaries"`
+	GoogleGeoKey string `json:"googleGeoKey"`
+
 	Sandbox bool `json:"sandbox"`
 
+	// Brand safety lexicons and thresholds keyed off of risk category
+	Safety map[string]*safety.Rule `json:"safety"`
+	// Sponsorship disclosure rules keyed off of country (ISO).. added
+	// to or replacing the built in ones
+	Disclosure map[string]*disclosure.Rule `json:"disclosure"`
+
 	Mandrill struct {
 		APIKey         string `json:"apiKey"`
 		SubAccount     string `json:"subAccount"`
@@ -154,6 +204,10 @@ type Config struct {
 		Audience string `json:"audience"`
 		Budget   string `json:"budget"`
 		Balance  string `json:"balance"`
+		Thread   string `json:"thread"`
+		Auction  string `json:"auction"`
+		Coupon   string `json:"coupon"`
+		Stock    string `json:"stock"`
 	} `json:"bucket"`
 
 	Stripe struct {
@@ -166,6 +220,11 @@ type Config struct {
 		BankAcct string `json:"bank"`
 	} `json:"lob"`
 
+	Shipping struct {
+		// Signs the sandbox carrier's webhook events
+		FakeSecret string `json:"fakeSecret"`
+	} `json:"shipping"`
+
 	ec      *mandrill.Client
 	replyEc *mandrill.Client
 
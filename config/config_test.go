@@ -0,0 +1,44 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestGeocoder(t *testing.T) {
+	var c Config
+	if err := loadJson("config.json", &c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Paths in the config are relative to the repo root
+	if err := os.Chdir(".."); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir("config")
+
+	if _, err := c.getGeocoder(); err != nil {
+		t.Fatalf("the shipped config should be able to geocode: %v", err)
+	}
+
+	// Without the fallback it comes down to the offline data
+	var (
+		_, gErr = os.Stat(c.Gazetteer)
+		_, bErr = os.Stat(c.Boundaries)
+		ex      error
+	)
+
+	if gErr != nil && bErr != nil {
+		ex = ErrGeocoder
+	}
+
+	c.GoogleGeoKey = ""
+	if _, err := c.getGeocoder(); err != ex {
+		t.Fatalf("wanted %v, got %v", ex, err)
+	}
+
+	c.Gazetteer, c.Boundaries = "", ""
+	if _, err := c.getGeocoder(); err != ErrGeocoder {
+		t.Fatalf("wanted %v, got %v", ErrGeocoder, err)
+	}
+}
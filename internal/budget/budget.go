@@ -42,7 +42,8 @@ type Store struct {
 	Charges   []*Charge `json:"charges,omitempty"` // Used for billing
 
 	SpendHistory map[string]float64 `json:"spendHistory,omitempty"`
-	NextBill     int64              `json:"nextBill,omitempty"` // When will this campaign be charged for again?
+	DailySpend   map[string]float64 `json:"dailySpend,omitempty"` // Keyed off of YYYY-MM-DD.. used for pacing
+	NextBill     int64              `json:"nextBill,omitempty"`   // When will this campaign be charged for again?
 }
 
 type Charge struct {
@@ -162,6 +163,7 @@ func RemoteBill(tx *bolt.Tx, cfg *config.Config, cmp *common.Campaign, cust stri
 
 		NextBill:     nextBill.Unix(),
 		SpendHistory: oldStore.SpendHistory,
+		DailySpend:   oldStore.DailySpend,
 	}
 
 	// Charge the campaign for budget unless it's an IO campaign OR product based budget!
@@ -279,10 +281,11 @@ func ReplenishSpendable(tx *bolt.Tx, cfg *config.Config, cmp *common.Campaign, i
 	}
 
 	newStore := &Store{
-		Spent:     store.Spent,
-		Charges:   store.Charges,
-		Spendable: spendable,
-		NextBill:  store.NextBill,
+		Spent:      store.Spent,
+		Charges:    store.Charges,
+		Spendable:  spendable,
+		NextBill:   store.NextBill,
+		DailySpend: store.DailySpend,
 	}
 
 	if !isIO && !cmp.IsProductBasedBudget() {
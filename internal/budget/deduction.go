@@ -21,6 +21,11 @@ const (
 	TW_FAVORITE = 0.1
 
 	CLICK = 0.6
+
+	// Used to estimate performance based deals for influencers
+	// with no click or conversion history
+	CLICK_RATE      = 0.01 // Clicks per engagement
+	CONVERSION_RATE = 0.02 // Conversions per click
 )
 
 func DeductSpendable(store *Store, val float64) *Store {
@@ -32,9 +37,11 @@ func DeductSpendable(store *Store, val float64) *Store {
 		// We have enough spendable to deduct fully
 		store.Spent += val
 		store.Spendable -= val
+		store.addDailySpend(val)
 	} else {
 		// This value goes over our spendable
 		store.Spent += store.Spendable
+		store.addDailySpend(store.Spendable)
 		store.Spendable = 0
 	}
 
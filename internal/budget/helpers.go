@@ -68,3 +68,26 @@ func GetMargins(total, dspFee, exchangeFee, agencyFee float64) (dsp, exchange, a
 	return
 
 }
+
+// GetTotalFromPayout is the inverse of GetMargins.. it returns the
+// total spend required for the influencer to receive the given payout
+func GetTotalFromPayout(payout, dspFee, exchangeFee, agencyFee float64) float64 {
+	if dspFee == -1 {
+		dspFee = DEFAULT_DSP_FEE
+	}
+
+	if exchangeFee == -1 {
+		exchangeFee = DEFAULT_EXCHANGE_FEE
+	}
+
+	if agencyFee == -1 {
+		agencyFee = DEFAULT_AGENCY_FEE
+	}
+
+	ratio := (1 - (dspFee + exchangeFee)) * (1 - agencyFee)
+	if ratio <= 0 {
+		return 0
+	}
+
+	return payout / ratio
+}
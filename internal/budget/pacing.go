@@ -0,0 +1,141 @@
+package budget
+
+import (
+	"math"
+	"time"
+
+	"github.com/swayops/sway/internal/common"
+)
+
+const (
+	PACING_EVEN = "even" // Spread spend evenly over the billing cycle
+	PACING_ASAP = "asap" // Spend as fast as deals get picked up (default)
+
+	dayFormat = "2006-01-02"
+
+	// Campaigns that have spent less than this share of their planned
+	// spend get looser price ranges
+	behindPace = 0.8
+	// Floor for the fill rate used to loosen price ranges
+	minFillRate = 0.25
+)
+
+type Pace struct {
+	Mode string `json:"mode"`
+
+	Start int64 `json:"start"` // Start of the current billing cycle
+	End   int64 `json:"end"`   // Next bill
+
+	DaysLeft    int     `json:"daysLeft"`
+	DailyTarget float64 `json:"dailyTarget"`
+	// Spend committed today by deals that were picked up today
+	Committed float64 `json:"committed"`
+	// Actual spend / planned spend so far this cycle
+	FillRate float64 `json:"fillRate"`
+
+	Planned float64 `json:"planned"`
+	Actual  float64 `json:"actual"`
+
+	Days []*PaceDay `json:"days,omitempty"`
+}
+
+type PaceDay struct {
+	Date    string  `json:"date"`
+	Planned float64 `json:"planned"` // Cumulative
+	Actual  float64 `json:"actual"`  // Cumulative
+}
+
+func IsValidPacing(mode string) bool {
+	return mode == "" || mode == PACING_EVEN || mode == PACING_ASAP
+}
+
+// GetPace computes the campaign's daily spend target from what's left to
+// spend, the days left in the billing cycle and how well it's been filling
+func (st *Store) GetPace(cmp *common.Campaign, chart bool) *Pace {
+	var (
+		now   = time.Now()
+		end   = time.Unix(st.NextBill, 0)
+		total = st.Spent + st.Spendable
+	)
+
+	if st.NextBill == 0 {
+		end = now.AddDate(0, 1, 0)
+	}
+	start := end.AddDate(0, -1, 0)
+
+	p := &Pace{
+		Mode:     cmp.Pacing,
+		Start:    start.Unix(),
+		End:      end.Unix(),
+		FillRate: 1,
+		Actual:   st.Spent,
+	}
+
+	if p.Mode == "" {
+		p.Mode = PACING_ASAP
+	}
+
+	daysLeft := math.Ceil(end.Sub(now).Hours() / 24)
+	if daysLeft < 1 {
+		daysLeft = 1
+	}
+	p.DaysLeft = int(daysLeft)
+
+	totalDays := end.Sub(start).Hours() / 24
+	elapsed := math.Min(math.Max(now.Sub(start).Hours()/24, 0), totalDays)
+	if totalDays > 0 {
+		p.Planned = total * elapsed / totalDays
+	}
+
+	if p.Planned > 0 && elapsed >= 1 {
+		p.FillRate = st.Spent / p.Planned
+	}
+
+	// Campaigns only pick up their fill rate of what they target so
+	// aim higher when behind (and lower when ahead)
+	p.DailyTarget = math.Min(st.Spendable/daysLeft/math.Max(p.FillRate, minFillRate), st.Spendable)
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	p.Committed = cmp.GetCommittedSpend(int32(today.Unix()))
+
+	if chart && totalDays > 0 {
+		var actual float64
+		for day, i := start, 0.0; !day.After(end); day, i = day.AddDate(0, 0, 1), i+1 {
+			actual += st.DailySpend[day.Format(dayFormat)]
+			p.Days = append(p.Days, &PaceDay{
+				Date:    day.Format(dayFormat),
+				Planned: math.Min(total*i/totalDays, total),
+				Actual:  actual,
+			})
+		}
+	}
+
+	return p
+}
+
+// IsAhead returns whether deals picked up today already cover
+// today's target
+func (p *Pace) IsAhead() bool {
+	return p.Mode == PACING_EVEN && p.Committed >= p.DailyTarget
+}
+
+func (p *Pace) IsBehind() bool {
+	return p.Mode == PACING_EVEN && p.FillRate < behindPace
+}
+
+// Loosen widens the target yield range for campaigns that are behind pace
+func (p *Pace) Loosen(min, max float64) (float64, float64) {
+	if !p.IsBehind() {
+		return min, max
+	}
+
+	rate := math.Max(p.FillRate, minFillRate)
+	return min * rate, max / rate
+}
+
+func (st *Store) addDailySpend(val float64) {
+	if st.DailySpend == nil {
+		st.DailySpend = make(map[string]float64)
+	}
+	st.DailySpend[time.Now().Format(dayFormat)] += val
+}
@@ -0,0 +1,98 @@
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/swayops/sway/internal/common"
+)
+
+func TestGetPace(t *testing.T) {
+	var (
+		now      = time.Now()
+		nextBill = now.Add(10 * 24 * time.Hour).Unix()
+		even     = &common.Campaign{Pacing: PACING_EVEN}
+	)
+
+	// Nothing spent 20 days into the cycle
+	p := (&Store{Spendable: 1000, NextBill: nextBill}).GetPace(even, false)
+	if p.Mode != PACING_EVEN || p.DaysLeft != 10 || p.FillRate != 0 {
+		t.Fatalf("unexpected pace: %+v", p)
+	}
+
+	// Aims for 4x the even split since the fill rate is floored at 25%
+	if p.DailyTarget != 400 {
+		t.Fatalf("wanted a daily target of 400, got %v", p.DailyTarget)
+	}
+
+	// Ahead of plan so the target drops under the even split
+	p = (&Store{Spent: 10000, Spendable: 1000, NextBill: nextBill}).GetPace(even, false)
+	if p.FillRate <= 1 || p.DailyTarget >= 100 || p.DailyTarget <= 0 {
+		t.Fatalf("unexpected pace: %+v", p)
+	}
+
+	// New cycle so there's no fill rate yet
+	p = (&Store{Spendable: 1000}).GetPace(&common.Campaign{}, false)
+	if p.Mode != PACING_ASAP || p.FillRate != 1 || p.Planned != 0 || p.DailyTarget != 1000/float64(p.DaysLeft) {
+		t.Fatalf("unexpected pace: %+v", p)
+	}
+
+	// Never targets more than what's left
+	p = (&Store{Spendable: 1000, NextBill: now.Add(-time.Hour).Unix()}).GetPace(even, false)
+	if p.DaysLeft != 1 || p.DailyTarget != 1000 {
+		t.Fatalf("unexpected pace: %+v", p)
+	}
+
+	st := &Store{Spent: 50, Spendable: 950, NextBill: nextBill, DailySpend: map[string]float64{now.Format(dayFormat): 50}}
+	p = st.GetPace(even, true)
+	if len(p.Days) == 0 || p.Days[len(p.Days)-1].Actual != 50 || p.Actual != 50 {
+		t.Fatalf("unexpected chart: %+v", p)
+	}
+}
+
+func TestIsAhead(t *testing.T) {
+	var (
+		now = int32(time.Now().Unix())
+		st  = &Store{Spendable: 1000, NextBill: time.Now().Add(10 * 24 * time.Hour).Unix()}
+	)
+
+	tests := []struct {
+		name   string
+		pacing string
+		deals  map[string]*common.Deal
+		ex     bool
+	}{
+		{"nothing picked up", PACING_EVEN, nil, false},
+		{"under target", PACING_EVEN, map[string]*common.Deal{"1": {Assigned: now, MaxYield: 100}}, false},
+		{"over target", PACING_EVEN, map[string]*common.Deal{"1": {Assigned: now, MaxYield: 300}, "2": {Assigned: now, MaxYield: 200}}, true},
+		// Only deals picked up today count
+		{"yesterday", PACING_EVEN, map[string]*common.Deal{"1": {Assigned: now - 2*86400, MaxYield: 500}}, false},
+		{"asap", PACING_ASAP, map[string]*common.Deal{"1": {Assigned: now, MaxYield: 500}}, false},
+	}
+
+	for _, ts := range tests {
+		p := st.GetPace(&common.Campaign{Pacing: ts.pacing, Deals: ts.deals}, false)
+		if v := p.IsAhead(); v != ts.ex {
+			t.Errorf("%s: wanted %v, got %v (%+v)", ts.name, ts.ex, v, p)
+		}
+	}
+}
+
+func TestLoosen(t *testing.T) {
+	tests := []struct {
+		name     string
+		p        *Pace
+		min, max float64
+	}{
+		{"on pace", &Pace{Mode: PACING_EVEN, FillRate: 0.9}, 10, 20},
+		{"behind", &Pace{Mode: PACING_EVEN, FillRate: 0.5}, 5, 40},
+		{"floored", &Pace{Mode: PACING_EVEN, FillRate: 0.1}, 2.5, 80},
+		{"asap", &Pace{Mode: PACING_ASAP, FillRate: 0.1}, 10, 20},
+	}
+
+	for _, ts := range tests {
+		if min, max := ts.p.Loosen(10, 20); min != ts.min || max != ts.max {
+			t.Errorf("%s: wanted %v-%v, got %v-%v", ts.name, ts.min, ts.max, min, max)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/boltdb/bolt"
+	"github.com/swayops/sway/config"
+)
+
+var (
+	ErrBidding = errors.New("Please provide valid bid multipliers and caps")
+)
+
+// Bidding lets a campaign value some influencers more than others.
+// Bids start at the influencer's engagement value and every matching
+// rule's multiplier is applied
+type Bidding struct {
+	Rules []*BidRule `json:"rules,omitempty"`
+	Cap   float64    `json:"cap,omitempty"` // Most the campaign will pay for any deal
+}
+
+type BidRule struct {
+	// Filters for the rule.. empty filters match everyone
+	Followers *Range `json:"followers,omitempty"`
+	Category  string `json:"category,omitempty"`
+	Platform  string `json:"platform,omitempty"`
+
+	Multiplier float64 `json:"multiplier,omitempty"`
+	Cap        float64 `json:"cap,omitempty"` // Most the campaign will pay for matching influencers
+}
+
+func (b *Bidding) Validate() error {
+	if b == nil {
+		return nil
+	}
+
+	if b.Cap < 0 {
+		return ErrBidding
+	}
+
+	for _, r := range b.Rules {
+		if r == nil || r.Multiplier < 0 || r.Cap < 0 {
+			return ErrBidding
+		}
+	}
+	return nil
+}
+
+func (r *BidRule) matches(followers int64, categories []string, platform string) bool {
+	if r.Followers != nil && !r.Followers.InRange(followers) {
+		return false
+	}
+
+	if r.Platform != "" && r.Platform != platform {
+		return false
+	}
+
+	if r.Category != "" && !IsInList(categories, r.Category) {
+		return false
+	}
+
+	return true
+}
+
+// GetBid returns the most the campaign is willing to pay for the influencer
+func (b *Bidding) GetBid(value float64, followers int64, categories []string, platform string) float64 {
+	if b == nil {
+		return value
+	}
+
+	var (
+		bid    = value
+		maxBid = b.Cap
+	)
+
+	for _, r := range b.Rules {
+		if !r.matches(followers, categories, platform) {
+			continue
+		}
+
+		if r.Multiplier > 0 {
+			bid *= r.Multiplier
+		}
+
+		if r.Cap > 0 && (maxBid == 0 || r.Cap < maxBid) {
+			maxBid = r.Cap
+		}
+	}
+
+	if maxBid > 0 && bid > maxBid {
+		bid = maxBid
+	}
+
+	return bid
+}
+
+// GetBid returns the deal's effective bid in the auction
+func (d *Deal) GetBid() float64 {
+	if d.Bid > 0 {
+		return d.Bid
+	}
+	return d.MaxYield
+}
+
+var followerBands = []struct {
+	Label string
+	Max   int64
+}{
+	{"<10k", 10000},
+	{"10k-50k", 50000},
+	{"50k-250k", 250000},
+	{"250k-1m", 1000000},
+}
+
+func GetFollowerBand(followers int64) string {
+	for _, band := range followerBands {
+		if followers < band.Max {
+			return band.Label
+		}
+	}
+	return "1m+"
+}
+
+// GetFollowerBandIndex returns the position of the follower band
+// (0 is the smallest) so bands can be compared
+func GetFollowerBandIndex(followers int64) int {
+	for i, band := range followerBands {
+		if followers < band.Max {
+			return i
+		}
+	}
+	return len(followerBands)
+}
+
+// AuctionReport holds how often a campaign's offers were accepted when
+// competing with other campaigns.. keyed off of campaign ID in the
+// auction bucket
+type AuctionReport struct {
+	CampaignId string `json:"campaignId"`
+
+	// Keyed off of "platform:", "followers:" or "category:" segments
+	Segments map[string]*AuctionSegment `json:"segments,omitempty"`
+}
+
+type AuctionSegment struct {
+	Offers  int32   `json:"offers"`            // Times the campaign was in an influencer's auction
+	Wins    int32   `json:"wins"`              // Times the influencer picked the campaign
+	WinRate float64 `json:"winRate,omitempty"` // Only filled when retrieved
+}
+
+func (r *AuctionReport) Add(segment string, won bool) {
+	if r.Segments == nil {
+		r.Segments = make(map[string]*AuctionSegment)
+	}
+
+	seg, ok := r.Segments[segment]
+	if !ok {
+		seg = &AuctionSegment{}
+		r.Segments[segment] = seg
+	}
+
+	seg.Offers += 1
+	if won {
+		seg.Wins += 1
+	}
+}
+
+// Merge adds another report's offers and wins to this one
+func (r *AuctionReport) Merge(o *AuctionReport) {
+	if o == nil {
+		return
+	}
+
+	if r.Segments == nil {
+		r.Segments = make(map[string]*AuctionSegment)
+	}
+
+	for segment, oseg := range o.Segments {
+		seg, ok := r.Segments[segment]
+		if !ok {
+			seg = &AuctionSegment{}
+			r.Segments[segment] = seg
+		}
+		seg.Offers += oseg.Offers
+		seg.Wins += oseg.Wins
+	}
+}
+
+func (r *AuctionReport) SetWinRates() {
+	for _, seg := range r.Segments {
+		if seg.Offers > 0 {
+			seg.WinRate = float64(seg.Wins) / float64(seg.Offers)
+		}
+	}
+}
+
+func GetAuctionReportTx(tx *bolt.Tx, cid string, cfg *config.Config) *AuctionReport {
+	v := tx.Bucket([]byte(cfg.Bucket.Auction)).Get([]byte(cid))
+	if v == nil {
+		return &AuctionReport{CampaignId: cid}
+	}
+
+	var r AuctionReport
+	if err := json.Unmarshal(v, &r); err != nil {
+		return &AuctionReport{CampaignId: cid}
+	}
+
+	return &r
+}
+
+// AuctionLog holds auction results in memory until the engine flushes
+// them so accepting a deal doesn't rewrite every campaign offered
+type AuctionLog struct {
+	mux     sync.Mutex
+	pending map[string]*AuctionReport
+}
+
+func NewAuctionLog() *AuctionLog {
+	return &AuctionLog{pending: make(map[string]*AuctionReport)}
+}
+
+func (l *AuctionLog) Add(cid string, segments []string, won bool) {
+	l.mux.Lock()
+	r, ok := l.pending[cid]
+	if !ok {
+		r = &AuctionReport{CampaignId: cid}
+		l.pending[cid] = r
+	}
+
+	for _, segment := range segments {
+		r.Add(segment, won)
+	}
+	l.mux.Unlock()
+}
+
+// Get returns a copy of the results not flushed yet for the campaign
+func (l *AuctionLog) Get(cid string) *AuctionReport {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	r, ok := l.pending[cid]
+	if !ok {
+		return nil
+	}
+
+	out := &AuctionReport{CampaignId: cid}
+	out.Merge(r)
+	return out
+}
+
+// Flush hands over everything pending and starts over
+func (l *AuctionLog) Flush() map[string]*AuctionReport {
+	l.mux.Lock()
+	out := l.pending
+	l.pending = make(map[string]*AuctionReport)
+	l.mux.Unlock()
+	return out
+}
+
+// Restore puts results back when they couldn't be saved
+func (l *AuctionLog) Restore(reports map[string]*AuctionReport) {
+	l.mux.Lock()
+	for cid, r := range reports {
+		cur, ok := l.pending[cid]
+		if !ok {
+			cur = &AuctionReport{CampaignId: cid}
+			l.pending[cid] = cur
+		}
+		cur.Merge(r)
+	}
+	l.mux.Unlock()
+}
@@ -0,0 +1,69 @@
+package common
+
+import "testing"
+
+func TestGetBid(t *testing.T) {
+	b := &Bidding{
+		Cap: 100,
+		Rules: []*BidRule{
+			{Platform: "instagram", Multiplier: 2},
+			{Followers: &Range{From: 10000, To: 50000}, Multiplier: 1.5},
+			{Category: "fashion", Cap: 25},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		b          *Bidding
+		value      float64
+		followers  int64
+		categories []string
+		platform   string
+		ex         float64
+	}{
+		{"no bidding", nil, 10, 20000, nil, "instagram", 10},
+		{"no match", b, 10, 5000, nil, "twitter", 10},
+		{"platform", b, 10, 5000, nil, "instagram", 20},
+		{"followers", b, 10, 20000, nil, "twitter", 15},
+		{"stacked", b, 10, 20000, nil, "instagram", 30},
+		// Lowest matching cap wins
+		{"rule cap", b, 10, 20000, []string{"fashion"}, "instagram", 25},
+		{"campaign cap", b, 80, 20000, nil, "instagram", 100},
+		// A cap alone never raises the bid
+		{"under cap", b, 10, 5000, []string{"fashion"}, "twitter", 10},
+	}
+
+	for _, ts := range tests {
+		if v := ts.b.GetBid(ts.value, ts.followers, ts.categories, ts.platform); v != ts.ex {
+			t.Errorf("%s: wanted %v, got %v", ts.name, ts.ex, v)
+		}
+	}
+
+	if v := (&Deal{MaxYield: 5}).GetBid(); v != 5 {
+		t.Fatalf("wanted the deal's value without a bid, got %v", v)
+	}
+
+	if v := (&Deal{MaxYield: 5, Bid: 8}).GetBid(); v != 8 {
+		t.Fatalf("wanted the deal's bid, got %v", v)
+	}
+}
+
+func TestBiddingValidate(t *testing.T) {
+	tests := []struct {
+		b  *Bidding
+		ex error
+	}{
+		{nil, nil},
+		{&Bidding{Cap: 10, Rules: []*BidRule{{Multiplier: 2}}}, nil},
+		{&Bidding{Cap: -1}, ErrBidding},
+		{&Bidding{Rules: []*BidRule{nil}}, ErrBidding},
+		{&Bidding{Rules: []*BidRule{{Multiplier: -1}}}, ErrBidding},
+		{&Bidding{Rules: []*BidRule{{Cap: -1}}}, ErrBidding},
+	}
+
+	for _, ts := range tests {
+		if err := ts.b.Validate(); err != ts.ex {
+			t.Errorf("%+v: wanted %v, got %v", ts.b, ts.ex, err)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/boltdb/bolt"
 	"github.com/swayops/sway/config"
+	"github.com/swayops/sway/internal/demographics"
 	"github.com/swayops/sway/internal/geo"
 	"github.com/swayops/sway/misc"
 )
@@ -20,7 +21,8 @@ type Campaign struct {
 	CreatedAt int64 `json:"createdAt"`
 
 	Budget  float64 `json:"budget"`
-	Monthly bool    `json:"monthly"` // Is this an ongoing monthly campaign?
+	Monthly bool    `json:"monthly"`          // Is this an ongoing monthly campaign?
+	Pacing  string  `json:"pacing,omitempty"` // "even" or "asap" (default)
 
 	TermsAndConditions string `json:"terms"`
 
@@ -45,6 +47,12 @@ type Campaign struct {
 	Male    bool             `json:"male,omitempty"`
 	Female  bool             `json:"female,omitempty"`
 
+	// Creative variants being tested.. deals are assigned one by weight
+	Variants []*Variant `json:"variants,omitempty"`
+
+	// Tracking params and alternate destinations for the deal links
+	LinkTemplate *LinkTemplate `json:"linkTemplate,omitempty"`
+
 	// Inventory Types Campaign is Targeting
 	Twitter   bool `json:"twitter,omitempty"`
 	Facebook  bool `json:"facebook,omitempty"`
@@ -53,17 +61,47 @@ type Campaign struct {
 
 	// Only allow brand safe influencers?
 	BrandSafe bool `json:"brandSafe,omitempty"`
+	// Risk categories (i.e. "alcohol", "politics") influencers can't be flagged for
+	ExcludeRisks []string `json:"excludeRisks,omitempty"`
 
 	// Categories the client is targeting
 	Categories []string `json:"categories,omitempty"`
 	Keywords   []string `json:"keywords,omitempty"`
 	Audiences  []string `json:"audiences,omitempty"` // Audience IDs the client is targeting
 
+	// Boolean expression over categories, keywords, audiences, bio, platforms,
+	// followers and geo i.e. "fitness AND (yoga OR running) AND NOT supplements"..
+	// has to match on top of the filters above
+	Targeting string `json:"targeting,omitempty"`
+
+	// Requirements on who follows the influencer (all have to match)
+	AudienceTargets []*demographics.Target `json:"audienceTargets,omitempty"`
+
+	// Country (ISO) whose sponsorship disclosure rules posts are held
+	// to.. the influencer's country is used if not set
+	Market string `json:"market,omitempty"`
+
+	// ISO 639-1 codes the influencer has to speak one of
+	Languages []string `json:"languages,omitempty"`
+	// Does the post itself have to be in one of the languages?
+	RequireLanguage bool `json:"requireLanguage,omitempty"`
+
 	FollowerTarget *Range      `json:"followerTarget,omitempty"` // Min and max followers this campaign is targeting
 	EngTarget      *Range      `json:"engTarget,omitempty"`      // Min and max engagements this campaign is targeting
 	PriceTarget    *FloatRange `json:"priceTarget,omitempty"`    // Min and max payouts this campaign is targeting
 
+	// How deals are paid for.. engagement based if not set
+	Pricing *Pricing `json:"pricing,omitempty"`
+	// Bid multipliers and caps used when competing for influencers
+	Bidding *Bidding `json:"bidding,omitempty"`
+	// Keeps influencers from working with competitors around this campaign
+	Exclusivity *Exclusivity `json:"exclusivity,omitempty"`
+
 	Perks *Perk `json:"perks,omitempty"`
+	// What the product looks like (i.e. "sneaker", "bottle").. photos for
+	// product perk deals have to be tagged with one of these
+	VisualTags       []string `json:"visualTags,omitempty"`
+	VisualConfidence float64  `json:"visualConfidence,omitempty"` // Min tag confidence (0-100)
 
 	LegacyWhitelist map[string]bool `json:"whitelist,omitempty"` // List of emails
 
@@ -79,12 +117,21 @@ type Campaign struct {
 
 	Timeline []*Timeline `json:"timeline,omitempty"`
 
-	RequiresSubmission bool `json:"reqSub,omitempty"` // Does the advertiser require submission?
+	RequiresSubmission bool `json:"reqSub,omitempty"`     // Does the advertiser require submission?
+	ReviewDays         int  `json:"reviewDays,omitempty"` // Days before a pending submission is auto approved
+
+	// Deal timeouts and reminders for this campaign
+	Schedule *DealSchedule `json:"schedule,omitempty"`
+
+	// Content usage rights the advertiser is licensing from influencers
+	Rights *UsageRights `json:"rights,omitempty"`
 
 	Archived bool `json:"archived,omitempty"` // aka "deleted"
 
 	Notifications []string `json:"notifications,omitempty"` // List of influencers notified
 
+	// Private invites sent to specific influencers.. keyed off of influencer ID
+	Invites map[string]*DealInvite `json:"invites,omitempty"`
 }
 
 type Range struct {
@@ -129,6 +176,18 @@ func (cmp *Campaign) IsProductBasedBudget() bool {
 	return cmp.Budget == 0 && cmp.Perks != nil
 }
 
+// ChecksProduct returns whether deal photos have to show the product
+func (cmp *Campaign) ChecksProduct() bool {
+	return cmp.Perks != nil && cmp.Perks.IsProduct() && len(cmp.VisualTags) > 0
+}
+
+func (cmp *Campaign) GetVisualConfidence() float64 {
+	if cmp.VisualConfidence > 0 {
+		return cmp.VisualConfidence
+	}
+	return DEFAULT_VISUAL_CONFIDENCE
+}
+
 func (cmp *Campaign) HasMailedPerk() bool {
 	for _, deal := range cmp.Deals {
 		if deal.Perk != nil && deal.Perk.Status {
@@ -239,6 +298,18 @@ func (cmp *Campaign) GetPendingDetails() (float64, int) {
 	return pendingSpend, dealsEmpty
 }
 
+// GetCommittedSpend returns the spend expected from deals picked up
+// since the given TS
+func (cmp *Campaign) GetCommittedSpend(since int32) float64 {
+	var committed float64
+	for _, deal := range cmp.Deals {
+		if deal.Assigned >= since {
+			committed += deal.MaxYield
+		}
+	}
+	return committed
+}
+
 func (cmp *Campaign) GetAcceptedCount() (count int) {
 	// Returns the number of deals accepted
 	for _, deal := range cmp.Deals {
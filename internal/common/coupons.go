@@ -0,0 +1,345 @@
+package common
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/swayops/sway/config"
+)
+
+const (
+	MAX_COUPON_LENGTH = 64
+	MAX_COUPON_UPLOAD = 50000 // Codes per upload
+	couponDateFormat  = "2006-01-02"
+)
+
+var (
+	ErrNoCoupons     = errors.New("Deal is no longer available!")
+	ErrCouponInUse   = errors.New("Cannot delete coupon codes that are in use by influencers")
+	ErrCouponUpload  = errors.New("Coupon uploads look like one code per line with an optional expiry date (YYYY-MM-DD) after a comma")
+	ErrCouponTooMany = errors.New("Too many coupon codes in one upload")
+)
+
+// Coupon is a single code in a campaign's pool
+type Coupon struct {
+	Code    string `json:"code"`
+	Expires int32  `json:"expires,omitempty"` // 0 never expires
+	Added   int32  `json:"added,omitempty"`
+	Seq     int32  `json:"seq,omitempty"` // Order the code went into the pool
+
+	// Set once the code is given out at assignDeal
+	DealId   string `json:"dealId,omitempty"`
+	InfId    string `json:"infId,omitempty"`
+	Assigned int32  `json:"assigned,omitempty"`
+
+	Redemptions int32   `json:"redemptions,omitempty"`
+	Revenue     float64 `json:"revenue,omitempty"`
+}
+
+func (cp *Coupon) IsAssigned() bool {
+	return cp.DealId != ""
+}
+
+func (cp *Coupon) IsExpired(now int32) bool {
+	return cp.Expires > 0 && cp.Expires <= now
+}
+
+// CouponPool holds every code for a coupon campaign.. keyed off of
+// campaign ID in the coupon bucket. Codes are matched case insensitively
+type CouponPool struct {
+	CampaignId string             `json:"campaignId"`
+	Coupons    map[string]*Coupon `json:"coupons"`
+	Seq        int32              `json:"seq,omitempty"`
+}
+
+func normalizeCoupon(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func NewCouponPool(cid string) *CouponPool {
+	return &CouponPool{CampaignId: cid, Coupons: make(map[string]*Coupon)}
+}
+
+func GetCouponPool(cmp *Campaign, db *bolt.DB, cfg *config.Config) *CouponPool {
+	var p *CouponPool
+	db.View(func(tx *bolt.Tx) error {
+		p = GetCouponPoolTx(tx, cmp, cfg)
+		return nil
+	})
+	return p
+}
+
+// GetCouponPoolTx returns the campaign's pool. Campaigns from before
+// pools existed get one built from the codes saved on the campaign
+func GetCouponPoolTx(tx *bolt.Tx, cmp *Campaign, cfg *config.Config) *CouponPool {
+	if v := tx.Bucket([]byte(cfg.Bucket.Coupon)).Get([]byte(cmp.Id)); v != nil {
+		var p CouponPool
+		if err := json.Unmarshal(v, &p); err == nil && p.Coupons != nil {
+			return &p
+		}
+	}
+
+	p := NewCouponPool(cmp.Id)
+	if cmp.Perks == nil {
+		return p
+	}
+
+	p.AddCodes(cmp.Perks.Codes, 0)
+	for _, d := range cmp.Deals {
+		if d.Perk == nil || d.Perk.Code == "" {
+			continue
+		}
+
+		cp := &Coupon{Code: d.Perk.Code, DealId: d.Id, InfId: d.InfluencerId, Assigned: d.Assigned}
+		p.Coupons[normalizeCoupon(cp.Code)] = cp
+	}
+
+	return p
+}
+
+// Add adds the coupons to the pool and returns how many were new.
+// Codes already in the pool only get their expiry updated
+func (p *CouponPool) Add(coupons []*Coupon) (added, dupes int) {
+	now := int32(time.Now().Unix())
+	for _, cp := range coupons {
+		key := normalizeCoupon(cp.Code)
+		if key == "" {
+			continue
+		}
+
+		if old, ok := p.Coupons[key]; ok {
+			if !old.IsAssigned() {
+				old.Expires = cp.Expires
+			}
+			dupes++
+			continue
+		}
+
+		cp.Code = strings.TrimSpace(cp.Code)
+		cp.Added = now
+		p.Seq++
+		cp.Seq = p.Seq
+		p.Coupons[key] = cp
+		added++
+	}
+	return
+}
+
+func (p *CouponPool) AddCodes(codes []string, expires int32) (added, dupes int) {
+	coupons := make([]*Coupon, 0, len(codes))
+	for _, code := range codes {
+		coupons = append(coupons, &Coupon{Code: code, Expires: expires})
+	}
+	return p.Add(coupons)
+}
+
+// Remove deletes unassigned codes from the pool
+func (p *CouponPool) Remove(codes []string) error {
+	for _, code := range codes {
+		if cp, ok := p.Coupons[normalizeCoupon(code)]; ok && cp.IsAssigned() {
+			return ErrCouponInUse
+		}
+	}
+
+	for _, code := range codes {
+		delete(p.Coupons, normalizeCoupon(code))
+	}
+	return nil
+}
+
+// Missing returns the unassigned codes that aren't in the list and
+// whether any code has been given out
+func (p *CouponPool) Missing(codes []string) (missing []string, inUse bool) {
+	wanted := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		wanted[normalizeCoupon(code)] = true
+	}
+
+	for key, cp := range p.Coupons {
+		if cp.IsAssigned() {
+			inUse = true
+		} else if !wanted[key] {
+			missing = append(missing, cp.Code)
+		}
+	}
+	return
+}
+
+func (p *CouponPool) Get(code string) *Coupon {
+	return p.Coupons[normalizeCoupon(code)]
+}
+
+// Codes returns every code in the pool (assigned or not)
+func (p *CouponPool) Codes() []string {
+	codes := make([]string, 0, len(p.Coupons))
+	for _, cp := range p.Coupons {
+		codes = append(codes, cp.Code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Available returns unassigned codes that haven't expired with the
+// ones expiring soonest first so they get used up. Codes with the same
+// expiry go out last in, first out like the campaign's old code list
+func (p *CouponPool) Available() []*Coupon {
+	var (
+		now = int32(time.Now().Unix())
+		out []*Coupon
+	)
+
+	for _, cp := range p.Coupons {
+		if !cp.IsAssigned() && !cp.IsExpired(now) {
+			out = append(out, cp)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Expires != b.Expires {
+			if a.Expires == 0 || b.Expires == 0 {
+				return b.Expires == 0
+			}
+			return a.Expires < b.Expires
+		}
+		if a.Seq != b.Seq {
+			return a.Seq > b.Seq
+		}
+		return a.Code < b.Code
+	})
+
+	return out
+}
+
+func (p *CouponPool) Count() int {
+	return len(p.Available())
+}
+
+// Assign gives the next available code to the deal
+func (p *CouponPool) Assign(dealId, infId string) (*Coupon, error) {
+	avail := p.Available()
+	if len(avail) == 0 {
+		return nil, ErrNoCoupons
+	}
+
+	cp := avail[0]
+	cp.DealId, cp.InfId, cp.Assigned = dealId, infId, int32(time.Now().Unix())
+	return cp, nil
+}
+
+// Release puts the deal's code back in the pool unless it's already
+// been used
+func (p *CouponPool) Release(code string) {
+	cp := p.Get(code)
+	if cp == nil || cp.Redemptions > 0 {
+		return
+	}
+	cp.DealId, cp.InfId, cp.Assigned = "", "", 0
+	p.Seq++
+	cp.Seq = p.Seq
+}
+
+// ParseCouponCSV reads one code per row with an optional expiry date
+// (YYYY-MM-DD or unix timestamp) in the second column. A header row
+// is skipped
+func ParseCouponCSV(r io.Reader) ([]*Coupon, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var out []*Coupon
+	for row := 0; ; row++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrCouponUpload
+		}
+
+		if len(rec) == 0 || strings.TrimSpace(rec[0]) == "" {
+			continue
+		}
+
+		if row == 0 && strings.EqualFold(strings.TrimSpace(rec[0]), "code") {
+			continue
+		}
+
+		cp := &Coupon{Code: strings.TrimSpace(rec[0])}
+		if len(cp.Code) > MAX_COUPON_LENGTH || strings.ContainsAny(cp.Code, " \t") {
+			return nil, ErrCouponUpload
+		}
+
+		if len(rec) > 1 && strings.TrimSpace(rec[1]) != "" {
+			if cp.Expires, err = ParseCouponExpiry(strings.TrimSpace(rec[1])); err != nil {
+				return nil, err
+			}
+		}
+
+		if out = append(out, cp); len(out) > MAX_COUPON_UPLOAD {
+			return nil, ErrCouponTooMany
+		}
+	}
+
+	return out, nil
+}
+
+// ParseCouponExpiry parses a YYYY-MM-DD date or unix timestamp
+func ParseCouponExpiry(v string) (int32, error) {
+	if ts, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return int32(ts), nil
+	}
+
+	t, err := time.Parse(couponDateFormat, v)
+	if err != nil {
+		return 0, ErrCouponUpload
+	}
+
+	// Good through the end of the day
+	return int32(t.AddDate(0, 0, 1).Unix() - 1), nil
+}
+
+// Redemption is a coupon code used at the advertiser's checkout
+type Redemption struct {
+	Code    string  `json:"code"`
+	OrderId string  `json:"orderId,omitempty"`
+	Value   float64 `json:"value,omitempty"`
+	TS      int32   `json:"ts,omitempty"`
+}
+
+// AddRedemption adds the redemption to the deal's stats for the day it
+// happened. Returns false if we've already seen the order
+func (d *Deal) AddRedemption(r *Redemption) bool {
+	for _, data := range d.Reporting {
+		for _, old := range data.Redemptions {
+			if r.OrderId != "" && old.OrderId == r.OrderId {
+				return false
+			}
+
+			if r.OrderId == "" && old.OrderId == "" && old.Code == r.Code && old.TS == r.TS {
+				return false
+			}
+		}
+	}
+
+	if d.Reporting == nil {
+		d.Reporting = make(map[string]*Stats)
+	}
+
+	key := GetDateFromTime(time.Unix(int64(r.TS), 0).UTC())
+	data, ok := d.Reporting[key]
+	if !ok {
+		data = &Stats{}
+		d.Reporting[key] = data
+	}
+
+	data.Redemptions = append(data.Redemptions, r)
+	return true
+}
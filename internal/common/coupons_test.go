@@ -0,0 +1,41 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCouponAssign(t *testing.T) {
+	var (
+		p    = NewCouponPool("cid")
+		soon = int32(time.Now().Add(24 * time.Hour).Unix())
+	)
+
+	p.AddCodes([]string{"123COUPON", "321COUPON", "LASTCOUPON"}, 0)
+	if added, dupes := p.AddCodes([]string{"lastcoupon", "EXPIRING"}, 0); added != 1 || dupes != 1 {
+		t.Fatalf("unexpected add: %d %d", added, dupes)
+	}
+	p.Get("EXPIRING").Expires = soon
+
+	// Expiring codes go first, then the newest code like the old code list
+	for _, ex := range []string{"EXPIRING", "LASTCOUPON", "321COUPON"} {
+		cp, err := p.Assign("deal"+ex, "inf")
+		if err != nil || cp.Code != ex {
+			t.Fatalf("wanted %s, got %+v %v", ex, cp, err)
+		}
+	}
+
+	// Released codes go back on top
+	p.Release("lastcoupon")
+	if cp, _ := p.Assign("1", "inf"); cp == nil || cp.Code != "LASTCOUPON" {
+		t.Fatalf("wanted LASTCOUPON, got %+v", cp)
+	}
+
+	if cp, _ := p.Assign("2", "inf"); cp == nil || cp.Code != "123COUPON" {
+		t.Fatalf("wanted 123COUPON, got %+v", cp)
+	}
+
+	if _, err := p.Assign("3", "inf"); err != ErrNoCoupons {
+		t.Fatalf("wanted %v, got %v", ErrNoCoupons, err)
+	}
+}
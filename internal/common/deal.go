@@ -11,6 +11,8 @@ import (
 
 	"github.com/boltdb/bolt"
 	"github.com/swayops/sway/config"
+	"github.com/swayops/sway/internal/disclosure"
+	"github.com/swayops/sway/internal/language"
 	"github.com/swayops/sway/misc"
 	"github.com/swayops/sway/platforms/facebook"
 	"github.com/swayops/sway/platforms/instagram"
@@ -62,6 +64,22 @@ type Deal struct {
 	Assigned int32 `json:"assigned,omitempty"`
 	// Timestamp for when the deal was completed by an influencer
 	Completed int32 `json:"completed,omitempty"`
+	// Timestamp for when the deal times out (set at assignment from
+	// the campaign's schedule)
+	Timeout int32 `json:"timeout,omitempty"`
+	// Number of post reminders sent to the influencer
+	Reminders int `json:"reminders,omitempty"`
+	// Extension requested by the influencer (only one allowed)
+	Extension *Extension `json:"extension,omitempty"`
+	// Usage rights accepted by the influencer at assignment
+	Rights *UsageRights `json:"rights,omitempty"`
+	// Pricing model accepted by the influencer at assignment
+	Pricing *Pricing `json:"pricing,omitempty"`
+	// Clicks or conversions paid for so far (performance pricing only)
+	BilledUnits int32 `json:"billedUnits,omitempty"`
+	// Campaign's exclusivity category at assignment.. used to find
+	// conflicts with competitors
+	Exclusivity *Exclusivity `json:"exclusivity,omitempty"`
 
 	// All of the following are when a deal is assigned/unassigned
 	// or times out
@@ -82,6 +100,11 @@ type Deal struct {
 
 	PostUrl string `json:"postUrl,omitempty"`
 
+	// Latest sponsorship disclosure check.. kept for the compliance audit
+	Disclosure *disclosure.Result `json:"disclosure,omitempty"`
+	// Latest check for the product in the post's photo
+	ProductCheck *ProductCheck `json:"productCheck,omitempty"`
+
 	// Requirements copied from the campaign to the deal
 	// GetAvailableDeals
 	Tags          []string `json:"tags,omitempty"`
@@ -92,6 +115,12 @@ type Deal struct {
 	Task string `json:"task,omitempty"`
 	Perk *Perk  `json:"perk,omitempty"`
 
+	// Post has to be in one of these (only set if the campaign requires it)
+	Languages []string `json:"languages,omitempty"`
+
+	// Creative variant assigned at assignDeal
+	VariantId string `json:"variantId,omitempty"`
+
 	// How much this campaign has left to spend for the month
 	// Only filled in GetAvailableDeals for the influencer to see
 	// and is saved to show how much the influencer was offered
@@ -106,7 +135,9 @@ type Deal struct {
 
 	// Used by inf app to decide on whether or not it should show post submission option
 	RequiresSubmission bool        `json:"reqSub,omitempty"`
-	Submission         *Submission `json:"submission,omitempty"`
+	Submission         *Submission `json:"submission,omitempty"` // Latest revision
+	// Previous revisions of the submission (oldest first)
+	Revisions []*Submission `json:"revisions,omitempty"`
 
 	From int64 `json:"fromTime,omitempty"`
 	To   int64 `json:"toTime,omitempty"`
@@ -115,6 +146,8 @@ type Deal struct {
 
 	// MaxYield calculated at deal offer time
 	MaxYield float64 `json:"maxYield"`
+	// Most the campaign was willing to pay (only set for campaigns with bidding)
+	Bid float64 `json:"bid,omitempty"`
 	// Has this deal been deducted from spendable?
 	Paid bool `json:"paid,omitempty"`
 }
@@ -127,6 +160,14 @@ type Submission struct {
 	Message string `json:"caption,omitempty"`
 
 	Approved bool `json:"approved,omitempty"`
+
+	Version     int        `json:"version,omitempty"`
+	Status      string     `json:"status,omitempty"`
+	Comments    []*Comment `json:"comments,omitempty"`
+	SubmittedAt int64      `json:"submittedAt,omitempty"`
+	ReviewedAt  int64      `json:"reviewedAt,omitempty"`
+	// Set when the review SLA ran out and the engine approved it
+	AutoApproved bool `json:"autoApproved,omitempty"`
 }
 
 func (s *Submission) SanitizeContent() {
@@ -176,6 +217,7 @@ type Stats struct {
 	Perks    int32 `json:"perks,omitempty"`
 
 	Conversions []pixel.Conversion `json:"conversions,omitempty"`
+	Redemptions []*Redemption      `json:"redemptions,omitempty"` // Coupon code uses
 
 	LegacyClicks int32 `json:"clicks,omitempty"`
 
@@ -334,6 +376,7 @@ func (d *Deal) TotalStats() *Stats {
 		total.Influencer += data.Influencer
 		total.Agency += data.Agency
 		total.Conversions = append(total.Conversions, data.Conversions...)
+		total.Redemptions = append(total.Redemptions, data.Redemptions...)
 	}
 
 	return total
@@ -560,6 +603,7 @@ func (d *Deal) Get(dates []string, agid string) (m *Stats) {
 		data.Perks += stats.Perks
 
 		data.Conversions = append(data.Conversions, stats.Conversions...)
+		data.Redemptions = append(data.Redemptions, stats.Redemptions...)
 	}
 	return data
 }
@@ -604,6 +648,15 @@ func (d *Deal) Caption() string {
 	return ""
 }
 
+// MatchesLanguage returns whether the post text is in one of the
+// deal's required languages
+func (d *Deal) MatchesLanguage(text string) bool {
+	if len(d.Languages) == 0 {
+		return true
+	}
+	return language.IsAny(text, d.Languages)
+}
+
 func (d *Deal) Picture() string {
 	if d.Instagram != nil && misc.Ping(d.Instagram.Thumbnail) == nil {
 		return d.Instagram.Thumbnail
@@ -626,7 +679,7 @@ func (d *Deal) IsAvailable() bool {
 
 func (d *Deal) GetInstructions() []string {
 	var instructions []string
-	if d.ShortenedLink != "" {
+	if d.RequiresLink() {
 		instructions = append(instructions, "Put this link in your bio/caption: "+d.ShortenedLink)
 	}
 
@@ -662,6 +715,20 @@ func (d *Deal) ConvertToClear() *Deal {
 	d.Spendable = 0
 	d.Earnings = 0
 	d.InfluencerName = ""
+	d.Timeout = 0
+	d.Reminders = 0
+	d.Extension = nil
+	d.Rights = nil
+	d.VariantId = ""
+	d.Pricing = nil
+	d.BilledUnits = 0
+	d.Bid = 0
+	d.Exclusivity = nil
+	d.Submission = nil
+	d.Revisions = nil
+	d.Languages = nil
+	d.Disclosure = nil
+	d.ProductCheck = nil
 
 	return d
 }
@@ -689,14 +756,14 @@ func (d *Deal) IsSubmitted() bool {
 }
 
 func (d *Deal) MatchesSubmission(caption string) bool {
-	if d.Submission != nil {
-		msg := strings.TrimSpace(strings.ToLower(d.Submission.Message))
-		caption = strings.TrimSpace(strings.ToLower(caption))
-		if strings.Contains(caption, msg) || strings.Contains(msg, caption) {
+	// Only the approved revision is enforced
+	if d.Submission != nil && d.Submission.Approved {
+		msg := normalizeCaption(d.Submission.Message)
+		if msg == "" {
 			return true
 		}
 
-		return false
+		return strings.Contains(normalizeCaption(caption), msg)
 	}
 
 	return true
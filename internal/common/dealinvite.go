@@ -0,0 +1,89 @@
+package common
+
+import "time"
+
+const (
+	INVITE_PENDING  = "pending"
+	INVITE_ACCEPTED = "accepted"
+	INVITE_DECLINED = "declined"
+	INVITE_EXPIRED  = "expired"
+)
+
+// DealInvite is a private offer sent by an advertiser to a single
+// influencer. The deal it points to is reserved for the invitee until
+// the deadline passes
+type DealInvite struct {
+	Id           string `json:"id,omitempty"`
+	InfluencerId string `json:"influencerId"`
+	DealId       string `json:"dealId,omitempty"` // Deal reserved for the invitee
+
+	Payout       float64 `json:"payout"`                 // Fixed amount the influencer will earn
+	Deadline     int64   `json:"deadline"`               // Unix TS the invite is valid until
+	Perk         *Perk   `json:"perk,omitempty"`         // Optional override of the campaign perk's name and instructions
+	Instructions string  `json:"instructions,omitempty"` // Replaces the campaign task for this deal
+
+	Status    string `json:"status,omitempty"`
+	CreatedAt int64  `json:"createdAt,omitempty"`
+	UpdatedAt int64  `json:"updatedAt,omitempty"`
+}
+
+func (inv *DealInvite) IsExpired() bool {
+	return inv.Deadline > 0 && time.Now().Unix() > inv.Deadline
+}
+
+// IsLive returns whether the invite is still holding its deal
+func (inv *DealInvite) IsLive() bool {
+	return inv.Status == INVITE_PENDING && !inv.IsExpired()
+}
+
+// GetStatus returns the status with pending invites past their
+// deadline reported as expired
+func (inv *DealInvite) GetStatus() string {
+	if inv.Status == INVITE_PENDING && inv.IsExpired() {
+		return INVITE_EXPIRED
+	}
+	return inv.Status
+}
+
+// GetLiveInvite returns the pending invite for the influencer (if any)
+func (cmp *Campaign) GetLiveInvite(infId string) *DealInvite {
+	inv, ok := cmp.Invites[infId]
+	if !ok || inv == nil || !inv.IsLive() {
+		return nil
+	}
+	return inv
+}
+
+// GetReservedDeals returns the deal IDs that are being held for
+// influencers other than the one given
+func (cmp *Campaign) GetReservedDeals(infId string) map[string]bool {
+	reserved := make(map[string]bool)
+	for id, inv := range cmp.Invites {
+		if id != infId && inv != nil && inv.IsLive() && inv.DealId != "" {
+			reserved[inv.DealId] = true
+		}
+	}
+	return reserved
+}
+
+// Apply sets the invite's custom terms on the deal
+func (inv *DealInvite) Apply(deal *Deal) {
+	if inv.Instructions != "" {
+		deal.Task = inv.Instructions
+	}
+
+	if inv.Perk != nil && deal.Perk != nil {
+		if inv.Perk.Name != "" {
+			deal.Perk.Name = inv.Perk.Name
+		}
+
+		if inv.Perk.Instructions != "" {
+			deal.Perk.Instructions = inv.Perk.Instructions
+		}
+	}
+
+	if inv.Payout > 0 {
+		// Fixed payouts are paid once regardless of the campaign's pricing
+		deal.Pricing = nil
+	}
+}
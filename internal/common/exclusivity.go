@@ -0,0 +1,100 @@
+package common
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrExclusivity = errors.New("Please provide a valid exclusivity category and window")
+)
+
+// Exclusivity declares the brand category a campaign competes in. Influencers
+// can't take deals from two different advertisers in the same category
+// within Days of each other (before or after)
+type Exclusivity struct {
+	Category string `json:"category,omitempty"` // i.e. "beverage"
+	Days     int32  `json:"days,omitempty"`     // 0 only labels the campaign's category
+}
+
+func (e *Exclusivity) IsSet() bool {
+	return e != nil && e.Category != ""
+}
+
+func (e *Exclusivity) Validate() error {
+	if e == nil {
+		return nil
+	}
+
+	e.Category = strings.ToLower(strings.TrimSpace(e.Category))
+	if e.Category == "" || e.Days < 0 {
+		return ErrExclusivity
+	}
+	return nil
+}
+
+// GetExclusivity returns the campaign's exclusivity with the window
+// stretched to cover exclusivity bought through usage rights
+func (cmp *Campaign) GetExclusivity() *Exclusivity {
+	ex := cmp.Exclusivity
+	if !ex.IsSet() || cmp.Rights == nil || int32(cmp.Rights.Exclusivity) <= ex.Days {
+		return ex
+	}
+
+	out := *ex
+	out.Days = int32(cmp.Rights.Exclusivity)
+	return &out
+}
+
+// Snapshot returns a copy of the campaign's exclusivity for a deal
+func (e *Exclusivity) Snapshot() *Exclusivity {
+	if !e.IsSet() {
+		return nil
+	}
+
+	snap := *e
+	return &snap
+}
+
+// ConflictsWith returns whether the given deal from another advertiser
+// falls within the exclusivity window around ts. Deals that are still
+// active always conflict since they'll be posted soon
+func (e *Exclusivity) ConflictsWith(d *Deal, advertiserId string, ts int32) bool {
+	if !e.IsSet() || !d.Exclusivity.IsSet() || d.Exclusivity.Category != e.Category {
+		return false
+	}
+
+	if d.AdvertiserId == advertiserId {
+		// Same brand isn't a competitor
+		return false
+	}
+
+	if d.Completed == 0 {
+		return d.Assigned > 0
+	}
+
+	// The longest window of the two campaigns wins
+	window := e.Days
+	if d.Exclusivity.Days > window {
+		window = d.Exclusivity.Days
+	}
+
+	diff := d.Completed - ts
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= window*daySeconds
+}
+
+// ExclusivityConflict is a deal that was completed alongside a
+// competitor's deal (only possible via admin overrides or if the
+// campaign's exclusivity was added after the deals went out)
+type ExclusivityConflict struct {
+	DealId          string `json:"dealId"`
+	InfluencerId    string `json:"influencerId"`
+	InfluencerName  string `json:"influencerName"`
+	Category        string `json:"category"`
+	OtherCampaignId string `json:"otherCampaignId"`
+	OtherCompany    string `json:"otherCompany,omitempty"`
+	OtherCompleted  int32  `json:"otherCompleted,omitempty"`
+}
@@ -0,0 +1,60 @@
+package common
+
+import "testing"
+
+func TestConflictsWith(t *testing.T) {
+	var (
+		now = int32(1500000000)
+		ex  = &Exclusivity{Category: "beverage", Days: 10}
+	)
+
+	tests := []struct {
+		name string
+		ex   *Exclusivity
+		d    *Deal
+		conf bool
+	}{
+		{"no exclusivity", nil, &Deal{AdvertiserId: "other", Assigned: 1, Exclusivity: ex}, false},
+		{"untagged deal", ex, &Deal{AdvertiserId: "other", Assigned: 1}, false},
+		{"other category", ex, &Deal{AdvertiserId: "other", Assigned: 1, Exclusivity: &Exclusivity{Category: "apparel", Days: 10}}, false},
+		{"same advertiser", ex, &Deal{AdvertiserId: "adv", Assigned: 1, Exclusivity: ex}, false},
+		// Active deals conflict no matter the window
+		{"active", &Exclusivity{Category: "beverage"}, &Deal{AdvertiserId: "other", Assigned: 1, Exclusivity: &Exclusivity{Category: "beverage"}}, true},
+		{"not assigned", ex, &Deal{AdvertiserId: "other", Exclusivity: ex}, false},
+		{"within window", ex, &Deal{AdvertiserId: "other", Assigned: 1, Completed: now - 5*daySeconds, Exclusivity: ex}, true},
+		{"edge of window", ex, &Deal{AdvertiserId: "other", Assigned: 1, Completed: now - 10*daySeconds, Exclusivity: ex}, true},
+		{"outside window", ex, &Deal{AdvertiserId: "other", Assigned: 1, Completed: now - 11*daySeconds, Exclusivity: ex}, false},
+		// Windows apply before and after
+		{"after", ex, &Deal{AdvertiserId: "other", Assigned: 1, Completed: now + 5*daySeconds, Exclusivity: ex}, true},
+		// The longest window of the two campaigns wins
+		{"deal window", ex, &Deal{AdvertiserId: "other", Assigned: 1, Completed: now - 20*daySeconds, Exclusivity: &Exclusivity{Category: "beverage", Days: 30}}, true},
+		{"campaign window", &Exclusivity{Category: "beverage", Days: 30}, &Deal{AdvertiserId: "other", Assigned: 1, Completed: now - 20*daySeconds, Exclusivity: &Exclusivity{Category: "beverage"}}, true},
+	}
+
+	for _, ts := range tests {
+		if v := ts.ex.ConflictsWith(ts.d, "adv", now); v != ts.conf {
+			t.Errorf("%s: wanted %v, got %v", ts.name, ts.conf, v)
+		}
+	}
+}
+
+func TestExclusivityValidate(t *testing.T) {
+	ex := &Exclusivity{Category: " Beverage ", Days: 10}
+	if err := ex.Validate(); err != nil || ex.Category != "beverage" {
+		t.Fatalf("unexpected exclusivity: %+v %v", ex, err)
+	}
+
+	for _, ex := range []*Exclusivity{{}, {Category: "  "}, {Category: "beverage", Days: -1}} {
+		if err := ex.Validate(); err != ErrExclusivity {
+			t.Errorf("%+v: wanted %v, got %v", ex, ErrExclusivity, err)
+		}
+	}
+
+	if snap := (&Exclusivity{}).Snapshot(); snap != nil {
+		t.Fatalf("expected no snapshot, got %+v", snap)
+	}
+
+	if snap := ex.Snapshot(); snap == ex || *snap != *ex {
+		t.Fatalf("expected a copy, got %+v", snap)
+	}
+}
@@ -0,0 +1,182 @@
+package common
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+const (
+	DEVICE_IOS     = "ios"
+	DEVICE_ANDROID = "android"
+)
+
+var (
+	ErrLinkTemplate = errors.New("Please provide valid destination links")
+)
+
+// App schemes destinations can deep link to besides http(s).. anything
+// else (i.e. javascript: or data:) could run in the click domain
+var appSchemes = map[string]bool{
+	"itms-apps":   true,
+	"itms-appss":  true,
+	"market":      true,
+	"intent":      true,
+	"fb":          true,
+	"instagram":   true,
+	"twitter":     true,
+	"youtube":     true,
+	"vnd.youtube": true,
+	"snapchat":    true,
+	"pinterest":   true,
+	"spotify":     true,
+	"amazon":      true,
+	"tiktok":      true,
+}
+
+// LinkTemplate controls where a deal's short link sends clicks. Links
+// and param values may use the placeholders {influencer_id}, {platform},
+// {deal_id}, {campaign}, {campaign_id} and {variant}
+type LinkTemplate struct {
+	// Query params added to every http destination (e.g. UTM params)
+	Params map[string]string `json:"params,omitempty"`
+
+	// Destinations keyed off of social network or device (ios/android).
+	// Device destinations win over network ones
+	Platforms map[string]string `json:"platforms,omitempty"`
+	Devices   map[string]string `json:"devices,omitempty"`
+
+	// Web URL to use when a deep link (non http destination) can't be opened
+	Fallback string `json:"fallback,omitempty"`
+}
+
+func (lt *LinkTemplate) Validate() error {
+	if lt == nil {
+		return nil
+	}
+
+	for _, link := range lt.Platforms {
+		if !IsValidLink(link) {
+			return ErrLinkTemplate
+		}
+	}
+
+	for _, link := range lt.Devices {
+		if !IsValidLink(link) {
+			return ErrLinkTemplate
+		}
+	}
+
+	if lt.Fallback != "" && !isWebLink(lt.Fallback) {
+		return ErrLinkTemplate
+	}
+
+	return nil
+}
+
+func (lt *LinkTemplate) hasLink() bool {
+	return lt != nil && (len(lt.Platforms) > 0 || len(lt.Devices) > 0)
+}
+
+// GetDestination returns the fully expanded URL a click on the deal
+// should be sent to along with a web fallback for deep links
+func (cmp *Campaign) GetDestination(d *Deal, ua string) (string, string) {
+	var (
+		lt       = cmp.LinkTemplate
+		platform = d.AssignedPlatform
+		dest     string
+	)
+
+	if platform == "" && len(d.Platforms) > 0 {
+		platform = d.Platforms[0]
+	}
+
+	if lt != nil {
+		if link := lt.Devices[GetDevice(ua)]; link != "" {
+			dest = link
+		} else if link := lt.Platforms[platform]; link != "" {
+			dest = link
+		}
+	}
+
+	if dest == "" {
+		dest = cmp.GetLink(d.VariantId)
+	}
+
+	if dest == "" {
+		return "", ""
+	}
+
+	r := getPlaceholders(cmp, d, platform, true)
+	dest = r.Replace(dest)
+	if lt == nil {
+		return dest, ""
+	}
+
+	if len(lt.Params) > 0 && isWebLink(dest) {
+		if u, err := url.Parse(dest); err == nil {
+			// Values are escaped by Encode
+			raw := getPlaceholders(cmp, d, platform, false)
+			q := u.Query()
+			for k, v := range lt.Params {
+				q.Set(k, raw.Replace(v))
+			}
+			u.RawQuery = q.Encode()
+			dest = u.String()
+		}
+	}
+
+	var fallback string
+	if !isWebLink(dest) && lt.Fallback != "" {
+		fallback = r.Replace(lt.Fallback)
+	}
+
+	return dest, fallback
+}
+
+func getPlaceholders(cmp *Campaign, d *Deal, platform string, escape bool) *strings.Replacer {
+	values := []string{
+		"{influencer_id}", d.InfluencerId,
+		"{platform}", platform,
+		"{deal_id}", d.Id,
+		"{campaign}", cmp.Name,
+		"{campaign_id}", cmp.Id,
+		"{variant}", d.VariantId,
+	}
+
+	if escape {
+		for i := 1; i < len(values); i += 2 {
+			values[i] = url.QueryEscape(values[i])
+		}
+	}
+
+	return strings.NewReplacer(values...)
+}
+
+// GetDevice returns the mobile OS for the user agent if there is one
+func GetDevice(ua string) string {
+	ua = strings.ToLower(ua)
+	switch {
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"), strings.Contains(ua, "ipod"):
+		return DEVICE_IOS
+	case strings.Contains(ua, "android"):
+		return DEVICE_ANDROID
+	}
+	return ""
+}
+
+func isWebLink(link string) bool {
+	lower := strings.ToLower(link)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// IsValidLink returns whether the link is http(s) or opens a known app
+func IsValidLink(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	return scheme == "http" || scheme == "https" || appSchemes[scheme]
+}
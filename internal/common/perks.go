@@ -1,6 +1,37 @@
 package common
 
-import "github.com/swayops/sway/platforms/lob"
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/swayops/sway/config"
+	"github.com/swayops/sway/internal/shipping"
+	"github.com/swayops/sway/platforms/lob"
+)
+
+const (
+	// Stock ledger entry types
+	STOCK_RECEIPT = "receipt" // Product received by Sway (or coupons added)
+	STOCK_RESERVE = "reserve" // Held for an influencer at assignDeal
+	STOCK_RELEASE = "release" // Returned to stock when a deal is cleared
+	STOCK_SHIP    = "ship"    // Mailed out to the influencer
+	STOCK_ADJUST  = "adjust"  // Advertiser lowered their stock
+
+	// Variants at or below this count trigger a low stock
+	// email if the perk doesn't set its own threshold
+	DEFAULT_LOW_STOCK = 3
+)
+
+var (
+	ErrPickVariant = errors.New("Please pick an option (size, color, etc.) for this perk")
+	ErrOutOfStock  = errors.New("Unfortunately, the option you picked is out of stock!")
+	ErrVariants    = errors.New("Perk variants need a unique SKU and a count of 0 or more")
+)
 
 type Perk struct {
 	Name  string   `json:"name,omitempty"`
@@ -8,10 +39,18 @@ type Perk struct {
 	Codes []string `json:"codes,omitempty"` // List of coupon codes that are available
 
 	Category     string `json:"category,omitempty"`     // Set internally
-	Count        int    `json:"count,omitempty"`        // Set internally for coupons
+	Count        int    `json:"count,omitempty"`        // Set internally for coupons and variants
 	PendingCount int    `json:"pendingCount,omitempty"` // Set when the campaign increases perks
 	Instructions string `json:"instructions,omitempty"` // Optional
 
+	// Optional for products. i.e. sizes and colors of a shirt.
+	// Count is the sum of the variants' counts when set
+	Variants []*PerkVariant `json:"variants,omitempty"`
+	LowStock int            `json:"lowStock,omitempty"` // Alert threshold per variant
+
+	// Stock movements waiting to be saved to the campaign's ledger
+	stock []*StockEntry
+
 	// Set once a user picks up a deal.. only set for the
 	// common.Deal.Perk value! not for the campaign.Perks one
 	// since it's set on a per deal basis!
@@ -20,6 +59,56 @@ type Perk struct {
 	Address *lob.AddressLoad `json:"address,omitempty"`
 	Status  bool             `json:"status,omitempty"`
 	Code    string           `json:"code,omitempty"`
+	Expires int32            `json:"expires,omitempty"` // Coupon code expiry
+	SKU     string           `json:"sku,omitempty"`     // Variant the influencer picked
+	Variant string           `json:"variant,omitempty"` // Label for the picked variant
+
+	Shipment *shipping.Shipment `json:"shipment,omitempty"` // Set by approvePerk
+}
+
+// PerkVariant is a single SKU of a product perk with its own stock
+type PerkVariant struct {
+	SKU     string            `json:"sku,omitempty"`
+	Options map[string]string `json:"options,omitempty"` // i.e. {"size": "M", "color": "Black"}
+
+	Count        int `json:"count"`
+	PendingCount int `json:"pendingCount,omitempty"` // Awaiting receipt by admin
+}
+
+// Label returns the options in a stable, readable order. i.e. "color: Black, size: M"
+func (v *PerkVariant) Label() string {
+	if len(v.Options) == 0 {
+		return v.SKU
+	}
+
+	keys := make([]string, 0, len(v.Options))
+	for k := range v.Options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+": "+v.Options[k])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// StockLedger is every stock movement for a campaign's perks. It's kept
+// in its own bucket so the campaign doesn't grow with it
+type StockLedger struct {
+	CampaignId string        `json:"campaignId"`
+	Entries    []*StockEntry `json:"entries"`
+}
+
+type StockEntry struct {
+	Type   string `json:"type,omitempty"`
+	SKU    string `json:"sku,omitempty"`
+	Qty    int    `json:"qty,omitempty"`
+	DealId string `json:"dealId,omitempty"`
+	InfId  string `json:"infId,omitempty"`
+	Note   string `json:"note,omitempty"`
+	Ts     int32  `json:"ts,omitempty"`
 }
 
 func (p *Perk) GetType() string {
@@ -37,3 +126,255 @@ func (p *Perk) IsProduct() bool {
 func (p *Perk) IsCoupon() bool {
 	return p.Type == 2
 }
+
+// GetShipmentStatus returns where the deal's perk is at
+func (p *Perk) GetShipmentStatus() string {
+	if p.Shipment == nil {
+		if p.Status {
+			// Shipped before we tracked shipments
+			return shipping.STATUS_SHIPPED
+		}
+		return shipping.STATUS_PENDING
+	}
+	return p.Shipment.Status
+}
+
+// IsInTransit returns whether the influencer is still waiting on a
+// tracked perk. Shipments stuck past the max transit time don't count
+// so the deal's normal timeout applies
+func (p *Perk) IsInTransit() bool {
+	return p != nil && p.Shipment.IsPending() && !p.Shipment.IsOverdue()
+}
+
+func (p *Perk) HasVariants() bool {
+	return len(p.Variants) > 0
+}
+
+func (p *Perk) GetVariant(sku string) *PerkVariant {
+	for _, v := range p.Variants {
+		if v.SKU == sku {
+			return v
+		}
+	}
+	return nil
+}
+
+// ValidateVariants cleans up the variants and sets the perk
+// count to their total
+func (p *Perk) ValidateVariants() error {
+	if !p.HasVariants() {
+		return nil
+	}
+
+	if !p.IsProduct() {
+		return errors.New("Only product perks can have variants")
+	}
+
+	seen := make(map[string]bool, len(p.Variants))
+	for _, v := range p.Variants {
+		if v == nil {
+			return ErrVariants
+		}
+
+		v.SKU = strings.TrimSpace(v.SKU)
+		if v.SKU == "" || seen[v.SKU] || v.Count < 0 {
+			return ErrVariants
+		}
+		seen[v.SKU] = true
+		v.PendingCount = 0
+	}
+
+	p.UpdateCount()
+	return nil
+}
+
+// UpdateCount sets the perk totals from its variants
+func (p *Perk) UpdateCount() {
+	if !p.HasVariants() {
+		return
+	}
+
+	p.Count, p.PendingCount = 0, 0
+	for _, v := range p.Variants {
+		p.Count += v.Count
+		p.PendingCount += v.PendingCount
+	}
+}
+
+// InStock returns the variants an influencer can still pick
+func (p *Perk) InStock() []*PerkVariant {
+	var out []*PerkVariant
+	for _, v := range p.Variants {
+		if v.Count > 0 {
+			out = append(out, &PerkVariant{SKU: v.SKU, Options: v.Options, Count: v.Count})
+		}
+	}
+	return out
+}
+
+func (p *Perk) record(typ, sku string, qty int, dealId, infId, note string) {
+	p.stock = append(p.stock, &StockEntry{
+		Type:   typ,
+		SKU:    sku,
+		Qty:    qty,
+		DealId: dealId,
+		InfId:  infId,
+		Note:   note,
+		Ts:     int32(time.Now().Unix()),
+	})
+}
+
+// FlushStock returns the stock movements recorded since the
+// last flush so they can be added to the ledger
+func (p *Perk) FlushStock() []*StockEntry {
+	if p == nil {
+		return nil
+	}
+
+	out := p.stock
+	p.stock = nil
+	return out
+}
+
+func GetStockLedgerTx(tx *bolt.Tx, cid string, cfg *config.Config) *StockLedger {
+	l := &StockLedger{CampaignId: cid}
+	if v := tx.Bucket([]byte(cfg.Bucket.Stock)).Get([]byte(cid)); v != nil {
+		if err := json.Unmarshal(v, l); err != nil {
+			log.Println("error when unmarshalling stock ledger", cid, err)
+		}
+	}
+	return l
+}
+
+// RecordStock records the perk's starting stock as receipts
+func (p *Perk) RecordStock(note string) {
+	if !p.HasVariants() {
+		if p.Count > 0 {
+			p.record(STOCK_RECEIPT, "", p.Count, "", "", note)
+		}
+		return
+	}
+
+	for _, v := range p.Variants {
+		if v.Count > 0 {
+			p.record(STOCK_RECEIPT, v.SKU, v.Count, "", "", note)
+		}
+	}
+}
+
+// Receive moves pending stock (added after the campaign was
+// created) into the available count once admin has it in hand
+func (p *Perk) Receive(note string) {
+	if !p.HasVariants() {
+		if p.PendingCount > 0 {
+			p.record(STOCK_RECEIPT, "", p.PendingCount, "", "", note)
+		}
+		p.Count += p.PendingCount
+		p.PendingCount = 0
+		return
+	}
+
+	for _, v := range p.Variants {
+		if v.PendingCount > 0 {
+			p.record(STOCK_RECEIPT, v.SKU, v.PendingCount, "", "", note)
+		}
+		v.Count += v.PendingCount
+		v.PendingCount = 0
+	}
+	p.UpdateCount()
+}
+
+// Adjust lowers available stock for the variant (or the whole
+// perk if sku is empty)
+func (p *Perk) Adjust(sku string, qty int, note string) error {
+	if sku == "" {
+		if qty > p.Count {
+			return ErrOutOfStock
+		}
+		p.Count -= qty
+	} else {
+		v := p.GetVariant(sku)
+		if v == nil || qty > v.Count {
+			return ErrOutOfStock
+		}
+		v.Count -= qty
+		p.UpdateCount()
+	}
+
+	p.record(STOCK_ADJUST, sku, -qty, "", "", note)
+	return nil
+}
+
+// Reserve holds one unit of the picked variant for the deal and
+// returns it. Perks without variants ignore the sku
+func (p *Perk) Reserve(sku, dealId, infId string) (*PerkVariant, error) {
+	if !p.HasVariants() {
+		if p.Count == 0 {
+			return nil, ErrOutOfStock
+		}
+		p.Count -= 1
+		p.record(STOCK_RESERVE, "", 1, dealId, infId, "")
+		return nil, nil
+	}
+
+	if sku == "" {
+		return nil, ErrPickVariant
+	}
+
+	v := p.GetVariant(sku)
+	if v == nil {
+		return nil, ErrPickVariant
+	}
+
+	if v.Count == 0 {
+		return nil, ErrOutOfStock
+	}
+
+	v.Count -= 1
+	p.UpdateCount()
+	p.record(STOCK_RESERVE, sku, 1, dealId, infId, "")
+	return v, nil
+}
+
+// Release puts a deal's reserved perk back into stock. Coupon
+// codes go back through the campaign's CouponPool
+func (p *Perk) Release(dp *Perk, dealId string) {
+	if v := p.GetVariant(dp.SKU); v != nil {
+		v.Count += dp.Count
+		p.UpdateCount()
+	} else {
+		p.Count += dp.Count
+	}
+
+	p.record(STOCK_RELEASE, dp.SKU, dp.Count, dealId, dp.InfId, "")
+}
+
+// Ship records a deal's reserved perk leaving the warehouse
+func (p *Perk) Ship(dp *Perk, dealId string) {
+	p.record(STOCK_SHIP, dp.SKU, dp.Count, dealId, dp.InfId, "")
+}
+
+// IsLowStock returns whether the variant just hit the alert
+// threshold or ran out
+func (p *Perk) IsLowStock(v *PerkVariant) bool {
+	if v == nil {
+		return false
+	}
+
+	threshold := p.LowStock
+	if threshold <= 0 {
+		threshold = DEFAULT_LOW_STOCK
+	}
+	return v.Count == threshold || v.Count == 0
+}
+
+// Booked returns how many units of each SKU are held by deals
+func Booked(deals map[string]*Deal) map[string]int {
+	booked := make(map[string]int)
+	for _, d := range deals {
+		if d.Perk != nil {
+			booked[d.Perk.SKU] += d.Perk.Count
+		}
+	}
+	return booked
+}
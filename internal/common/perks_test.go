@@ -0,0 +1,190 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newVariantPerk() *Perk {
+	return &Perk{
+		Type: 1,
+		Variants: []*PerkVariant{
+			{SKU: " shirt-m ", Options: map[string]string{"size": "M", "color": "Black"}, Count: 4},
+			{SKU: "shirt-l", Options: map[string]string{"size": "L"}, Count: 1},
+		},
+	}
+}
+
+func TestValidateVariants(t *testing.T) {
+	p := newVariantPerk()
+	p.Variants[0].PendingCount = 5
+	if err := p.ValidateVariants(); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Variants[0].SKU != "shirt-m" || p.Variants[0].PendingCount != 0 || p.Count != 5 {
+		t.Fatalf("unexpected perk: %+v %+v", p, p.Variants[0])
+	}
+
+	tests := []struct {
+		p  *Perk
+		ex error
+	}{
+		{&Perk{Type: 1}, nil},
+		{&Perk{Type: 1, Variants: []*PerkVariant{nil}}, ErrVariants},
+		{&Perk{Type: 1, Variants: []*PerkVariant{{SKU: " "}}}, ErrVariants},
+		{&Perk{Type: 1, Variants: []*PerkVariant{{SKU: "a"}, {SKU: "a"}}}, ErrVariants},
+		{&Perk{Type: 1, Variants: []*PerkVariant{{SKU: "a", Count: -1}}}, ErrVariants},
+	}
+
+	for i, ts := range tests {
+		if err := ts.p.ValidateVariants(); err != ts.ex {
+			t.Errorf("%d: wanted %v, got %v", i, ts.ex, err)
+		}
+	}
+
+	if err := (&Perk{Type: 2, Variants: []*PerkVariant{{SKU: "a"}}}).ValidateVariants(); err == nil {
+		t.Error("expected coupon variants to fail")
+	}
+}
+
+func TestVariantLabel(t *testing.T) {
+	tests := []struct {
+		v  *PerkVariant
+		ex string
+	}{
+		{&PerkVariant{SKU: "sku"}, "sku"},
+		{&PerkVariant{SKU: "sku", Options: map[string]string{"size": "M", "color": "Black"}}, "color: Black, size: M"},
+	}
+
+	for _, ts := range tests {
+		if v := ts.v.Label(); v != ts.ex {
+			t.Errorf("wanted %q, got %q", ts.ex, v)
+		}
+	}
+}
+
+func TestReserveRelease(t *testing.T) {
+	p := newVariantPerk()
+	if err := p.ValidateVariants(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.Reserve("", "1", "inf"); err != ErrPickVariant {
+		t.Fatalf("wanted %v, got %v", ErrPickVariant, err)
+	}
+
+	if _, err := p.Reserve("shirt-xl", "1", "inf"); err != ErrPickVariant {
+		t.Fatalf("wanted %v, got %v", ErrPickVariant, err)
+	}
+
+	v, err := p.Reserve("shirt-l", "1", "inf")
+	if err != nil || v == nil || v.Count != 0 || p.Count != 4 {
+		t.Fatalf("unexpected reserve: %+v %v (count %d)", v, err, p.Count)
+	}
+
+	if !p.IsLowStock(v) {
+		t.Fatal("expected an empty variant to be low on stock")
+	}
+
+	if _, err = p.Reserve("shirt-l", "2", "inf2"); err != ErrOutOfStock {
+		t.Fatalf("wanted %v, got %v", ErrOutOfStock, err)
+	}
+
+	p.Release(&Perk{SKU: "shirt-l", Count: 1, InfId: "inf"}, "1")
+	if v.Count != 1 || p.Count != 5 {
+		t.Fatalf("unexpected counts after release: %d %d", v.Count, p.Count)
+	}
+
+	// Perks without variants ignore the sku
+	plain := &Perk{Type: 1, Count: 1}
+	if v, err := plain.Reserve("anything", "1", "inf"); v != nil || err != nil || plain.Count != 0 {
+		t.Fatalf("unexpected reserve: %+v %v (count %d)", v, err, plain.Count)
+	}
+
+	if _, err := plain.Reserve("", "2", "inf"); err != ErrOutOfStock {
+		t.Fatalf("wanted %v, got %v", ErrOutOfStock, err)
+	}
+}
+
+func TestReceiveAdjust(t *testing.T) {
+	p := newVariantPerk()
+	if err := p.ValidateVariants(); err != nil {
+		t.Fatal(err)
+	}
+
+	p.Variants[1].PendingCount = 3
+	p.UpdateCount()
+	if p.Count != 5 || p.PendingCount != 3 {
+		t.Fatalf("unexpected counts: %d %d", p.Count, p.PendingCount)
+	}
+
+	p.Receive("Received")
+	if p.Count != 8 || p.PendingCount != 0 || p.Variants[1].Count != 4 {
+		t.Fatalf("unexpected counts after receipt: %d %d", p.Count, p.PendingCount)
+	}
+
+	if err := p.Adjust("shirt-m", 5, "Lowered"); err != ErrOutOfStock {
+		t.Fatalf("wanted %v, got %v", ErrOutOfStock, err)
+	}
+
+	if err := p.Adjust("shirt-m", 2, "Lowered"); err != nil || p.Count != 6 {
+		t.Fatalf("unexpected adjust: %v (count %d)", err, p.Count)
+	}
+
+	plain := &Perk{Type: 1, Count: 2, PendingCount: 3}
+	plain.Receive("Received")
+	if plain.Count != 5 || plain.PendingCount != 0 {
+		t.Fatalf("unexpected counts after receipt: %d %d", plain.Count, plain.PendingCount)
+	}
+}
+
+func TestFlushStock(t *testing.T) {
+	p := newVariantPerk()
+	if err := p.ValidateVariants(); err != nil {
+		t.Fatal(err)
+	}
+
+	p.RecordStock("Campaign created")
+	p.Reserve("shirt-m", "1", "inf")
+	p.Ship(&Perk{SKU: "shirt-m", Count: 1, InfId: "inf"}, "1")
+
+	var got []StockEntry
+	for _, e := range p.FlushStock() {
+		e.Ts = 0
+		got = append(got, *e)
+	}
+
+	ex := []StockEntry{
+		{Type: STOCK_RECEIPT, SKU: "shirt-m", Qty: 4, Note: "Campaign created"},
+		{Type: STOCK_RECEIPT, SKU: "shirt-l", Qty: 1, Note: "Campaign created"},
+		{Type: STOCK_RESERVE, SKU: "shirt-m", Qty: 1, DealId: "1", InfId: "inf"},
+		{Type: STOCK_SHIP, SKU: "shirt-m", Qty: 1, DealId: "1", InfId: "inf"},
+	}
+
+	if !reflect.DeepEqual(got, ex) {
+		t.Fatalf("wanted %+v, got %+v", ex, got)
+	}
+
+	if out := p.FlushStock(); len(out) != 0 {
+		t.Fatalf("expected flushed entries to be cleared, got %d", len(out))
+	}
+
+	var nilPerk *Perk
+	if out := nilPerk.FlushStock(); out != nil {
+		t.Fatalf("expected nothing from a nil perk, got %v", out)
+	}
+}
+
+func TestBooked(t *testing.T) {
+	deals := map[string]*Deal{
+		"1": {Perk: &Perk{SKU: "a", Count: 1}},
+		"2": {Perk: &Perk{SKU: "a", Count: 1}},
+		"3": {Perk: &Perk{Count: 1}},
+		"4": {},
+	}
+
+	if v := Booked(deals); !reflect.DeepEqual(v, map[string]int{"a": 2, "": 1}) {
+		t.Fatalf("unexpected booked counts: %v", v)
+	}
+}
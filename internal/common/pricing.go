@@ -0,0 +1,116 @@
+package common
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	PRICING_ENGAGEMENT = "engagement" // Default.. paid once based off of avg engagements
+	PRICING_CPC        = "cpc"        // Paid per approved click
+	PRICING_CPA        = "cpa"        // Paid per conversion
+	PRICING_FLAT       = "flat"       // Fixed fee per post
+
+	DEFAULT_ATTRIBUTION_DAYS = 30
+)
+
+var (
+	ErrPricingModel = errors.New("Invalid pricing model")
+	ErrPricingRate  = errors.New("Please provide a valid rate for the pricing model")
+)
+
+// Pricing is how the advertiser pays for a campaign's deals
+type Pricing struct {
+	Model string `json:"model,omitempty"`
+	// Advertiser spend per click/conversion or the flat fee per post
+	Rate float64 `json:"rate,omitempty"`
+	// Days after the post that clicks/conversions are billed for
+	AttributionDays int `json:"attributionDays,omitempty"`
+}
+
+func (p *Pricing) Validate() error {
+	if p == nil {
+		return nil
+	}
+
+	switch p.GetModel() {
+	case PRICING_ENGAGEMENT:
+		return nil
+	case PRICING_CPC, PRICING_CPA, PRICING_FLAT:
+		if p.Rate <= 0 {
+			return ErrPricingRate
+		}
+		return nil
+	}
+
+	return ErrPricingModel
+}
+
+func (p *Pricing) GetModel() string {
+	if p == nil || p.Model == "" {
+		return PRICING_ENGAGEMENT
+	}
+	return p.Model
+}
+
+// IsPerformance returns whether spend accrues over the attribution window
+// rather than being paid once
+func (p *Pricing) IsPerformance() bool {
+	model := p.GetModel()
+	return model == PRICING_CPC || model == PRICING_CPA
+}
+
+func (p *Pricing) GetAttributionDays() int {
+	if p == nil || p.AttributionDays <= 0 {
+		return DEFAULT_ATTRIBUTION_DAYS
+	}
+	return p.AttributionDays
+}
+
+// GetWindowEnd returns the TS billing stops for a post made at the given TS
+func (p *Pricing) GetWindowEnd(completed int32) int32 {
+	return completed + int32(p.GetAttributionDays())*daySeconds
+}
+
+// Snapshot returns a copy of the campaign's pricing for a deal
+func (p *Pricing) Snapshot() *Pricing {
+	if p == nil {
+		return nil
+	}
+
+	snap := *p
+	return &snap
+}
+
+// GetBillableUnits returns the total amount of clicks or conversions
+// for the deal within its attribution window
+func (d *Deal) GetBillableUnits() int32 {
+	var (
+		units  int32
+		end    = d.Pricing.GetWindowEnd(d.Completed)
+		model  = d.Pricing.GetModel()
+		totals = d.TotalStats()
+	)
+
+	switch model {
+	case PRICING_CPC:
+		for _, cl := range totals.ApprovedClicks {
+			if cl.TS >= d.Completed && cl.TS <= end {
+				units += 1
+			}
+		}
+	case PRICING_CPA:
+		for _, conv := range totals.Conversions {
+			if conv.TS >= int64(d.Completed) && conv.TS <= int64(end) {
+				units += 1
+			}
+		}
+	}
+
+	return units
+}
+
+// IsWindowClosed returns whether the deal can no longer accrue spend
+func (d *Deal) IsWindowClosed() bool {
+	return int32(time.Now().Unix()) > d.Pricing.GetWindowEnd(d.Completed)
+}
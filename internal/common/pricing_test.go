@@ -0,0 +1,74 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/swayops/converter/pixel"
+)
+
+func TestGetBillableUnits(t *testing.T) {
+	var (
+		now       = int32(time.Now().Unix())
+		completed = now - 10*daySeconds
+		end       = completed + 5*daySeconds
+		stats     = &Stats{
+			ApprovedClicks: []*Click{{TS: completed - 1}, {TS: completed}, {TS: now - daySeconds}, {TS: end}, {TS: end + 1}},
+			PendingClicks:  []*Click{{TS: completed}},
+			Conversions:    []pixel.Conversion{{TS: int64(completed + 1)}, {TS: int64(end + 1)}},
+		}
+	)
+
+	tests := []struct {
+		pricing *Pricing
+		units   int32
+		closed  bool
+	}{
+		{nil, 0, false},
+		{&Pricing{Model: PRICING_FLAT, Rate: 10}, 0, false},
+		// Only approved clicks within the window
+		{&Pricing{Model: PRICING_CPC, Rate: 1, AttributionDays: 5}, 2, true},
+		{&Pricing{Model: PRICING_CPC, Rate: 1}, 4, false},
+		{&Pricing{Model: PRICING_CPA, Rate: 1, AttributionDays: 5}, 1, true},
+	}
+
+	for _, ts := range tests {
+		d := &Deal{Completed: completed, Pricing: ts.pricing, Reporting: map[string]*Stats{"1": stats}}
+		if v := d.GetBillableUnits(); v != ts.units {
+			t.Errorf("%s: wanted %d units, got %d", ts.pricing.GetModel(), ts.units, v)
+		}
+
+		// Only the 5 day windows have closed
+		if ts.pricing.GetAttributionDays() == 5 && d.IsWindowClosed() != ts.closed {
+			t.Errorf("%s: wanted closed %v", ts.pricing.GetModel(), ts.closed)
+		}
+	}
+
+	if (&Deal{Completed: completed, Pricing: &Pricing{Model: PRICING_CPC, Rate: 1}}).IsWindowClosed() {
+		t.Fatal("expected the default window to still be open")
+	}
+}
+
+func TestPricingValidate(t *testing.T) {
+	tests := []struct {
+		p  *Pricing
+		ex error
+	}{
+		{nil, nil},
+		{&Pricing{}, nil},
+		{&Pricing{Model: PRICING_CPC, Rate: 0.5}, nil},
+		{&Pricing{Model: PRICING_CPA}, ErrPricingRate},
+		{&Pricing{Model: PRICING_FLAT, Rate: -1}, ErrPricingRate},
+		{&Pricing{Model: "cpm", Rate: 1}, ErrPricingModel},
+	}
+
+	for _, ts := range tests {
+		if err := ts.p.Validate(); err != ts.ex {
+			t.Errorf("%+v: wanted %v, got %v", ts.p, ts.ex, err)
+		}
+	}
+
+	if (&Pricing{Model: PRICING_FLAT}).IsPerformance() || !(&Pricing{Model: PRICING_CPA}).IsPerformance() {
+		t.Fatal("unexpected IsPerformance")
+	}
+}
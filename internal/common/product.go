@@ -0,0 +1,115 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Imagga confidence (0-100) an expected tag needs before we consider
+// the product to be in the photo
+const DEFAULT_VISUAL_CONFIDENCE = 30.0
+
+var ErrVisualConfidence = errors.New("Visual confidence has to be between 0 and 100")
+
+// ProductCheck is the outcome of looking for the campaign's product in
+// the post's image.. saved on the deal
+type ProductCheck struct {
+	Image         string             `json:"image,omitempty"`
+	Expected      []string           `json:"expected,omitempty"`
+	MinConfidence float64            `json:"minConfidence,omitempty"`
+	Found         map[string]float64 `json:"found,omitempty"` // Expected tags in the image and their confidence
+
+	Pass   bool   `json:"pass"`
+	Reason string `json:"reason,omitempty"` // Why it failed
+
+	Checked int32 `json:"checked,omitempty"`
+}
+
+// CheckProduct compares the image's tags against the expected ones.. an
+// expected tag matches a tag or any word in it (i.e. "shoe" matches
+// "running shoe"). One match over the confidence is enough to pass.
+// Returns nil if there are no tags to look at
+func CheckProduct(image string, expected []string, minConfidence float64, tags map[string]float64) *ProductCheck {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	if minConfidence <= 0 {
+		minConfidence = DEFAULT_VISUAL_CONFIDENCE
+	}
+
+	pc := &ProductCheck{
+		Image:         image,
+		Expected:      expected,
+		MinConfidence: minConfidence,
+		Checked:       int32(time.Now().Unix()),
+	}
+
+	var best float64
+	for _, exp := range expected {
+		for tag, conf := range tags {
+			if conf <= pc.Found[exp] || !tagMatches(tag, exp) {
+				continue
+			}
+
+			if pc.Found == nil {
+				pc.Found = make(map[string]float64)
+			}
+			pc.Found[exp] = conf
+
+			if conf > best {
+				best = conf
+			}
+		}
+	}
+
+	if best >= minConfidence {
+		pc.Pass = true
+		return pc
+	}
+
+	if len(pc.Found) == 0 {
+		pc.Reason = "Product not found in image (expected " + strings.Join(expected, " or ") + ")"
+	} else {
+		pc.Reason = fmt.Sprintf("Product confidence too low (%s)", pc.matches())
+	}
+	return pc
+}
+
+func tagMatches(tag, expected string) bool {
+	if tag == expected {
+		return true
+	}
+
+	for _, w := range strings.Fields(tag) {
+		if w == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns the found tags as "tag 12" sorted by confidence.. no
+// commas or slashes since fraud reasons end up in strike URLs
+func (pc *ProductCheck) matches() string {
+	found := make([]string, 0, len(pc.Found))
+	for tag := range pc.Found {
+		found = append(found, tag)
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if pc.Found[a] != pc.Found[b] {
+			return pc.Found[a] > pc.Found[b]
+		}
+		return a < b
+	})
+
+	for i, tag := range found {
+		found[i] = fmt.Sprintf("%s %.0f", tag, pc.Found[tag])
+	}
+	return strings.Join(found, " and ")
+}
@@ -0,0 +1,69 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagMatches(t *testing.T) {
+	tests := []struct {
+		tag, expected string
+		ex            bool
+	}{
+		{"shoe", "shoe", true},
+		{"running shoe", "shoe", true},
+		{"shoelace", "shoe", false},
+		{"shoes", "shoe", false},
+		{"running shoe", "running shoe", true},
+		{"shoe", "running shoe", false},
+	}
+
+	for _, ts := range tests {
+		if v := tagMatches(ts.tag, ts.expected); v != ts.ex {
+			t.Errorf("%q/%q: wanted %v, got %v", ts.tag, ts.expected, ts.ex, v)
+		}
+	}
+}
+
+func TestCheckProduct(t *testing.T) {
+	if pc := CheckProduct("img", []string{"shoe"}, 0, nil); pc != nil {
+		t.Fatalf("expected no check without tags, got %+v", pc)
+	}
+
+	tags := map[string]float64{
+		"running shoe": 45,
+		"shoe":         20,
+		"footwear":     25,
+		"grass":        80,
+	}
+
+	tests := []struct {
+		name       string
+		expected   []string
+		confidence float64
+		pass       bool
+		found      map[string]float64
+		reason     string
+	}{
+		{"default confidence", []string{"shoe"}, 0, true, map[string]float64{"shoe": 45}, ""},
+		{"any tag passes", []string{"bottle", "footwear"}, 20, true, map[string]float64{"footwear": 25}, ""},
+		{"too low", []string{"shoe", "footwear"}, 50, false, map[string]float64{"shoe": 45, "footwear": 25}, "Product confidence too low (shoe 45 and footwear 25)"},
+		{"not found", []string{"bottle", "can"}, 0, false, nil, "Product not found in image (expected bottle or can)"},
+	}
+
+	for _, ts := range tests {
+		pc := CheckProduct("img", ts.expected, ts.confidence, tags)
+		if pc == nil {
+			t.Errorf("%s: expected a check", ts.name)
+			continue
+		}
+
+		if pc.Pass != ts.pass || pc.Reason != ts.reason || !reflect.DeepEqual(pc.Found, ts.found) {
+			t.Errorf("%s: wanted %v %v %q, got %+v", ts.name, ts.pass, ts.found, ts.reason, pc)
+		}
+
+		if pc.Image != "img" || pc.Checked == 0 || (ts.confidence == 0 && pc.MinConfidence != DEFAULT_VISUAL_CONFIDENCE) {
+			t.Errorf("%s: unexpected check %+v", ts.name, pc)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package common
+
+// Rejection is why GetAvailableDeals didn't offer an influencer a
+// campaign's deal
+type Rejection string
+
+const (
+	// Campaign wide.. these block every influencer
+	REJECT_INVALID     Rejection = "INVALID"
+	REJECT_NO_DEALS    Rejection = "NO_ACTIVE_DEALS"
+	REJECT_BUDGET      Rejection = "BUDGET"
+	REJECT_AVAIL_SPEND Rejection = "AVAIL_SPEND"
+	REJECT_PACING      Rejection = "PACING"
+	REJECT_NO_PERKS    Rejection = "NO_PERKS"
+
+	// Influencer specific
+	REJECT_SUBSCRIPTION  Rejection = "INVALID_SUBSCRIPTION"
+	REJECT_CATEGORY      Rejection = "CAT_NOT_FOUND"
+	REJECT_TARGETING     Rejection = "TARGETING"
+	REJECT_DEAL_FOUND    Rejection = "DEAL_FOUND"
+	REJECT_GEO           Rejection = "GEO_MATCH"
+	REJECT_GENDER        Rejection = "GENDER"
+	REJECT_GENDER_UNI    Rejection = "GENDER_UNI"
+	REJECT_GENDER_F      Rejection = "GENDER_F"
+	REJECT_GENDER_M      Rejection = "GENDER_M"
+	REJECT_ADV_BLACKLIST Rejection = "ADV_BLACKLIST"
+	REJECT_CMP_BLACKLIST Rejection = "CMP_BLACKLIST"
+	REJECT_WHITELIST     Rejection = "CMP_WHITELIST"
+	REJECT_EXCLUSIVITY   Rejection = "EXCLUSIVITY_CONFLICT"
+	REJECT_BRAND_SAFETY  Rejection = "BRAND_SAFETY"
+	REJECT_RISK          Rejection = "RISK_CATEGORY"
+	REJECT_FOLLOWERS     Rejection = "FOLLOWER_TARGETING"
+	REJECT_ENGAGEMENTS   Rejection = "ENG_TARGETING"
+	REJECT_LANGUAGE      Rejection = "LANGUAGE"
+	REJECT_AUDIENCE      Rejection = "AUDIENCE_TARGETING"
+	REJECT_PRICE         Rejection = "PRICE_TARGET"
+	REJECT_OUT_OF_RANGE  Rejection = "OUT_OF_RANGE"
+	REJECT_MAX_YIELD     Rejection = "MAX_YIELD"
+	REJECT_NO_PLATFORM   Rejection = "NO_PLATFORM"
+)
+
+var rejectionLabels = map[Rejection]string{
+	REJECT_INVALID:       "Campaign is off, unapproved or has no budget",
+	REJECT_NO_DEALS:      "No deals left to offer",
+	REJECT_BUDGET:        "Budget is exhausted for this cycle",
+	REJECT_AVAIL_SPEND:   "Pending deals have reserved all spendable budget",
+	REJECT_PACING:        "Today's pacing target has been hit",
+	REJECT_NO_PERKS:      "No perks left to give out",
+	REJECT_SUBSCRIPTION:  "Influencer is too big for the advertiser's plan",
+	REJECT_CATEGORY:      "Categories, keywords or audiences",
+	REJECT_TARGETING:     "Targeting expression",
+	REJECT_DEAL_FOUND:    "Already has or had a deal for this campaign",
+	REJECT_GEO:           "Geo targeting",
+	REJECT_GENDER:        "Gender targeting",
+	REJECT_GENDER_UNI:    "Gender targeting",
+	REJECT_GENDER_F:      "Gender targeting",
+	REJECT_GENDER_M:      "Gender targeting",
+	REJECT_ADV_BLACKLIST: "Advertiser blacklist",
+	REJECT_CMP_BLACKLIST: "Campaign blacklist",
+	REJECT_WHITELIST:     "Not on the campaign whitelist",
+	REJECT_EXCLUSIVITY:   "Recently worked with a competitor",
+	REJECT_BRAND_SAFETY:  "Brand safety",
+	REJECT_RISK:          "Posts about an excluded risk category",
+	REJECT_FOLLOWERS:     "Follower range",
+	REJECT_ENGAGEMENTS:   "Engagement range",
+	REJECT_LANGUAGE:      "Language targeting",
+	REJECT_AUDIENCE:      "Audience demographics",
+	REJECT_PRICE:         "Price range",
+	REJECT_OUT_OF_RANGE:  "Influencer costs more than the remaining budget",
+	REJECT_MAX_YIELD:     "Influencer's price is outside the campaign's target range",
+	REJECT_NO_PLATFORM:   "No recently updated account on a targeted platform",
+}
+
+// Label returns a human readable description of the rejection
+func (r Rejection) Label() string {
+	if l, ok := rejectionLabels[r]; ok {
+		return l
+	}
+	return string(r)
+}
+
+// Reason is a rejection along with anything that explains it
+type Reason struct {
+	Code   Rejection `json:"code"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+func (r *Reason) String() string {
+	if r.Detail == "" {
+		return string(r.Code)
+	}
+	return string(r.Code) + " " + r.Detail
+}
@@ -0,0 +1,122 @@
+package common
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// Days before the license expires that the advertiser is reminded
+	RIGHTS_REMINDER_DAYS = 7
+)
+
+var (
+	ErrRights            = errors.New("Please provide valid usage rights")
+	ErrUplift            = errors.New("Usage rights uplift cannot be negative")
+	ErrRightsExclusivity = errors.New("Please provide an exclusivity category for exclusive usage rights")
+)
+
+// UsageRights are the content licensing terms a campaign asks for.
+// Deals keep a snapshot of the rights the influencer accepted so later
+// campaign edits don't change what was agreed to
+type UsageRights struct {
+	Organic     bool     `json:"organic,omitempty"`     // Advertiser may repost on their own channels
+	Paid        bool     `json:"paid,omitempty"`        // Advertiser may whitelist the post for paid ads
+	Days        int      `json:"days,omitempty"`        // Length of the license.. 0 is perpetual
+	Territories []string `json:"territories,omitempty"` // Country codes.. empty is worldwide
+	Exclusivity int      `json:"exclusivity,omitempty"` // Days the influencer can't work with competitors
+
+	// Price uplift per right as a fraction of the deal value (0.2 = 20%)
+	OrganicUplift     float64 `json:"organicUplift,omitempty"`
+	PaidUplift        float64 `json:"paidUplift,omitempty"`
+	ExclusivityUplift float64 `json:"exclusivityUplift,omitempty"`
+
+	// Only set on the deal's snapshot
+	Accepted int32 `json:"accepted,omitempty"` // TS the influencer accepted the rights
+	Notified bool  `json:"notified,omitempty"` // Whether the advertiser was reminded of expiry
+}
+
+func (r *UsageRights) IsSet() bool {
+	return r != nil && (r.Organic || r.Paid || r.Exclusivity > 0)
+}
+
+// Validate checks every uplift on its own (including rights that are
+// off) and that exclusive rights have a category to be exclusive in
+func (r *UsageRights) Validate(ex *Exclusivity) error {
+	if r == nil {
+		return nil
+	}
+
+	if r.Days < 0 || r.Exclusivity < 0 {
+		return ErrRights
+	}
+
+	if r.OrganicUplift < 0 || r.PaidUplift < 0 || r.ExclusivityUplift < 0 {
+		return ErrUplift
+	}
+
+	if r.Exclusivity > 0 && !ex.IsSet() {
+		return ErrRightsExclusivity
+	}
+	return nil
+}
+
+// GetUplift returns the total uplift for all rights requested
+func (r *UsageRights) GetUplift() float64 {
+	if r == nil {
+		return 0
+	}
+
+	var uplift float64
+	if r.Organic {
+		uplift += r.OrganicUplift
+	}
+
+	if r.Paid {
+		uplift += r.PaidUplift
+	}
+
+	if r.Exclusivity > 0 {
+		uplift += r.ExclusivityUplift
+	}
+
+	return uplift
+}
+
+// Snapshot returns a copy of the campaign's rights for a deal
+func (r *UsageRights) Snapshot() *UsageRights {
+	if !r.IsSet() {
+		return nil
+	}
+
+	snap := *r
+	snap.Territories = append([]string(nil), r.Territories...)
+	snap.Accepted = 0
+	snap.Notified = false
+	return &snap
+}
+
+// GetExpiry returns the TS the license runs out given the TS the
+// post went live. 0 means it never expires
+func (r *UsageRights) GetExpiry(from int32) int32 {
+	if r == nil || r.Days <= 0 || from == 0 {
+		return 0
+	}
+	return from + int32(r.Days)*daySeconds
+}
+
+// IsExpiring returns whether the license runs out within the reminder window
+func (r *UsageRights) IsExpiring(from int32) bool {
+	expiry := r.GetExpiry(from)
+	if expiry == 0 {
+		return false
+	}
+
+	now := int32(time.Now().Unix())
+	return now < expiry && now > expiry-RIGHTS_REMINDER_DAYS*daySeconds
+}
+
+// GetRightsExpiry returns when the deal's content license expires
+func (d *Deal) GetRightsExpiry() int32 {
+	return d.Rights.GetExpiry(d.Completed)
+}
@@ -0,0 +1,71 @@
+package common
+
+import "testing"
+
+func TestRightsValidate(t *testing.T) {
+	ex := &Exclusivity{Category: "beverage", Days: 10}
+
+	tests := []struct {
+		name string
+		r    *UsageRights
+		ex   *Exclusivity
+		err  error
+	}{
+		{"none", nil, nil, nil},
+		{"organic", &UsageRights{Organic: true, OrganicUplift: 0.2}, nil, nil},
+		{"negative days", &UsageRights{Organic: true, Days: -1}, nil, ErrRights},
+		{"negative uplift", &UsageRights{Organic: true, OrganicUplift: -0.1}, nil, ErrUplift},
+		// Disabled rights are still checked
+		{"disabled right", &UsageRights{Organic: true, PaidUplift: -0.1}, nil, ErrUplift},
+		// A positive uplift can't hide a negative one
+		{"hidden", &UsageRights{Organic: true, Paid: true, OrganicUplift: 0.5, PaidUplift: -0.2}, nil, ErrUplift},
+		{"exclusivity uplift", &UsageRights{Exclusivity: 30, ExclusivityUplift: -0.1}, ex, ErrUplift},
+		{"no category", &UsageRights{Exclusivity: 30, ExclusivityUplift: 0.3}, nil, ErrRightsExclusivity},
+		{"exclusive", &UsageRights{Exclusivity: 30, ExclusivityUplift: 0.3}, ex, nil},
+	}
+
+	for _, ts := range tests {
+		if err := ts.r.Validate(ts.ex); err != ts.err {
+			t.Errorf("%s: wanted %v, got %v", ts.name, ts.err, err)
+		}
+	}
+}
+
+func TestGetExclusivity(t *testing.T) {
+	tests := []struct {
+		name string
+		cmp  *Campaign
+		days int32
+	}{
+		{"category only", &Campaign{Exclusivity: &Exclusivity{Category: "beverage"}}, 0},
+		{"rights", &Campaign{Exclusivity: &Exclusivity{Category: "beverage", Days: 10}, Rights: &UsageRights{Exclusivity: 30}}, 30},
+		{"longer window", &Campaign{Exclusivity: &Exclusivity{Category: "beverage", Days: 60}, Rights: &UsageRights{Exclusivity: 30}}, 60},
+	}
+
+	for _, ts := range tests {
+		if ex := ts.cmp.GetExclusivity(); ex.Days != ts.days || ex.Category != "beverage" {
+			t.Errorf("%s: wanted %d days, got %+v", ts.name, ts.days, ex)
+		}
+	}
+
+	// The campaign's own exclusivity is left alone
+	cmp := tests[1].cmp
+	if cmp.Exclusivity.Days != 10 {
+		t.Fatalf("campaign exclusivity changed: %+v", cmp.Exclusivity)
+	}
+
+	if ex := (&Campaign{Rights: &UsageRights{Exclusivity: 30}}).GetExclusivity(); ex.IsSet() {
+		t.Fatalf("expected no exclusivity without a category, got %+v", ex)
+	}
+
+	// Deals with a competitor completed 20 days ago conflict through the
+	// window bought with the usage rights
+	var (
+		now   = int32(1500000000)
+		other = &Deal{AdvertiserId: "other", Assigned: 1, Completed: now - 20*daySeconds, Exclusivity: &Exclusivity{Category: "beverage"}}
+	)
+
+	if cmp.Exclusivity.ConflictsWith(other, "adv", now) || !cmp.GetExclusivity().ConflictsWith(other, "adv", now) {
+		t.Fatal("expected the usage rights window to be enforced")
+	}
+}
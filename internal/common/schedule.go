@@ -0,0 +1,157 @@
+package common
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DEFAULT_TIMEOUT_DAYS   = 25
+	DEFAULT_REMINDER_DAYS  = 7
+	DEFAULT_HEADS_UP_DAYS  = 7
+	DEFAULT_EXTENSION_DAYS = 14
+
+	daySeconds = int32(60 * 60 * 24)
+)
+
+var (
+	ErrExtensionRequested = errors.New("An extension has already been requested for this deal")
+	ErrExtensionDays      = errors.New("Invalid number of extension days")
+	ErrExtensionNotFound  = errors.New("No pending extension request for this deal")
+)
+
+// DealSchedule controls how long influencers have to complete a deal
+// and how often they are reminded. Zero values fall back to defaults
+type DealSchedule struct {
+	TimeoutDays      int `json:"timeoutDays,omitempty"`      // Days after assignment before the deal is cleared
+	ReminderDays     int `json:"reminderDays,omitempty"`     // Cadence of post reminders
+	HeadsUpDays      int `json:"headsUpDays,omitempty"`      // Days before the timeout to warn the influencer
+	GraceDays        int `json:"graceDays,omitempty"`        // Days after the timeout before the deal is cleared
+	MaxExtensionDays int `json:"maxExtensionDays,omitempty"` // Most days an influencer may ask for
+}
+
+func (s *DealSchedule) GetTimeoutDays() int {
+	if s == nil || s.TimeoutDays <= 0 {
+		return DEFAULT_TIMEOUT_DAYS
+	}
+	return s.TimeoutDays
+}
+
+func (s *DealSchedule) GetReminderDays() int {
+	if s == nil || s.ReminderDays <= 0 {
+		return DEFAULT_REMINDER_DAYS
+	}
+	return s.ReminderDays
+}
+
+func (s *DealSchedule) GetHeadsUpDays() int {
+	if s == nil || s.HeadsUpDays <= 0 {
+		return DEFAULT_HEADS_UP_DAYS
+	}
+	return s.HeadsUpDays
+}
+
+func (s *DealSchedule) GetGraceDays() int {
+	if s == nil || s.GraceDays < 0 {
+		return 0
+	}
+	return s.GraceDays
+}
+
+func (s *DealSchedule) GetMaxExtensionDays() int {
+	if s == nil || s.MaxExtensionDays <= 0 {
+		return DEFAULT_EXTENSION_DAYS
+	}
+	return s.MaxExtensionDays
+}
+
+// RemindersDue returns how many reminders should have gone out
+// since the given TS
+func (s *DealSchedule) RemindersDue(ts int32) int {
+	elapsed := int32(time.Now().Unix()) - ts
+	if elapsed <= 0 {
+		return 0
+	}
+	return int(elapsed / (int32(s.GetReminderDays()) * daySeconds))
+}
+
+type Extension struct {
+	Days      int    `json:"days"`
+	Reason    string `json:"reason,omitempty"`
+	Requested int32  `json:"requested,omitempty"`
+	Approved  int32  `json:"approved,omitempty"`
+	Denied    int32  `json:"denied,omitempty"`
+}
+
+// SetTimeout sets the deal's deadline based off of the campaign schedule
+func (d *Deal) SetTimeout(s *DealSchedule) {
+	d.SetTimeoutFrom(d.Assigned, s)
+}
+
+// SetTimeoutFrom restarts the deal's deadline from ts.. used once the
+// influencer receives their perk
+func (d *Deal) SetTimeoutFrom(ts int32, s *DealSchedule) {
+	d.Timeout = ts + int32(s.GetTimeoutDays())*daySeconds
+}
+
+// getTimeoutStart returns when the influencer's clock started
+func (d *Deal) getTimeoutStart() int32 {
+	if d.Perk != nil && d.Perk.Shipment.IsDelivered() && d.Timeout > 0 {
+		return d.Perk.Shipment.Delivered
+	}
+	return d.Assigned
+}
+
+// GetTimeout returns the TS the deal times out at including any
+// approved extension
+func (d *Deal) GetTimeout() int32 {
+	timeout := d.Timeout
+	if timeout == 0 {
+		// Deals assigned before per campaign schedules
+		timeout = d.Assigned + DEFAULT_TIMEOUT_DAYS*daySeconds
+	}
+
+	if d.Extension != nil && d.Extension.Approved > 0 {
+		timeout += int32(d.Extension.Days) * daySeconds
+	}
+
+	return timeout
+}
+
+// GetTimeoutDays returns the total amount of days the influencer has
+func (d *Deal) GetTimeoutDays() int {
+	return int((d.GetTimeout() - d.getTimeoutStart()) / daySeconds)
+}
+
+func (d *Deal) RequestExtension(days int, reason string, s *DealSchedule) error {
+	if d.Extension != nil {
+		return ErrExtensionRequested
+	}
+
+	if days <= 0 || days > s.GetMaxExtensionDays() {
+		return ErrExtensionDays
+	}
+
+	d.Extension = &Extension{
+		Days:      days,
+		Reason:    reason,
+		Requested: int32(time.Now().Unix()),
+	}
+	return nil
+}
+
+func (d *Deal) ReviewExtension(approved bool) error {
+	if d.Extension == nil || d.Extension.Approved > 0 || d.Extension.Denied > 0 {
+		return ErrExtensionNotFound
+	}
+
+	now := int32(time.Now().Unix())
+	if approved {
+		d.Extension.Approved = now
+		// New deadline so the heads up should go out again
+		d.HeadsUpAlert = false
+	} else {
+		d.Extension.Denied = now
+	}
+	return nil
+}
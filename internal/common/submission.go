@@ -0,0 +1,118 @@
+package common
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	SUBMISSION_PENDING  = "pending"
+	SUBMISSION_APPROVED = "approved"
+	SUBMISSION_REJECTED = "rejected"
+	SUBMISSION_CHANGES  = "changes"
+
+	// Days of advertiser inactivity before a pending submission
+	// is approved automatically
+	DEFAULT_REVIEW_DAYS = 3
+)
+
+var (
+	ErrSubmissionApproved = errors.New("Submission already approved")
+	ErrSubmissionStatus   = errors.New("Invalid review status")
+)
+
+type Comment struct {
+	Author  string `json:"author,omitempty"` // "advertiser" or "influencer"
+	Name    string `json:"name,omitempty"`
+	Message string `json:"msg,omitempty"`
+	TS      int64  `json:"ts,omitempty"`
+}
+
+// AddSubmission versions the new submission and moves the
+// current one into the revision history
+func (d *Deal) AddSubmission(sub *Submission) error {
+	if d.Submission != nil {
+		if d.Submission.Approved {
+			return ErrSubmissionApproved
+		}
+		d.Revisions = append(d.Revisions, d.Submission)
+	}
+
+	sub.Version = len(d.Revisions) + 1
+	sub.Status = SUBMISSION_PENDING
+	sub.Approved = false
+	sub.AutoApproved = false
+	sub.Comments = nil
+	sub.SubmittedAt = time.Now().Unix()
+	sub.ReviewedAt = 0
+
+	d.Submission = sub
+	return nil
+}
+
+// Review sets the advertiser's decision on the latest revision
+func (d *Deal) Review(status, comment, name string) error {
+	if d.Submission == nil {
+		return ErrSubmissionStatus
+	}
+
+	if d.Submission.Approved {
+		return ErrSubmissionApproved
+	}
+
+	switch status {
+	case SUBMISSION_APPROVED, SUBMISSION_REJECTED, SUBMISSION_CHANGES:
+	default:
+		return ErrSubmissionStatus
+	}
+
+	d.Submission.Status = status
+	d.Submission.Approved = status == SUBMISSION_APPROVED
+	d.Submission.ReviewedAt = time.Now().Unix()
+	if comment != "" {
+		d.Submission.AddComment("advertiser", name, comment)
+	}
+
+	return nil
+}
+
+func (s *Submission) AddComment(author, name, msg string) {
+	s.Comments = append(s.Comments, &Comment{
+		Author:  author,
+		Name:    name,
+		Message: msg,
+		TS:      time.Now().Unix(),
+	})
+}
+
+// IsPendingReview returns whether the submission is waiting on the advertiser
+func (s *Submission) IsPendingReview() bool {
+	// Submissions made before versioning have no status
+	return !s.Approved && (s.Status == SUBMISSION_PENDING || s.Status == "")
+}
+
+// IsReviewOverdue returns whether the advertiser hasn't reviewed
+// the submission within the given amount of days
+func (s *Submission) IsReviewOverdue(days int) bool {
+	if !s.IsPendingReview() || s.SubmittedAt == 0 {
+		return false
+	}
+
+	if days <= 0 {
+		days = DEFAULT_REVIEW_DAYS
+	}
+
+	lastActivity := s.SubmittedAt
+	for _, c := range s.Comments {
+		if c.Author == "advertiser" && c.TS > lastActivity {
+			lastActivity = c.TS
+		}
+	}
+
+	return time.Now().Unix() > lastActivity+int64(days)*86400
+}
+
+func normalizeCaption(caption string) string {
+	return strings.Join(strings.Fields(strings.ToLower(caption)), " ")
+}
@@ -0,0 +1,135 @@
+package common
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/swayops/sway/config"
+	"github.com/swayops/sway/misc"
+)
+
+const (
+	AUTHOR_ADVERTISER = "advertiser"
+	AUTHOR_INFLUENCER = "influencer"
+	AUTHOR_ADMIN      = "admin"
+)
+
+// Thread is the conversation attached to an assigned deal.. keyed off
+// of deal and influencer ID in the thread bucket so whoever gets the deal
+// next starts with a clean thread
+type Thread struct {
+	DealId       string `json:"dealId"`
+	CampaignId   string `json:"campaignId"`
+	AdvertiserId string `json:"advertiserId"`
+	InfluencerId string `json:"influencerId"`
+
+	Messages []*Message `json:"messages,omitempty"`
+}
+
+type Message struct {
+	Id          string   `json:"id"`
+	Author      string   `json:"author"` // advertiser, influencer or admin
+	Name        string   `json:"name,omitempty"`
+	Text        string   `json:"text,omitempty"`
+	Attachments []string `json:"attachments,omitempty"` // Image URLs
+
+	TS   int64 `json:"ts"`
+	Read int64 `json:"read,omitempty"` // TS the recipient read the message
+
+	// Set once the recipient has been emailed about this unread message
+	Notified bool `json:"notified,omitempty"`
+	// Hidden by an admin
+	Removed bool `json:"removed,omitempty"`
+}
+
+func ThreadKey(dealId, infId string) string {
+	return dealId + "-" + infId
+}
+
+func (t *Thread) Key() string {
+	return ThreadKey(t.DealId, t.InfluencerId)
+}
+
+func NewThread(deal *Deal) *Thread {
+	return &Thread{
+		DealId:       deal.Id,
+		CampaignId:   deal.CampaignId,
+		AdvertiserId: deal.AdvertiserId,
+		InfluencerId: deal.InfluencerId,
+	}
+}
+
+func (t *Thread) AddMessage(author, name, text string, attachments []string) *Message {
+	msg := &Message{
+		Id:          misc.PseudoUUID(),
+		Author:      author,
+		Name:        name,
+		Text:        text,
+		Attachments: attachments,
+		TS:          time.Now().Unix(),
+	}
+	t.Messages = append(t.Messages, msg)
+	return msg
+}
+
+// MarkRead sets read receipts on all messages the reader didn't write.
+// Returns whether anything changed
+func (t *Thread) MarkRead(reader string) bool {
+	var updated bool
+	now := time.Now().Unix()
+	for _, msg := range t.Messages {
+		if msg.Author != reader && msg.Read == 0 {
+			msg.Read = now
+			updated = true
+		}
+	}
+	return updated
+}
+
+// GetUnread returns messages the reader hasn't read and hasn't
+// been notified about yet
+func (t *Thread) GetUnread(reader string) []*Message {
+	var unread []*Message
+	for _, msg := range t.Messages {
+		if msg.Author != reader && msg.Read == 0 && !msg.Notified && !msg.Removed {
+			unread = append(unread, msg)
+		}
+	}
+	return unread
+}
+
+// Visible returns a copy of the thread without moderated messages
+func (t *Thread) Visible() *Thread {
+	out := *t
+	out.Messages = make([]*Message, 0, len(t.Messages))
+	for _, msg := range t.Messages {
+		if !msg.Removed {
+			out.Messages = append(out.Messages, msg)
+		}
+	}
+	return &out
+}
+
+func GetThread(dealId, infId string, db *bolt.DB, cfg *config.Config) *Thread {
+	var t *Thread
+	db.View(func(tx *bolt.Tx) error {
+		t = GetThreadTx(tx, dealId, infId, cfg)
+		return nil
+	})
+	return t
+}
+
+func GetThreadTx(tx *bolt.Tx, dealId, infId string, cfg *config.Config) *Thread {
+	v := tx.Bucket([]byte(cfg.Bucket.Thread)).Get([]byte(ThreadKey(dealId, infId)))
+	if v == nil {
+		return nil
+	}
+
+	var t Thread
+	if err := json.Unmarshal(v, &t); err != nil {
+		return nil
+	}
+
+	return &t
+}
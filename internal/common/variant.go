@@ -0,0 +1,178 @@
+package common
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/swayops/sway/misc"
+)
+
+var (
+	ErrVariantReq = errors.New("Please provide a required hashtag or mention for every variant")
+)
+
+// Variant is one creative of a campaign being A/B tested. Empty
+// requirements fall back to the campaign's
+type Variant struct {
+	Id      string   `json:"id"`
+	Name    string   `json:"name,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	Mention string   `json:"mention,omitempty"`
+	Link    string   `json:"link,omitempty"`
+	Task    string   `json:"task,omitempty"`
+	Weight  int      `json:"weight,omitempty"` // Relative share of deals.. defaults to 1
+
+	// Left out of a campaign update while deals still use it.. kept for
+	// their requirements and reporting but not given to new deals
+	Retired bool `json:"retired,omitempty"`
+}
+
+func (v *Variant) GetWeight() int {
+	if v.Weight <= 0 {
+		return 1
+	}
+	return v.Weight
+}
+
+// Apply copies the variant's requirements onto the deal so the
+// explorer matches against them
+func (v *Variant) Apply(d *Deal) {
+	d.VariantId = v.Id
+	if len(v.Tags) > 0 {
+		d.Tags = v.Tags
+	}
+
+	if v.Mention != "" {
+		d.Mention = v.Mention
+	}
+
+	if v.Task != "" {
+		d.Task = v.Task
+	}
+
+	if v.Link != "" {
+		d.Link = v.Link
+	}
+}
+
+// RequiresLink returns whether the deal's post needs the tracking link.
+// Variants without a link of their own don't require one
+func (d *Deal) RequiresLink() bool {
+	return d.ShortenedLink != "" && (d.VariantId == "" || d.Link != "")
+}
+
+// SetVariantIds fills in IDs for new variants and makes sure every
+// variant ends up with a requirement
+func (cmp *Campaign) SetVariantIds() error {
+	for _, v := range cmp.Variants {
+		if v.Id == "" {
+			v.Id = misc.PseudoUUID()
+		}
+
+		if len(v.Tags) == 0 && v.Mention == "" && len(cmp.Tags) == 0 && cmp.Mention == "" {
+			return ErrVariantReq
+		}
+	}
+	return nil
+}
+
+func (cmp *Campaign) GetVariant(id string) *Variant {
+	if id == "" {
+		return nil
+	}
+
+	for _, v := range cmp.Variants {
+		if v.Id == id {
+			return v
+		}
+	}
+	return nil
+}
+
+// RetainVariants keeps the old variants that were left out of an update
+// but are still used by deals (retired). Updated variants are matched
+// by ID so deals keep pointing at them
+func (cmp *Campaign) RetainVariants(old []*Variant) {
+	kept := make(map[string]bool, len(cmp.Variants))
+	for _, v := range cmp.Variants {
+		v.Retired = false
+		kept[v.Id] = true
+	}
+
+	inUse := make(map[string]bool)
+	for _, d := range cmp.Deals {
+		if d.VariantId != "" {
+			inUse[d.VariantId] = true
+		}
+	}
+
+	for _, v := range old {
+		if !kept[v.Id] && inUse[v.Id] {
+			v.Retired = true
+			cmp.Variants = append(cmp.Variants, v)
+		}
+	}
+}
+
+// PickVariant chooses a variant at random using the variant weights
+func (cmp *Campaign) PickVariant() *Variant {
+	var total int
+	for _, v := range cmp.Variants {
+		if !v.Retired {
+			total += v.GetWeight()
+		}
+	}
+
+	if total == 0 {
+		return nil
+	}
+
+	n := rand.Intn(total)
+	for _, v := range cmp.Variants {
+		if v.Retired {
+			continue
+		}
+
+		if n < v.GetWeight() {
+			return v
+		}
+		n -= v.GetWeight()
+	}
+	return nil
+}
+
+// GetLink returns the destination for a deal's clicks
+func (cmp *Campaign) GetLink(variantId string) string {
+	if v := cmp.GetVariant(variantId); v != nil && v.Link != "" {
+		return v.Link
+	}
+	return cmp.Link
+}
+
+// HasLink returns whether the campaign, its link template or any
+// of its variants require a link
+func (cmp *Campaign) HasLink() bool {
+	if cmp.Link != "" || cmp.LinkTemplate.hasLink() {
+		return true
+	}
+
+	for _, v := range cmp.Variants {
+		if v.Link != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// GetVariantName returns a display name for the variant
+func (cmp *Campaign) GetVariantName(id string) string {
+	v := cmp.GetVariant(id)
+	if v == nil {
+		return "Default"
+	}
+
+	if v.Name != "" {
+		return v.Name
+	}
+	return v.Id
+}
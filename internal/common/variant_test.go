@@ -0,0 +1,148 @@
+package common
+
+import "testing"
+
+func TestPickVariant(t *testing.T) {
+	if v := (&Campaign{}).PickVariant(); v != nil {
+		t.Fatalf("expected no variant, got %+v", v)
+	}
+
+	cmp := &Campaign{Variants: []*Variant{
+		{Id: "a", Weight: 3},
+		{Id: "b"}, // Defaults to 1
+		{Id: "c", Weight: 50, Retired: true},
+	}}
+
+	picks := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		picks[cmp.PickVariant().Id]++
+	}
+
+	if picks["c"] != 0 {
+		t.Fatalf("retired variant was picked %d times", picks["c"])
+	}
+
+	// a should get roughly 75% of deals
+	if share := float64(picks["a"]) / 10000; share < 0.7 || share > 0.8 {
+		t.Fatalf("unexpected share for a: %v (%v)", share, picks)
+	}
+
+	cmp.Variants[0].Retired, cmp.Variants[1].Retired = true, true
+	if v := cmp.PickVariant(); v != nil {
+		t.Fatalf("expected no variant when all are retired, got %+v", v)
+	}
+}
+
+func TestRetainVariants(t *testing.T) {
+	old := []*Variant{
+		{Id: "a", Name: "Old A"},
+		{Id: "b", Name: "B"},
+		{Id: "c", Name: "C"},
+		{Id: "d", Name: "D", Retired: true},
+	}
+
+	cmp := &Campaign{
+		Variants: []*Variant{
+			{Id: "a", Name: "New A"},
+			{Id: "d", Name: "D"},
+			{Id: "e", Name: "E"},
+		},
+		Deals: map[string]*Deal{
+			"1": {VariantId: "a"},
+			"2": {VariantId: "b"},
+			"3": {},
+		},
+	}
+
+	cmp.RetainVariants(old)
+
+	ex := []struct {
+		id, name string
+		retired  bool
+	}{
+		{"a", "New A", false},
+		{"d", "D", false}, // Brought back by the update
+		{"e", "E", false},
+		{"b", "B", true}, // Still used by a deal
+	}
+
+	if len(cmp.Variants) != len(ex) {
+		t.Fatalf("wanted %d variants, got %d", len(ex), len(cmp.Variants))
+	}
+
+	for i, v := range cmp.Variants {
+		if v.Id != ex[i].id || v.Name != ex[i].name || v.Retired != ex[i].retired {
+			t.Errorf("wanted %+v, got %+v", ex[i], v)
+		}
+	}
+
+	// Retired variants are still around for their deals
+	if v := cmp.GetVariant("b"); v == nil || cmp.GetVariantName("b") != "B" {
+		t.Fatalf("unexpected retired variant: %+v", v)
+	}
+
+	if cmp.GetVariant("c") != nil || cmp.GetVariantName("c") != "Default" {
+		t.Fatal("unused variant should be dropped")
+	}
+}
+
+func TestRequiresLink(t *testing.T) {
+	tests := []struct {
+		name string
+		d    *Deal
+		ex   bool
+	}{
+		{"no link", &Deal{}, false},
+		{"campaign link", &Deal{Link: "http://a.com", ShortenedLink: "http://sway.com/1"}, true},
+		{"not shortened", &Deal{Link: "http://a.com"}, false},
+		{"variant link", &Deal{VariantId: "a", Link: "http://b.com", ShortenedLink: "http://sway.com/1"}, true},
+		{"variant without link", &Deal{VariantId: "a", ShortenedLink: "http://sway.com/1"}, false},
+	}
+
+	for _, ts := range tests {
+		if v := ts.d.RequiresLink(); v != ts.ex {
+			t.Errorf("%s: wanted %v, got %v", ts.name, ts.ex, v)
+		}
+	}
+
+	// Variants only override what they set
+	d := &Deal{Tags: []string{"cmp"}, Mention: "cmp", Link: "http://a.com", Task: "cmp"}
+	(&Variant{Id: "a", Tags: []string{"variant"}}).Apply(d)
+	if d.VariantId != "a" || d.Tags[0] != "variant" || d.Mention != "cmp" || d.Link != "http://a.com" || d.Task != "cmp" {
+		t.Fatalf("unexpected deal: %+v", d)
+	}
+
+	(&Variant{Id: "b", Link: "http://b.com"}).Apply(d)
+	if d.Link != "http://b.com" || !(&Deal{VariantId: d.VariantId, Link: d.Link, ShortenedLink: "x"}).RequiresLink() {
+		t.Fatalf("unexpected deal: %+v", d)
+	}
+}
+
+func TestGetLink(t *testing.T) {
+	cmp := &Campaign{
+		Link: "http://a.com",
+		Variants: []*Variant{
+			{Id: "a"},
+			{Id: "b", Link: "http://b.com"},
+		},
+	}
+
+	tests := []struct {
+		id, ex string
+	}{
+		{"", "http://a.com"},
+		{"a", "http://a.com"},
+		{"b", "http://b.com"},
+		{"missing", "http://a.com"},
+	}
+
+	for _, ts := range tests {
+		if v := cmp.GetLink(ts.id); v != ts.ex {
+			t.Errorf("%q: wanted %s, got %s", ts.id, ts.ex, v)
+		}
+	}
+
+	if !(&Campaign{Variants: cmp.Variants}).HasLink() || (&Campaign{Variants: cmp.Variants[:1]}).HasLink() {
+		t.Fatal("unexpected HasLink")
+	}
+}
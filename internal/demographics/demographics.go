@@ -0,0 +1,214 @@
+package demographics
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	SOURCE_INSIGHTS   = "insights"   // Pulled from the platform's insights API
+	SOURCE_ADMIN      = "admin"      // Supplied by an admin
+	SOURCE_INFLUENCER = "influencer" // Supplied by the influencer (unverified)
+
+	FEMALE = "f"
+	MALE   = "m"
+)
+
+var (
+	ErrDemographics = errors.New("Please provide valid audience shares (0 to 1)")
+	ErrTarget       = errors.New("Please provide valid audience targets")
+)
+
+var AGE_BANDS = []string{"13-17", "18-24", "25-34", "35-44", "45-54", "55-64", "65+"}
+
+// Demographics describe who follows an influencer on a platform. All
+// values are shares of the audience (0.6 = 60%)
+type Demographics struct {
+	Gender    map[string]float64 `json:"gender,omitempty"`    // Keyed off of "f" or "m"
+	Ages      map[string]float64 `json:"ages,omitempty"`      // Keyed off of AGE_BANDS
+	Countries map[string]float64 `json:"countries,omitempty"` // Keyed off of country ISO
+	// Keyed off of gender and age band (i.e. "f.18-24").. only available
+	// from insights but makes gender and age targets exact
+	GenderAges map[string]float64 `json:"genderAges,omitempty"`
+
+	Source   string `json:"source,omitempty"`
+	Verified bool   `json:"verified,omitempty"`
+	Updated  int32  `json:"updated,omitempty"`
+}
+
+// Influencer supplied demographics can't replace ones an admin or the
+// platform supplied
+var sourceRank = map[string]int{
+	SOURCE_INFLUENCER: 0,
+	SOURCE_ADMIN:      1,
+	SOURCE_INSIGHTS:   1,
+}
+
+// CanReplace returns whether these demographics may overwrite the
+// current ones
+func (d *Demographics) CanReplace(cur *Demographics) bool {
+	if cur == nil {
+		return true
+	}
+	return sourceRank[d.Source] >= sourceRank[cur.Source]
+}
+
+func isValidAge(band string) bool {
+	for _, a := range AGE_BANDS {
+		if a == band {
+			return true
+		}
+	}
+	return false
+}
+
+func isValidShares(shares map[string]float64) bool {
+	var total float64
+	for _, v := range shares {
+		if v < 0 || v > 1 {
+			return false
+		}
+		total += v
+	}
+	// Leave some room for rounding
+	return total <= 1.01
+}
+
+func (d *Demographics) Validate() error {
+	if d == nil {
+		return ErrDemographics
+	}
+
+	d.Countries = lowerKeys(d.Countries)
+	d.Gender = lowerKeys(d.Gender)
+	d.GenderAges = lowerKeys(d.GenderAges)
+
+	if !isValidShares(d.Gender) || !isValidShares(d.Ages) || !isValidShares(d.Countries) || !isValidShares(d.GenderAges) {
+		return ErrDemographics
+	}
+
+	for g := range d.Gender {
+		if g != FEMALE && g != MALE {
+			return ErrDemographics
+		}
+	}
+
+	for band := range d.Ages {
+		if !isValidAge(band) {
+			return ErrDemographics
+		}
+	}
+
+	for key := range d.GenderAges {
+		parts := strings.SplitN(key, ".", 2)
+		if len(parts) != 2 || (parts[0] != FEMALE && parts[0] != MALE) || !isValidAge(parts[1]) {
+			return ErrDemographics
+		}
+	}
+
+	return nil
+}
+
+func lowerKeys(in map[string]float64) map[string]float64 {
+	if in == nil {
+		return nil
+	}
+
+	out := make(map[string]float64, len(in))
+	for k, v := range in {
+		out[strings.ToLower(k)] += v
+	}
+	return out
+}
+
+// Target is a campaign requirement on an influencer's audience i.e.
+// at least 60% US followers or at least 50% female 18-34
+type Target struct {
+	Gender    string   `json:"gender,omitempty"` // "f" or "m".. empty for both
+	Ages      []string `json:"ages,omitempty"`
+	Countries []string `json:"countries,omitempty"`
+
+	Min      float64 `json:"min"`                // Share of the audience that has to match
+	Verified bool    `json:"verified,omitempty"` // Ignore unverified demographics
+}
+
+func (t *Target) Validate() error {
+	if t == nil || t.Min <= 0 || t.Min > 1 {
+		return ErrTarget
+	}
+
+	t.Gender = strings.ToLower(t.Gender)
+	if t.Gender != "" && t.Gender != FEMALE && t.Gender != MALE {
+		return ErrTarget
+	}
+
+	for _, band := range t.Ages {
+		if !isValidAge(band) {
+			return ErrTarget
+		}
+	}
+
+	for i, cy := range t.Countries {
+		t.Countries[i] = strings.ToLower(cy)
+	}
+
+	if t.Gender == "" && len(t.Ages) == 0 && len(t.Countries) == 0 {
+		return ErrTarget
+	}
+
+	return nil
+}
+
+// Share returns the share of the audience that matches the target.
+// Without joint gender and age data the two are assumed independent
+func (d *Demographics) Share(t *Target) float64 {
+	if d == nil {
+		return 0
+	}
+
+	share := 1.0
+	switch {
+	case t.Gender != "" && len(t.Ages) > 0 && len(d.GenderAges) > 0:
+		var sum float64
+		for _, band := range t.Ages {
+			sum += d.GenderAges[t.Gender+"."+band]
+		}
+		share *= sum
+	default:
+		if t.Gender != "" {
+			share *= d.Gender[t.Gender]
+		}
+
+		if len(t.Ages) > 0 {
+			var sum float64
+			for _, band := range t.Ages {
+				sum += d.Ages[band]
+			}
+			share *= sum
+		}
+	}
+
+	if len(t.Countries) > 0 {
+		var sum float64
+		for _, cy := range t.Countries {
+			sum += d.Countries[cy]
+		}
+		share *= sum
+	}
+
+	return share
+}
+
+// IsMatch returns whether the demographics satisfy all of the targets
+func IsMatch(targets []*Target, d *Demographics) bool {
+	for _, t := range targets {
+		if d == nil || (t.Verified && !d.Verified) {
+			return false
+		}
+
+		if d.Share(t) < t.Min {
+			return false
+		}
+	}
+	return true
+}
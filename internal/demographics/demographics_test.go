@@ -0,0 +1,166 @@
+package demographics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		d  *Demographics
+		ex bool
+	}{
+		{nil, false},
+		{&Demographics{}, true},
+		{&Demographics{Gender: map[string]float64{"F": 0.6, "m": 0.4}}, true},
+		{&Demographics{Gender: map[string]float64{"f": 0.6, "x": 0.4}}, false},
+		{&Demographics{Gender: map[string]float64{"f": 0.8, "m": 0.4}}, false},
+		{&Demographics{Ages: map[string]float64{"18-24": 0.5, "25-34": 0.5}}, true},
+		{&Demographics{Ages: map[string]float64{"18-25": 0.5}}, false},
+		{&Demographics{Countries: map[string]float64{"US": -0.1}}, false},
+		{&Demographics{GenderAges: map[string]float64{"f.18-24": 0.3, "m.65+": 0.1}}, true},
+		{&Demographics{GenderAges: map[string]float64{"f18-24": 0.3}}, false},
+	}
+
+	for i, ts := range tests {
+		if err := ts.d.Validate(); (err == nil) != ts.ex {
+			t.Errorf("%d: wanted valid %v, got %v", i, ts.ex, err)
+		}
+	}
+
+	d := &Demographics{Countries: map[string]float64{"US": 0.5, "us": 0.1}}
+	if err := d.Validate(); err != nil || math.Abs(d.Countries["us"]-0.6) > 1e-9 {
+		t.Errorf("expected merged lowercase countries, got %v %v", d.Countries, err)
+	}
+}
+
+func TestTargetValidate(t *testing.T) {
+	tests := []struct {
+		t  *Target
+		ex bool
+	}{
+		{nil, false},
+		{&Target{Gender: "F", Min: 0.5}, true},
+		{&Target{Gender: "x", Min: 0.5}, false},
+		{&Target{Ages: []string{"18-24"}, Min: 0.5}, true},
+		{&Target{Ages: []string{"18"}, Min: 0.5}, false},
+		{&Target{Countries: []string{"US"}, Min: 1.5}, false},
+		{&Target{Countries: []string{"US"}}, false},
+		{&Target{Min: 0.5}, false},
+	}
+
+	for i, ts := range tests {
+		if err := ts.t.Validate(); (err == nil) != ts.ex {
+			t.Errorf("%d: wanted valid %v, got %v", i, ts.ex, err)
+		}
+	}
+}
+
+func TestShare(t *testing.T) {
+	var (
+		d = &Demographics{
+			Gender:    map[string]float64{"f": 0.6, "m": 0.4},
+			Ages:      map[string]float64{"18-24": 0.5, "25-34": 0.3, "35-44": 0.2},
+			Countries: map[string]float64{"us": 0.7, "ca": 0.1},
+		}
+		joint = &Demographics{
+			Gender:     d.Gender,
+			Ages:       d.Ages,
+			GenderAges: map[string]float64{"f.18-24": 0.4, "f.25-34": 0.1},
+		}
+
+		tests = []struct {
+			d  *Demographics
+			t  *Target
+			ex float64
+		}{
+			{nil, &Target{Gender: "f"}, 0},
+			{d, &Target{Gender: "f"}, 0.6},
+			{d, &Target{Ages: []string{"18-24", "25-34"}}, 0.8},
+			{d, &Target{Countries: []string{"us", "ca"}}, 0.8},
+			// Independent without joint data
+			{d, &Target{Gender: "f", Ages: []string{"18-24"}}, 0.3},
+			{d, &Target{Gender: "m", Countries: []string{"us"}}, 0.28},
+			// Exact with joint data
+			{joint, &Target{Gender: "f", Ages: []string{"18-24", "25-34"}}, 0.5},
+		}
+	)
+
+	for i, ts := range tests {
+		if v := ts.d.Share(ts.t); math.Abs(v-ts.ex) > 1e-9 {
+			t.Errorf("%d: wanted %v, got %v", i, ts.ex, v)
+		}
+	}
+}
+
+func TestIsMatch(t *testing.T) {
+	var (
+		d = &Demographics{
+			Gender:    map[string]float64{"f": 0.6, "m": 0.4},
+			Countries: map[string]float64{"us": 0.7},
+		}
+		verified = &Demographics{
+			Gender:   d.Gender,
+			Verified: true,
+		}
+
+		female    = &Target{Gender: "f", Min: 0.5}
+		male      = &Target{Gender: "m", Min: 0.5}
+		us        = &Target{Countries: []string{"us"}, Min: 0.6}
+		verifiedF = &Target{Gender: "f", Min: 0.5, Verified: true}
+
+		tests = []struct {
+			targets []*Target
+			d       *Demographics
+			ex      bool
+		}{
+			{nil, nil, true},
+			{[]*Target{female}, nil, false},
+			{[]*Target{female}, d, true},
+			{[]*Target{male}, d, false},
+			{[]*Target{female, us}, d, true},
+			{[]*Target{female, male}, d, false},
+			{[]*Target{verifiedF}, d, false},
+			{[]*Target{verifiedF}, verified, true},
+		}
+	)
+
+	for i, ts := range tests {
+		if v := IsMatch(ts.targets, ts.d); v != ts.ex {
+			t.Errorf("%d: wanted %v, got %v", i, ts.ex, v)
+		}
+	}
+}
+
+func TestCanReplace(t *testing.T) {
+	var (
+		insights   = &Demographics{Source: SOURCE_INSIGHTS}
+		admin      = &Demographics{Source: SOURCE_ADMIN}
+		influencer = &Demographics{Source: SOURCE_INFLUENCER}
+
+		tests = []struct {
+			d, cur *Demographics
+			ex     bool
+		}{
+			{influencer, nil, true},
+			{influencer, influencer, true},
+			{influencer, insights, false},
+			{influencer, admin, false},
+			{admin, insights, true},
+			{admin, influencer, true},
+			{insights, admin, true},
+			{insights, insights, true},
+		}
+	)
+
+	for _, ts := range tests {
+		var cur string
+		if ts.cur != nil {
+			cur = ts.cur.Source
+		}
+
+		if v := ts.d.CanReplace(ts.cur); v != ts.ex {
+			t.Errorf("%s over %q: wanted %v, got %v", ts.d.Source, cur, ts.ex, v)
+		}
+	}
+}
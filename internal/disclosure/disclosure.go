@@ -0,0 +1,300 @@
+package disclosure
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// DEFAULT is the rule for markets we don't have specific guidance for
+const DEFAULT = "default"
+
+var ErrRule = errors.New("Disclosure rules need at least one accepted term")
+
+// Characters shown before the platform hides the rest behind "more".
+// Twitter shows the whole tweet
+var truncation = map[string]int{
+	"instagram": 125,
+	"facebook":  400,
+	"youtube":   100,
+}
+
+// Rule is what a market's regulator expects from a sponsored post
+type Rule struct {
+	Name  string   `json:"name,omitempty"`  // i.e. "FTC"
+	Terms []string `json:"terms,omitempty"` // Accepted disclosures.. hashtags or words
+
+	// Has to start within the first N characters (0 for anywhere)
+	Within int `json:"within,omitempty"`
+	// Has to be visible before the platform's "more" cutoff
+	BeforeTruncation bool `json:"beforeTruncation,omitempty"`
+	// Hashtags allowed before the disclosure so it isn't buried (0 for any)
+	MaxLeadingTags int `json:"maxLeadingTags,omitempty"`
+	// Platform paid partnership labels count on their own
+	Native bool `json:"native,omitempty"`
+}
+
+// Keyed off of lowercase ISO country code
+var defaultRules = map[string]*Rule{
+	DEFAULT: {
+		Name:             "Sway",
+		Terms:            []string{"ad", "ads", "advertisement", "sponsored", "sponsoredpost", "paidpost", "promotion", "endorsement", "endorsed", "paid partnership"},
+		BeforeTruncation: true,
+		Native:           true,
+	},
+	"us": {
+		Name:             "FTC",
+		Terms:            []string{"ad", "advertisement", "sponsored", "paid partnership", "paid ad", "promotion"},
+		BeforeTruncation: true,
+		MaxLeadingTags:   3,
+	},
+	"ca": {
+		Name:             "Ad Standards",
+		Terms:            []string{"ad", "advertisement", "sponsored", "paid partnership", "publicité", "pub", "commandité"},
+		BeforeTruncation: true,
+		MaxLeadingTags:   3,
+	},
+	"gb": {
+		Name:             "ASA / CMA",
+		Terms:            []string{"ad", "advert", "advertisement", "advertisement feature"},
+		Within:           50,
+		BeforeTruncation: true,
+	},
+	"au": {
+		Name:             "AANA",
+		Terms:            []string{"ad", "advertising", "advertisement", "sponsored", "paid partnership"},
+		BeforeTruncation: true,
+		MaxLeadingTags:   3,
+	},
+	"de": {
+		Name:             "Medienanstalten",
+		Terms:            []string{"werbung", "anzeige"},
+		Within:           50,
+		BeforeTruncation: true,
+	},
+	"fr": {
+		Name:             "ARPP",
+		Terms:            []string{"publicité", "collaboration commerciale", "sponsorisé", "partenariat rémunéré"},
+		BeforeTruncation: true,
+		Native:           true,
+	},
+	"es": {
+		Name:             "Autocontrol",
+		Terms:            []string{"publicidad", "publi", "patrocinado", "colaboración pagada"},
+		BeforeTruncation: true,
+		Native:           true,
+	},
+	"it": {
+		Name:             "IAP",
+		Terms:            []string{"pubblicità", "advertising", "adv", "sponsorizzato", "inserzione a pagamento"},
+		BeforeTruncation: true,
+		Native:           true,
+	},
+}
+
+var rules = struct {
+	sync.RWMutex
+	m map[string]*Rule
+}{m: defaultRules}
+
+// Configure replaces or adds market rules from the config
+func Configure(custom map[string]*Rule) error {
+	m := make(map[string]*Rule, len(defaultRules)+len(custom))
+	for market, r := range defaultRules {
+		m[market] = r
+	}
+
+	for market, r := range custom {
+		if r == nil || len(r.Terms) == 0 {
+			return ErrRule
+		}
+		m[strings.ToLower(market)] = r
+	}
+
+	rules.Lock()
+	rules.m = m
+	rules.Unlock()
+	return nil
+}
+
+// GetRule returns the market's rule or the default one
+func GetRule(market string) (string, *Rule) {
+	rules.RLock()
+	defer rules.RUnlock()
+
+	market = strings.ToLower(market)
+	if r, ok := rules.m[market]; ok {
+		return market, r
+	}
+	return DEFAULT, rules.m[DEFAULT]
+}
+
+// Post is what we know about a deal post
+type Post struct {
+	Platform string
+	Text     string
+	Hashtags []string // Some platforms pull these from comments too
+	Native   bool     // Platform paid partnership label
+}
+
+// Result is the outcome of checking a post.. saved on the deal for
+// the advertiser's audit report
+type Result struct {
+	Market   string   `json:"market,omitempty"`
+	Rule     string   `json:"rule,omitempty"`
+	Platform string   `json:"platform,omitempty"`
+	PostURL  string   `json:"postUrl,omitempty"`
+	Pass     bool     `json:"pass"`
+	Reasons  []string `json:"reasons,omitempty"` // Why it failed
+
+	Term     string `json:"term,omitempty"`     // Disclosure found
+	Position int    `json:"position,omitempty"` // Characters into the caption
+	Native   bool   `json:"native,omitempty"`   // Passed on the platform label
+
+	Checked int32 `json:"checked,omitempty"`
+}
+
+// Reason returns the failure reasons as one line
+func (r *Result) Reason() string {
+	if r == nil {
+		return ""
+	}
+	return strings.Join(r.Reasons, "; ")
+}
+
+// Check runs the post against the market's rule
+func Check(market string, p *Post) *Result {
+	key, rule := GetRule(market)
+	res := &Result{
+		Market:   key,
+		Rule:     rule.Name,
+		Platform: p.Platform,
+		Checked:  int32(time.Now().Unix()),
+	}
+
+	if p.Native && rule.Native {
+		res.Pass, res.Native = true, true
+		return res
+	}
+
+	term, pos := find(p.Text, rule.Terms)
+	if term == "" {
+		if tagged(p.Hashtags, rule.Terms) {
+			res.Reasons = append(res.Reasons, "Sponsorship disclosure has to be in the caption itself")
+		} else {
+			res.Reasons = append(res.Reasons, "Missing a sponsorship disclosure such as #"+strings.Replace(rule.Terms[0], " ", "", -1))
+		}
+		return res
+	}
+
+	res.Term, res.Position = term, pos
+
+	limit := rule.Within
+	if cut := truncation[p.Platform]; rule.BeforeTruncation && cut > 0 && (limit == 0 || cut < limit) {
+		limit = cut
+	}
+
+	if limit > 0 && pos >= limit {
+		res.Reasons = append(res.Reasons, fmt.Sprintf("Sponsorship disclosure has to be within the first %d characters so it shows before \"more\"", limit))
+	}
+
+	if rule.MaxLeadingTags > 0 {
+		if n := leadingTags(p.Text, pos); n > rule.MaxLeadingTags {
+			res.Reasons = append(res.Reasons, fmt.Sprintf("Sponsorship disclosure is buried after %d hashtags", n))
+		}
+	}
+
+	res.Pass = len(res.Reasons) == 0
+	return res
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// find returns the earliest accepted term in the text as a whole word
+// (or hashtag) and its position in characters
+func find(text string, terms []string) (string, int) {
+	var (
+		runes = []rune(strings.ToLower(text))
+		found string
+		first = -1
+	)
+
+	for _, t := range variants(terms) {
+		tr := []rune(strings.ToLower(t))
+		if len(tr) == 0 {
+			continue
+		}
+
+		for i := 0; i+len(tr) <= len(runes); i++ {
+			if first != -1 && i >= first {
+				break
+			}
+
+			if string(runes[i:i+len(tr)]) != string(tr) {
+				continue
+			}
+
+			if i > 0 && isWordRune(runes[i-1]) {
+				continue
+			}
+
+			if end := i + len(tr); end < len(runes) && isWordRune(runes[end]) {
+				continue
+			}
+
+			if i > 0 && runes[i-1] == '#' {
+				// Point at the hashtag rather than the word
+				i--
+			}
+
+			found, first = t, i
+			break
+		}
+	}
+
+	return found, first
+}
+
+// variants adds the hashtag form of multi word terms.. "paid
+// partnership" is also accepted as #paidpartnership
+func variants(terms []string) []string {
+	out := make([]string, 0, len(terms))
+	for _, t := range terms {
+		out = append(out, t)
+		if tag := strings.Replace(t, " ", "", -1); tag != t {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+func tagged(hashtags, terms []string) bool {
+	for _, tg := range hashtags {
+		tg = strings.TrimPrefix(tg, "#")
+		for _, t := range terms {
+			if strings.EqualFold(tg, strings.Replace(t, " ", "", -1)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// leadingTags counts the hashtags before pos
+func leadingTags(text string, pos int) int {
+	var n int
+	for i, r := range []rune(text) {
+		if i >= pos {
+			break
+		}
+		if r == '#' {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,123 @@
+package disclosure
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	terms := []string{"ad", "sponsored", "paid partnership"}
+
+	tests := []struct {
+		text string
+		term string
+		pos  int
+	}{
+		{"", "", -1},
+		{"Loving my new shoes", "", -1},
+		{"Ad: loving my new shoes", "ad", 0},
+		{"Loving my new shoes #ad", "ad", 20},
+		{"Loving my new shoes #AD", "ad", 20},
+		// Whole words only
+		{"Adding this to my list #adventure #badge", "", -1},
+		{"#sponsored and #ad", "sponsored", 0},
+		{"Thanks! #ad #sponsored", "ad", 8},
+		{"In paid partnership with @brand", "paid partnership", 3},
+		{"Go #paidpartnership", "paidpartnership", 3},
+		// Positions are in characters rather than bytes
+		{"Café ☕ #ad", "ad", 7},
+	}
+
+	for _, ts := range tests {
+		if term, pos := find(ts.text, terms); term != ts.term || pos != ts.pos {
+			t.Errorf("%q: wanted %q@%d, got %q@%d", ts.text, ts.term, ts.pos, term, pos)
+		}
+	}
+}
+
+func TestVariants(t *testing.T) {
+	v := variants([]string{"ad", "paid partnership"})
+	if ex := "ad,paid partnership,paidpartnership"; strings.Join(v, ",") != ex {
+		t.Fatalf("wanted %s, got %v", ex, v)
+	}
+
+	if !tagged([]string{"#PaidPartnership"}, []string{"paid partnership"}) || tagged([]string{"#paid"}, []string{"paid partnership"}) {
+		t.Fatal("unexpected hashtag match")
+	}
+}
+
+func TestLeadingTags(t *testing.T) {
+	tests := []struct {
+		text string
+		pos  int
+		ex   int
+	}{
+		{"#ad", 0, 0},
+		{"#one #two #ad", 10, 2},
+		{"#one #two #three #four #ad", 23, 4},
+		{"#one #two", 100, 2},
+	}
+
+	for _, ts := range tests {
+		if n := leadingTags(ts.text, ts.pos); n != ts.ex {
+			t.Errorf("%q: wanted %d, got %d", ts.text, ts.ex, n)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var (
+		long   = strings.Repeat("a", 130) + " #ad"
+		middle = strings.Repeat("a", 60) + " #ad"
+	)
+
+	tests := []struct {
+		name    string
+		market  string
+		p       *Post
+		pass    bool
+		reasons int
+		reason  string
+	}{
+		{"us ok", "US", &Post{Platform: "instagram", Text: "New drop! #ad"}, true, 0, ""},
+		{"missing", "us", &Post{Platform: "instagram", Text: "New drop!"}, false, 1, "#ad"},
+		{"comment hashtag", "us", &Post{Platform: "instagram", Text: "New drop!", Hashtags: []string{"#ad"}}, false, 1, "in the caption"},
+		{"instagram truncation", "us", &Post{Platform: "instagram", Text: long}, false, 1, "first 125 characters"},
+		{"facebook truncation", "us", &Post{Platform: "facebook", Text: long}, true, 0, ""},
+		{"twitter never truncates", "us", &Post{Platform: "twitter", Text: strings.Repeat("a", 500) + " #ad"}, true, 0, ""},
+		{"within beats truncation", "gb", &Post{Platform: "instagram", Text: middle}, false, 1, "first 50 characters"},
+		{"buried", "us", &Post{Platform: "instagram", Text: "Hi #a #b #c #d #ad"}, false, 1, "after 4 hashtags"},
+		{"three tags ok", "us", &Post{Platform: "instagram", Text: "Hi #a #b #c #ad"}, true, 0, ""},
+		{"default allows tags", "zz", &Post{Platform: "instagram", Text: "Hi #a #b #c #d #ad"}, true, 0, ""},
+		{"native", "zz", &Post{Platform: "instagram", Text: "Hi", Native: true}, true, 0, ""},
+		{"native not accepted", "us", &Post{Platform: "instagram", Text: "Hi", Native: true}, false, 1, ""},
+		{"local terms", "de", &Post{Platform: "instagram", Text: "#werbung Neues Outfit"}, true, 0, ""},
+	}
+
+	for _, ts := range tests {
+		res := Check(ts.market, ts.p)
+		if res.Pass != ts.pass || len(res.Reasons) != ts.reasons || !strings.Contains(res.Reason(), ts.reason) {
+			t.Errorf("%s: wanted %v (%d %q), got %+v", ts.name, ts.pass, ts.reasons, ts.reason, res)
+		}
+	}
+
+	if res := Check("zz", &Post{Platform: "instagram", Text: "x"}); res.Market != DEFAULT || res.Rule != "Sway" {
+		t.Fatalf("expected the default rule, got %+v", res)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	defer Configure(nil)
+
+	if err := Configure(map[string]*Rule{"nz": {Name: "ASA"}}); err != ErrRule {
+		t.Fatalf("wanted %v, got %v", ErrRule, err)
+	}
+
+	if err := Configure(map[string]*Rule{"NZ": {Name: "ASA", Terms: []string{"ad"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if market, r := GetRule("nz"); market != "nz" || r.Name != "ASA" {
+		t.Fatalf("unexpected rule: %s %+v", market, r)
+	}
+}
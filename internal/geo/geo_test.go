@@ -0,0 +1,96 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIsGeoMatch(t *testing.T) {
+	var (
+		la = &GeoRecord{City: "Los Angeles", State: "CA", Country: "US", Zip: "90210-1234", Lat: 34.0522, Long: -118.2437}
+		sf = &GeoRecord{City: "San Francisco", State: "CA", Country: "US", Zip: "94103", Lat: 37.7749, Long: -122.4194}
+		to = &GeoRecord{City: "Toronto", State: "ON", Country: "CA", Zip: "M5V 3L9"}
+
+		tests = []struct {
+			name     string
+			haystack []*GeoRecord
+			needle   *GeoRecord
+			ex       bool
+		}{
+			{"no targets", nil, nil, true},
+			{"no geo", []*GeoRecord{{Country: "US"}}, nil, false},
+			{"country", []*GeoRecord{{Country: "us"}}, la, true},
+			{"wrong country", []*GeoRecord{{Country: "CA"}}, la, false},
+			{"state", []*GeoRecord{{State: "ca", Country: "us"}}, sf, true},
+			{"wrong state", []*GeoRecord{{State: "NY", Country: "US"}}, sf, false},
+			{"city", []*GeoRecord{{City: "los angeles", Country: "US"}}, la, true},
+			{"city in state", []*GeoRecord{{City: "Los Angeles", State: "NY", Country: "US"}}, la, false},
+			{"wrong city", []*GeoRecord{{City: "Los Angeles", Country: "US"}}, sf, false},
+			{"zip+4", []*GeoRecord{{Zip: "90210", Country: "US"}}, la, true},
+			{"zip spacing", []*GeoRecord{{Zip: "m5v3l9", Country: "CA"}}, to, true},
+			{"zip in other country", []*GeoRecord{{Zip: "90210", Country: "CA"}}, la, false},
+			{"radius", []*GeoRecord{{Lat: 34.1, Long: -118.3, Radius: 25, Country: "US"}}, la, true},
+			{"outside radius", []*GeoRecord{{Lat: 34.1, Long: -118.3, Radius: 25, Country: "US"}}, sf, false},
+			{"radius without coords", []*GeoRecord{{Lat: 43.65, Long: -79.38, Radius: 25, Country: "CA"}}, to, false},
+			{"any target", []*GeoRecord{{Country: "MX"}, {City: "San Francisco", Country: "US"}}, sf, true},
+		}
+	)
+
+	for _, ts := range tests {
+		if v := IsGeoMatch(ts.haystack, ts.needle); v != ts.ex {
+			t.Errorf("%s: wanted %v, got %v", ts.name, ts.ex, v)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	// LA to SF is ~347 miles
+	if d := Distance(34.0522, -118.2437, 37.7749, -122.4194); math.Abs(d-347) > 5 {
+		t.Errorf("unexpected distance: %v", d)
+	}
+
+	if d := Distance(40.7128, -74.006, 40.7128, -74.006); d != 0 {
+		t.Errorf("unexpected distance: %v", d)
+	}
+}
+
+func TestNormalizeZip(t *testing.T) {
+	tests := []struct {
+		zip, country, ex string
+	}{
+		{"90210-1234", "US", "90210"},
+		{" 90210 ", "us", "90210"},
+		{"m5v 3l9", "CA", "M5V3L9"},
+		{"SW1A 1AA", "GB", "SW1A1AA"},
+		{"1234567", "DE", "1234567"},
+	}
+
+	for _, ts := range tests {
+		if v := NormalizeZip(ts.zip, ts.country); v != ts.ex {
+			t.Errorf("%s (%s): wanted %q, got %q", ts.zip, ts.country, ts.ex, v)
+		}
+	}
+}
+
+func TestIsValidGeoTarget(t *testing.T) {
+	tests := []struct {
+		r  *GeoRecord
+		ex bool
+	}{
+		{&GeoRecord{Country: "US", State: "CA"}, true},
+		{&GeoRecord{Country: "US", State: "XX"}, false},
+		{&GeoRecord{Country: "GB", City: "London"}, true},
+		{&GeoRecord{State: "CA"}, false},
+		{&GeoRecord{Country: "US", Lat: 34.05, Long: -118.24, Radius: 50}, true},
+		{&GeoRecord{Country: "US", Radius: 50}, false},
+		{&GeoRecord{Country: "US", Lat: 34.05, Long: -118.24, Radius: MAX_RADIUS + 1}, false},
+		{&GeoRecord{Country: "US", Lat: 95, Long: -118.24, Radius: 50}, false},
+		{&GeoRecord{Country: "US", Lat: 34.05, Long: -118.24, Radius: -1}, false},
+	}
+
+	for _, ts := range tests {
+		if v := IsValidGeoTarget(ts.r); v != ts.ex {
+			t.Errorf("wanted %v for %+v, got %v", ts.ex, ts.r, v)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package geo
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/swayops/sway/misc"
+)
+
+const (
+	GOOGLE_GEO = "https://maps.googleapis.com/maps/api/geocode/json?latlng=%s,%s&key=%s"
+)
+
+// Geocoder resolves coordinates to a country, state and city.
+// Implementations return nil when they can't place the point
+type Geocoder interface {
+	Reverse(lat, long float64) *GeoRecord
+}
+
+// Set once at startup by the config
+var geocoder Geocoder
+
+func SetGeocoder(g Geocoder) {
+	geocoder = g
+}
+
+// Chain tries each geocoder in order until one places the point
+type Chain []Geocoder
+
+func (ch Chain) Reverse(lat, long float64) *GeoRecord {
+	for _, g := range ch {
+		if rec := g.Reverse(lat, long); rec != nil {
+			return rec
+		}
+	}
+	return nil
+}
+
+// GoogleGeocoder hits Google's geocoding API.. only used as a fallback
+// for points the offline geocoder can't place since every call costs money
+type GoogleGeocoder struct {
+	Key string
+}
+
+type GoogleRequest struct {
+	Results []struct {
+		AddressComponents []struct {
+			LongName  string   `json:"long_name"`
+			ShortName string   `json:"short_name"`
+			Types     []string `json:"types"`
+		} `json:"address_components"`
+	} `json:"results"`
+	Status string `json:"status"`
+}
+
+func (g *GoogleGeocoder) Reverse(lat, long float64) *GeoRecord {
+	if g.Key == "" {
+		return nil
+	}
+
+	var (
+		output GoogleRequest
+		geo    = &GeoRecord{}
+		fLat   = strconv.FormatFloat(lat, 'f', 6, 64)
+		fLong  = strconv.FormatFloat(long, 'f', 6, 64)
+	)
+
+	err := misc.Request("GET", fmt.Sprintf(GOOGLE_GEO, fLat, fLong, g.Key), "", &output)
+	if err != nil || len(output.Results) == 0 {
+		log.Println("Error extracting geo", fLat, fLong)
+		return nil
+	}
+
+	for _, result := range output.Results {
+		for _, val := range result.AddressComponents {
+			for _, cat := range val.Types {
+				if cat == "postal_code" && geo.Zip == "" {
+					geo.Zip = val.LongName
+				} else if cat == "administrative_area_level_1" && len(val.ShortName) == 2 {
+					geo.State = val.ShortName
+				} else if cat == "locality" && geo.City == "" {
+					geo.City = val.LongName
+				} else if cat == "country" && len(val.ShortName) == 2 {
+					geo.Country = val.ShortName
+				}
+			}
+		}
+	}
+
+	if geo.State == "" && geo.Country == "" {
+		return nil
+	}
+
+	return geo
+}
+
+func GetGeoFromCoords(lat, long float64, ts int32) *GeoRecord {
+	if (lat == 0 && long == 0) || geocoder == nil {
+		return nil
+	}
+
+	geo := geocoder.Reverse(lat, long)
+	if geo == nil {
+		return nil
+	}
+
+	geo.Lat = lat
+	geo.Long = long
+	geo.Timestamp = ts
+	geo.Source = "coords"
+
+	if !IsValidGeo(geo) {
+		log.Println("Geocoder returned invalid geo!", lat, long)
+		return nil
+	}
+
+	return geo
+}
@@ -18,17 +18,17 @@ func NewGeoDB(file string) (*maxminddb.Reader, error) {
 }
 
 type MaxmindRecord struct {
-	// City struct {
-	// 	Names map[string]string `maxminddb:"names"`
-	// } `maxminddb:"city"`
-	// Location struct {
-	// 	Latitude  float64 `maxminddb:"latitude"`
-	// 	Longitude float64 `maxminddb:"longitude"`
-	// 	MetroCode uint    `maxminddb:"metro_code"`
-	// } `maxminddb:"location"`
-	// Postal struct {
-	// 	Code string `maxminddb:"code"`
-	// } `maxminddb:"postal"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+		MetroCode uint    `maxminddb:"metro_code"`
+	} `maxminddb:"location"`
+	Postal struct {
+		Code string `maxminddb:"code"`
+	} `maxminddb:"postal"`
 	State []struct {
 		IsoCode string `maxminddb:"iso_code"`
 	} `maxminddb:"subdivisions"`
@@ -91,6 +91,10 @@ func GetGeoFromIP(geoDb *maxminddb.Reader, ip string) *GeoRecord {
 		Timestamp: int32(time.Now().Unix()),
 		State:     state,
 		Country:   country,
+		City:      record.City.Names["en"],
+		Zip:       record.Postal.Code,
+		Lat:       record.Location.Latitude,
+		Long:      record.Location.Longitude,
 		Source:    "ip",
 	}
 
@@ -0,0 +1,400 @@
+package geo
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Furthest a point can be from a gazetteer entry to be placed in its city
+	MAX_CITY_DISTANCE = 25 // Miles
+
+	milesPerDegree = 69.0
+)
+
+var (
+	ErrGazetteer  = errors.New("Invalid gazetteer data")
+	ErrBoundaries = errors.New("Invalid boundary data")
+)
+
+// GeoNames uses numeric admin codes for Canadian provinces
+var caAdminCodes = map[string]string{
+	"01": "AB",
+	"02": "BC",
+	"03": "MB",
+	"04": "NB",
+	"05": "NL",
+	"07": "NS",
+	"08": "ON",
+	"09": "PE",
+	"10": "QC",
+	"11": "SK",
+	"12": "YT",
+	"13": "NT",
+	"14": "NU",
+}
+
+type place struct {
+	name, country, state string
+	lat, long            float64
+}
+
+// region is a single polygon of a country or state boundary
+type region struct {
+	country, state string
+
+	minLat, minLong, maxLat, maxLong float64
+	// Rings made of [long, lat] points (GeoJSON order).. the first ring
+	// is the outer boundary and the rest are holes. Rings crossing the
+	// antimeridian are unwrapped so longitudes can go past 180
+	polygon [][][2]float64
+}
+
+type cell struct {
+	lat, long int
+}
+
+func getCell(lat, long float64) cell {
+	return cell{int(math.Floor(lat)), wrapCell(int(math.Floor(long)))}
+}
+
+// wrapCell keeps longitude cells between -180 and 179
+func wrapCell(long int) int {
+	return ((long+180)%360+360)%360 - 180
+}
+
+// OfflineGeocoder places points using boundary polygons for the country
+// and state and the nearest gazetteer entry for the city. Both are
+// bucketed into a one degree grid so lookups only look at nearby data
+type OfflineGeocoder struct {
+	places  map[cell][]*place
+	regions map[cell][]*region
+}
+
+// NewOfflineGeocoder loads a GeoNames style gazetteer (tab separated) and
+// GeoJSON boundaries with "country" and optional "state" properties.
+// Either path may be empty or missing which leaves that part of the
+// index empty so lookups fall through to the next geocoder
+func NewOfflineGeocoder(gazetteer, boundaries string) (*OfflineGeocoder, error) {
+	g := &OfflineGeocoder{
+		places:  make(map[cell][]*place),
+		regions: make(map[cell][]*region),
+	}
+
+	if gazetteer != "" {
+		if err := g.loadGazetteer(gazetteer); err != nil {
+			return nil, err
+		}
+	}
+
+	if boundaries != "" {
+		if err := g.loadBoundaries(boundaries); err != nil {
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+// IsEmpty returns whether no gazetteer or boundary data was loaded
+func (g *OfflineGeocoder) IsEmpty() bool {
+	return len(g.places) == 0 && len(g.regions) == 0
+}
+
+func (g *OfflineGeocoder) loadGazetteer(fp string) error {
+	f, err := os.Open(fp)
+	if os.IsNotExist(err) {
+		log.Println("Gazetteer not found, skipping offline cities", fp)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	// Alternate names can make for some long lines
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for sc.Scan() {
+		// geonameid, name, asciiname, alternatenames, latitude, longitude,
+		// feature class, feature code, country code, cc2, admin1 code, ...
+		cols := strings.Split(sc.Text(), "\t")
+		if len(cols) < 11 {
+			continue
+		}
+
+		lat, err := strconv.ParseFloat(cols[4], 64)
+		if err != nil {
+			return ErrGazetteer
+		}
+
+		long, err := strconv.ParseFloat(cols[5], 64)
+		if err != nil {
+			return ErrGazetteer
+		}
+
+		p := &place{
+			name:    cols[1],
+			country: cols[8],
+			state:   cols[10],
+			lat:     lat,
+			long:    long,
+		}
+
+		if strings.EqualFold(p.country, "ca") {
+			p.state = caAdminCodes[p.state]
+		}
+
+		c := getCell(lat, long)
+		g.places[c] = append(g.places[c], p)
+	}
+
+	return sc.Err()
+}
+
+type geoJSON struct {
+	Features []struct {
+		Properties struct {
+			Country string `json:"country"`
+			State   string `json:"state"`
+		} `json:"properties"`
+		Geometry struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+func (g *OfflineGeocoder) loadBoundaries(fp string) error {
+	f, err := os.Open(fp)
+	if os.IsNotExist(err) {
+		log.Println("Boundaries not found, skipping offline regions", fp)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var data geoJSON
+	if err := json.NewDecoder(f).Decode(&data); err != nil {
+		return err
+	}
+
+	for _, feat := range data.Features {
+		if feat.Properties.Country == "" {
+			return ErrBoundaries
+		}
+
+		var polygons [][][][2]float64
+		switch feat.Geometry.Type {
+		case "Polygon":
+			var poly [][][2]float64
+			if err := json.Unmarshal(feat.Geometry.Coordinates, &poly); err != nil {
+				return ErrBoundaries
+			}
+			polygons = [][][][2]float64{poly}
+		case "MultiPolygon":
+			if err := json.Unmarshal(feat.Geometry.Coordinates, &polygons); err != nil {
+				return ErrBoundaries
+			}
+		default:
+			continue
+		}
+
+		// Every polygon is indexed on its own so islands on both sides
+		// of the antimeridian don't cover the whole globe
+		for _, poly := range polygons {
+			if len(poly) == 0 || len(poly[0]) == 0 {
+				return ErrBoundaries
+			}
+
+			r := &region{
+				country: feat.Properties.Country,
+				state:   feat.Properties.State,
+				polygon: poly,
+			}
+
+			for _, ring := range r.polygon {
+				unwrapRing(ring)
+			}
+			r.setBounds()
+
+			for lat := int(math.Floor(r.minLat)); lat <= int(math.Floor(r.maxLat)); lat++ {
+				for long := int(math.Floor(r.minLong)); long <= int(math.Floor(r.maxLong)); long++ {
+					c := cell{lat, wrapCell(long)}
+					g.regions[c] = append(g.regions[c], r)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// unwrapRing shifts points after a jump of more than 180 degrees in
+// longitude so rings crossing the antimeridian stay continuous (i.e.
+// 179 -> -179 becomes 179 -> 181). The ring is then moved so it
+// starts west of 180
+func unwrapRing(ring [][2]float64) {
+	if len(ring) == 0 {
+		return
+	}
+
+	min := ring[0][0]
+	for i := 1; i < len(ring); i++ {
+		prev := ring[i-1][0]
+		for ring[i][0]-prev > 180 {
+			ring[i][0] -= 360
+		}
+		for ring[i][0]-prev < -180 {
+			ring[i][0] += 360
+		}
+		min = math.Min(min, ring[i][0])
+	}
+
+	for min < -180 {
+		for i := range ring {
+			ring[i][0] += 360
+		}
+		min += 360
+	}
+
+	for min >= 180 {
+		for i := range ring {
+			ring[i][0] -= 360
+		}
+		min -= 360
+	}
+}
+
+func (r *region) setBounds() {
+	r.minLat, r.minLong = math.Inf(1), math.Inf(1)
+	r.maxLat, r.maxLong = math.Inf(-1), math.Inf(-1)
+
+	// Holes are always inside the outer ring
+	for _, pt := range r.polygon[0] {
+		r.minLong, r.maxLong = math.Min(r.minLong, pt[0]), math.Max(r.maxLong, pt[0])
+		r.minLat, r.maxLat = math.Min(r.minLat, pt[1]), math.Max(r.maxLat, pt[1])
+	}
+}
+
+func (r *region) contains(lat, long float64) bool {
+	if long < r.minLong {
+		// Unwrapped rings go past 180
+		long += 360
+	}
+
+	if lat < r.minLat || lat > r.maxLat || long < r.minLong || long > r.maxLong {
+		return false
+	}
+
+	if !inWrappedRing(r.polygon[0], lat, long) {
+		return false
+	}
+
+	for _, hole := range r.polygon[1:] {
+		if inWrappedRing(hole, lat, long) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// inWrappedRing also checks the point a full turn around since holes
+// are unwrapped on their own and may sit 360 degrees off the outer ring
+func inWrappedRing(ring [][2]float64, lat, long float64) bool {
+	return inRing(ring, lat, long) || inRing(ring, lat, long-360) || inRing(ring, lat, long+360)
+}
+
+// inRing casts a ray east from the point and counts edge crossings
+func inRing(ring [][2]float64, lat, long float64) bool {
+	in := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+
+		if (yi > lat) != (yj > lat) && long < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			in = !in
+		}
+	}
+	return in
+}
+
+func (g *OfflineGeocoder) getRegion(lat, long float64) (match *region) {
+	for _, r := range g.regions[getCell(lat, long)] {
+		if !r.contains(lat, long) {
+			continue
+		}
+
+		// Prefer state boundaries over country boundaries
+		if match == nil || (match.state == "" && r.state != "") {
+			match = r
+		}
+	}
+	return
+}
+
+func (g *OfflineGeocoder) getNearestPlace(lat, long float64) (match *place) {
+	// Number of cells to look at around the point.. longitude degrees
+	// get narrower the further we are from the equator
+	latCells := int(math.Ceil(MAX_CITY_DISTANCE / milesPerDegree))
+	longCells := latCells
+	if cos := math.Cos(lat * math.Pi / 180); cos > 0.01 {
+		longCells = int(math.Ceil(MAX_CITY_DISTANCE / (milesPerDegree * cos)))
+	}
+
+	var (
+		center = getCell(lat, long)
+		best   = float64(MAX_CITY_DISTANCE)
+	)
+
+	for dLat := -latCells; dLat <= latCells; dLat++ {
+		for dLong := -longCells; dLong <= longCells; dLong++ {
+			for _, p := range g.places[cell{center.lat + dLat, wrapCell(center.long + dLong)}] {
+				if dist := Distance(lat, long, p.lat, p.long); dist <= best {
+					best = dist
+					match = p
+				}
+			}
+		}
+	}
+	return
+}
+
+func (g *OfflineGeocoder) Reverse(lat, long float64) *GeoRecord {
+	var (
+		rec = &GeoRecord{}
+		r   = g.getRegion(lat, long)
+		p   = g.getNearestPlace(lat, long)
+	)
+
+	if r != nil {
+		rec.Country = r.country
+		rec.State = r.state
+	}
+
+	// Skip the nearest city if it's across the border
+	if p != nil && (r == nil || strings.EqualFold(p.country, r.country)) {
+		rec.City = p.name
+		if rec.Country == "" {
+			rec.Country = p.country
+		}
+
+		if rec.State == "" {
+			rec.State = p.state
+		}
+	}
+
+	if rec.Country == "" {
+		return nil
+	}
+
+	return rec
+}
@@ -0,0 +1,175 @@
+package geo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	testGazetteer = "1\tLos Angeles\tLos Angeles\t\t34.05223\t-118.24368\tP\tPPLA2\tUS\t\tCA\n" +
+		"2\tSanta Monica\tSanta Monica\t\t34.01945\t-118.49119\tP\tPPL\tUS\t\tCA\n" +
+		"3\tToronto\tToronto\t\t43.70011\t-79.4163\tP\tPPLA\tCA\t\t08\n" +
+		"4\tSuva\tSuva\t\t-18.14161\t178.44149\tP\tPPLC\tFJ\t\t01\n" +
+		"5\tWaiyevo\tWaiyevo\t\t-16.79\t-179.98\tP\tPPL\tFJ\t\t03\n"
+
+	// Fiji's polygon crosses the antimeridian
+	testBoundaries = `{"features": [
+		{"properties": {"country": "US", "state": "CA"}, "geometry": {"type": "Polygon", "coordinates": [
+			[[-124.4, 32.5], [-114.1, 32.5], [-114.1, 42.0], [-124.4, 42.0], [-124.4, 32.5]]
+		]}},
+		{"properties": {"country": "US"}, "geometry": {"type": "Polygon", "coordinates": [
+			[[-125, 24.5], [-66.9, 24.5], [-66.9, 49.4], [-125, 49.4], [-125, 24.5]],
+			[[-90, 40], [-85, 40], [-85, 45], [-90, 45], [-90, 40]]
+		]}},
+		{"properties": {"country": "FJ"}, "geometry": {"type": "MultiPolygon", "coordinates": [
+			[[[177, -19], [-179, -19], [-179, -16], [177, -16], [177, -19]]]
+		]}}
+	]}`
+)
+
+func TestInRing(t *testing.T) {
+	var (
+		square = [][2]float64{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}
+		// L shape with the top right corner cut out
+		ell = [][2]float64{{0, 0}, {10, 0}, {10, 5}, {5, 5}, {5, 10}, {0, 10}, {0, 0}}
+
+		tests = []struct {
+			ring      [][2]float64
+			lat, long float64
+			ex        bool
+		}{
+			{square, 5, 5, true},
+			{square, 0.1, 9.9, true},
+			{square, 11, 5, false},
+			{square, 5, -1, false},
+			{ell, 2, 8, true},
+			{ell, 8, 2, true},
+			{ell, 8, 8, false},
+			{nil, 5, 5, false},
+		}
+	)
+
+	for _, ts := range tests {
+		if v := inRing(ts.ring, ts.lat, ts.long); v != ts.ex {
+			t.Errorf("wanted %v for %v,%v in %v, got %v", ts.ex, ts.lat, ts.long, ts.ring, v)
+		}
+	}
+}
+
+func TestGetNearestPlace(t *testing.T) {
+	g := newTestGeocoder(t)
+
+	tests := []struct {
+		lat, long float64
+		ex        string
+	}{
+		{34.06, -118.25, "Los Angeles"},
+		{34.02, -118.48, "Santa Monica"},
+		{43.65, -79.38, "Toronto"},
+		// Across the antimeridian from Waiyevo
+		{-16.8, 179.95, "Waiyevo"},
+		// Middle of nowhere
+		{0, 0, ""},
+		{36.5, -118.2, ""},
+	}
+
+	for _, ts := range tests {
+		var name string
+		if p := g.getNearestPlace(ts.lat, ts.long); p != nil {
+			name = p.name
+		}
+
+		if name != ts.ex {
+			t.Errorf("wanted %q for %v,%v, got %q", ts.ex, ts.lat, ts.long, name)
+		}
+	}
+}
+
+func TestReverse(t *testing.T) {
+	g := newTestGeocoder(t)
+
+	tests := []struct {
+		lat, long            float64
+		country, state, city string
+	}{
+		{34.06, -118.25, "US", "CA", "Los Angeles"},
+		{30, -100, "US", "", ""},
+		// Hole in the US polygon
+		{42, -87, "", "", ""},
+		// Both sides of the antimeridian
+		{-18.1, 178.4, "FJ", "01", "Suva"},
+		{-16.8, 179.95, "FJ", "03", "Waiyevo"},
+		{-17, -179.5, "FJ", "", ""},
+		// Toronto is in the rough US boundary so the city across the
+		// border is skipped
+		{43.65, -79.38, "US", "", ""},
+		{0, 0, "", "", ""},
+	}
+
+	for _, ts := range tests {
+		rec := g.Reverse(ts.lat, ts.long)
+		if ts.country == "" {
+			if rec != nil {
+				t.Errorf("wanted no record for %v,%v, got %+v", ts.lat, ts.long, rec)
+			}
+			continue
+		}
+
+		if rec == nil || rec.Country != ts.country || rec.State != ts.state || rec.City != ts.city {
+			t.Errorf("wanted %s/%s/%s for %v,%v, got %+v", ts.country, ts.state, ts.city, ts.lat, ts.long, rec)
+		}
+	}
+
+	// The Fiji polygon shouldn't be indexed all the way around the globe
+	for c, regions := range g.regions {
+		for _, r := range regions {
+			if r.country == "FJ" && (c.long > -179 && c.long < 177) {
+				t.Fatalf("Fiji indexed in cell %+v", c)
+			}
+		}
+	}
+}
+
+func TestMissingOfflineData(t *testing.T) {
+	g, err := NewOfflineGeocoder("/does/not/exist.txt", "/does/not/exist.geojson")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rec := g.Reverse(34.06, -118.25); rec != nil || !g.IsEmpty() {
+		t.Fatalf("expected no record, got %+v", rec)
+	}
+
+	if newTestGeocoder(t).IsEmpty() {
+		t.Fatal("expected offline data")
+	}
+}
+
+func newTestGeocoder(t *testing.T) *OfflineGeocoder {
+	dir, err := ioutil.TempDir("", "geo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		gazetteer  = filepath.Join(dir, "cities.txt")
+		boundaries = filepath.Join(dir, "boundaries.geojson")
+	)
+
+	if err := ioutil.WriteFile(gazetteer, []byte(testGazetteer), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(boundaries, []byte(testBoundaries), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := NewOfflineGeocoder(gazetteer, boundaries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
@@ -0,0 +1,54 @@
+package influencer
+
+import (
+	"math"
+	"sort"
+
+	"github.com/swayops/sway/internal/budget"
+	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/misc"
+)
+
+// RankDeals orders the influencer's offers by effective bid. Campaigns
+// with bidding pay the next highest bid (second price) without going
+// under the influencer's value or over their own bid
+func RankDeals(deals []*common.Deal, campaigns *common.Campaigns, agencyFee float64) []*common.Deal {
+	sort.Stable(OrderedDeals(deals))
+
+	for i, deal := range deals {
+		if deal.Bid == 0 {
+			continue
+		}
+
+		var next float64
+		if i+1 < len(deals) {
+			next = deals[i+1].GetBid()
+		}
+
+		price := math.Min(math.Max(next, deal.MaxYield), deal.Bid)
+		if price == deal.MaxYield {
+			continue
+		}
+		deal.MaxYield = price
+
+		if deal.Earnings > 0 {
+			dspFee, exchangeFee := -1.0, -1.0
+			if fees, ok := campaigns.GetAdvertiserFees(deal.AdvertiserId); ok {
+				dspFee, exchangeFee = fees.DSP, fees.Exchange
+			}
+
+			_, _, _, infPayout := budget.GetMargins(price, dspFee, exchangeFee, agencyFee)
+			deal.Earnings = misc.TruncateFloat(infPayout, 2)
+		}
+	}
+
+	return deals
+}
+
+// capBid keeps a bid under a limit the deal's value was checked against
+func capBid(bid, limit float64) float64 {
+	if bid > limit {
+		return limit
+	}
+	return bid
+}
@@ -0,0 +1,75 @@
+package influencer
+
+import (
+	"testing"
+
+	"github.com/swayops/sway/internal/common"
+)
+
+func TestRankDeals(t *testing.T) {
+	var (
+		campaigns = common.NewCampaigns(map[string]common.Fees{"adv": {DSP: 0.1, Exchange: 0.1}})
+		deals     = []*common.Deal{
+			{Id: "c", Bid: 5, MaxYield: 5, Earnings: 3},
+			{Id: "b", MaxYield: 6, Earnings: 3},
+			{Id: "a", AdvertiserId: "adv", Bid: 10, MaxYield: 4, Earnings: 2},
+			{Id: "e", Bid: 2, MaxYield: 1},
+			{Id: "d", AdvertiserId: "other", Bid: 8, MaxYield: 3, Earnings: 1},
+		}
+	)
+
+	ex := []struct {
+		id       string
+		maxYield float64
+		earnings float64
+	}{
+		// Pays the next bid with the advertiser's fees
+		{"a", 8, 5.12},
+		// Pays the next value with the default fees
+		{"d", 6, 2.88},
+		// No bid so nothing changes
+		{"b", 6, 3},
+		// Never goes under the influencer's value
+		{"c", 5, 3},
+		// Last offer has nothing to beat
+		{"e", 1, 0},
+	}
+
+	ranked := RankDeals(deals, campaigns, 0.2)
+	if len(ranked) != len(ex) {
+		t.Fatalf("wanted %d deals, got %d", len(ex), len(ranked))
+	}
+
+	for i, deal := range ranked {
+		if deal.Id != ex[i].id || deal.MaxYield != ex[i].maxYield || deal.Earnings != ex[i].earnings {
+			t.Errorf("%d: wanted %+v, got %s %v %v", i, ex[i], deal.Id, deal.MaxYield, deal.Earnings)
+		}
+
+		// Bids are a ceiling
+		if deal.Bid > 0 && deal.MaxYield > deal.Bid {
+			t.Errorf("%s: paying %v over the bid %v", deal.Id, deal.MaxYield, deal.Bid)
+		}
+	}
+
+	// A lone bidder pays its own value
+	deal := &common.Deal{Bid: 10, MaxYield: 4, Earnings: 2}
+	if RankDeals([]*common.Deal{deal}, campaigns, 0.2); deal.MaxYield != 4 || deal.Earnings != 2 {
+		t.Fatalf("unexpected lone deal: %+v", deal)
+	}
+}
+
+func TestCapBid(t *testing.T) {
+	tests := []struct {
+		bid, limit, ex float64
+	}{
+		{5, 10, 5},
+		{10, 10, 10},
+		{15, 10, 10},
+	}
+
+	for _, ts := range tests {
+		if v := capBid(ts.bid, ts.limit); v != ts.ex {
+			t.Errorf("%v/%v: wanted %v, got %v", ts.bid, ts.limit, ts.ex, v)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package influencer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/boltdb/bolt"
+	"github.com/swayops/sway/config"
+	"github.com/swayops/sway/internal/common"
+)
+
+// Influencer specific filters in the order GetAvailableDeals applies them
+var funnelFilters = []common.Rejection{
+	common.REJECT_SUBSCRIPTION,
+	common.REJECT_CATEGORY,
+	common.REJECT_TARGETING,
+	common.REJECT_DEAL_FOUND,
+	common.REJECT_GEO,
+	common.REJECT_GENDER,
+	common.REJECT_ADV_BLACKLIST,
+	common.REJECT_CMP_BLACKLIST,
+	common.REJECT_WHITELIST,
+	common.REJECT_EXCLUSIVITY,
+	common.REJECT_BRAND_SAFETY,
+	common.REJECT_RISK,
+	common.REJECT_FOLLOWERS,
+	common.REJECT_ENGAGEMENTS,
+	common.REJECT_LANGUAGE,
+	common.REJECT_AUDIENCE,
+	common.REJECT_PRICE,
+	common.REJECT_OUT_OF_RANGE,
+	common.REJECT_PACING,
+	common.REJECT_MAX_YIELD,
+	common.REJECT_NO_PLATFORM,
+}
+
+// What the advertiser can change to get rid of a filter
+var relaxMessages = map[common.Rejection]string{
+	common.REJECT_CATEGORY:     "Broadening categories, keywords or audiences",
+	common.REJECT_TARGETING:    "Simplifying the targeting expression",
+	common.REJECT_GEO:          "Removing geo targeting",
+	common.REJECT_GENDER:       "Targeting all genders",
+	common.REJECT_BRAND_SAFETY: "Turning off brand safety",
+	common.REJECT_RISK:         "Excluding fewer risk categories",
+	common.REJECT_LANGUAGE:     "Dropping language targeting",
+	common.REJECT_AUDIENCE:     "Loosening audience demographic targets",
+	common.REJECT_PACING:       "Switching to asap pacing",
+}
+
+type FunnelStep struct {
+	Filter     common.Rejection `json:"filter"`
+	Label      string           `json:"label"`
+	Eliminated int              `json:"eliminated"`
+	Remaining  int              `json:"remaining"`
+}
+
+type Blocker struct {
+	Filter common.Rejection `json:"filter"`
+	Label  string           `json:"label"`
+	Failed int              `json:"failed"` // Influencers failing this filter
+	Only   int              `json:"only"`   // Influencers failing nothing but this filter
+}
+
+type Suggestion struct {
+	Filter  common.Rejection `json:"filter"`
+	Message string           `json:"message"`
+	Adds    int              `json:"adds"` // Influencers that would become eligible
+}
+
+type Diagnostic struct {
+	CampaignId  string `json:"campaignId"`
+	Influencers int    `json:"influencers"` // Influencers considered
+	Eligible    int    `json:"eligible"`    // Influencers passing every filter
+
+	// Problems with the campaign itself.. nobody gets offers until
+	// these are sorted out
+	Campaign []*Blocker `json:"campaign,omitempty"`
+
+	Funnel      []*FunnelStep `json:"funnel"`
+	Blockers    []*Blocker    `json:"blockers"`
+	Suggestions []*Suggestion `json:"suggestions"`
+}
+
+// Checks in GetAvailableDeals that block every influencer.. these show
+// up as problems with the campaign rather than in the funnel
+var campaignFilters = []common.Rejection{
+	common.REJECT_INVALID,
+	common.REJECT_NO_DEALS,
+	common.REJECT_BUDGET,
+	common.REJECT_AVAIL_SPEND,
+	common.REJECT_NO_PERKS,
+}
+
+func isCampaignFilter(r common.Rejection) bool {
+	for _, f := range campaignFilters {
+		if f == r {
+			return true
+		}
+	}
+	return false
+}
+
+// funnelFilter returns the funnel step the rejection falls under
+func funnelFilter(r common.Rejection) common.Rejection {
+	switch r {
+	case common.REJECT_GENDER_UNI, common.REJECT_GENDER_F, common.REJECT_GENDER_M:
+		return common.REJECT_GENDER
+	}
+	return r
+}
+
+// Diagnose evaluates the campaign against all of the influencers and
+// explains why it isn't filling: how many influencers each filter knocks
+// out, which filters block the most and what loosening them would add
+func Diagnose(cmp *common.Campaign, infs []Influencer, campaigns *common.Campaigns, audiences *common.Audiences, db *bolt.DB, cfg *config.Config, agencyFee func(string) float64) *Diagnostic {
+	d := &Diagnostic{
+		CampaignId:  cmp.Id,
+		Influencers: len(infs),
+	}
+
+	var (
+		blocked    = make(map[common.Rejection]int)
+		eliminated = make(map[common.Rejection]int)
+		failed     = make(map[common.Rejection]int)
+		only       = make(map[common.Rejection][]*Influencer)
+	)
+
+	for i := range infs {
+		inf := &infs[i]
+		_, reasons := inf.matchCampaign(cmp, &dealMatch{
+			campaigns: campaigns,
+			audiences: audiences,
+			db:        db,
+			location:  inf.GetLatestGeo(),
+			agencyFee: agencyFee(inf.AgencyId),
+			cfg:       cfg,
+			all:       true,
+		})
+
+		// Reasons come back in funnel order
+		var rejections []common.Rejection
+		for _, r := range reasons {
+			if isCampaignFilter(r.Code) {
+				blocked[r.Code]++
+				continue
+			}
+			rejections = append(rejections, funnelFilter(r.Code))
+		}
+
+		if len(rejections) == 0 {
+			d.Eligible++
+			continue
+		}
+
+		eliminated[rejections[0]]++
+		for _, r := range rejections {
+			failed[r]++
+		}
+
+		if len(rejections) == 1 {
+			only[rejections[0]] = append(only[rejections[0]], inf)
+		}
+	}
+
+	for _, r := range campaignFilters {
+		if blocked[r] > 0 {
+			d.Campaign = append(d.Campaign, &Blocker{Filter: r, Label: r.Label(), Failed: blocked[r]})
+		}
+	}
+
+	remaining := len(infs)
+	for _, r := range funnelFilters {
+		if eliminated[r] == 0 {
+			continue
+		}
+
+		remaining -= eliminated[r]
+		d.Funnel = append(d.Funnel, &FunnelStep{
+			Filter:     r,
+			Label:      r.Label(),
+			Eliminated: eliminated[r],
+			Remaining:  remaining,
+		})
+
+		d.Blockers = append(d.Blockers, &Blocker{
+			Filter: r,
+			Label:  r.Label(),
+			Failed: failed[r],
+			Only:   len(only[r]),
+		})
+	}
+
+	// Filters that knock out the most influencers on their own are the
+	// ones worth looking at first
+	sort.SliceStable(d.Blockers, func(i, j int) bool {
+		if d.Blockers[i].Only != d.Blockers[j].Only {
+			return d.Blockers[i].Only > d.Blockers[j].Only
+		}
+		return d.Blockers[i].Failed > d.Blockers[j].Failed
+	})
+
+	d.Suggestions = getSuggestions(cmp, only)
+	return d
+}
+
+func getSuggestions(cmp *common.Campaign, only map[common.Rejection][]*Influencer) []*Suggestion {
+	var out []*Suggestion
+	for _, r := range funnelFilters {
+		infs := only[r]
+		if len(infs) == 0 {
+			continue
+		}
+
+		var sg *Suggestion
+		switch r {
+		case common.REJECT_FOLLOWERS:
+			values := make([]float64, 0, len(infs))
+			for _, inf := range infs {
+				values = append(values, float64(inf.GetFollowers()))
+			}
+
+			from, to, adds := widenRange(float64(cmp.FollowerTarget.From), float64(cmp.FollowerTarget.To), values)
+			sg = &Suggestion{Adds: adds, Message: fmt.Sprintf("Widening follower range to %s–%s", formatCount(from), formatCount(to))}
+		case common.REJECT_ENGAGEMENTS:
+			values := make([]float64, 0, len(infs))
+			for _, inf := range infs {
+				values = append(values, float64(inf.GetAvgEngs()))
+			}
+
+			from, to, adds := widenRange(float64(cmp.EngTarget.From), float64(cmp.EngTarget.To), values)
+			sg = &Suggestion{Adds: adds, Message: fmt.Sprintf("Widening engagement range to %s–%s", formatCount(from), formatCount(to))}
+		case common.REJECT_PRICE:
+			values := make([]float64, 0, len(infs))
+			for _, inf := range infs {
+				values = append(values, inf.GetPricingYield(cmp, cmp.Pricing))
+			}
+
+			from, to, adds := widenRange(cmp.PriceTarget.From, cmp.PriceTarget.To, values)
+			sg = &Suggestion{Adds: adds, Message: fmt.Sprintf("Widening price range to $%s–$%s", formatCount(from), formatCount(to))}
+		default:
+			msg, ok := relaxMessages[r]
+			if !ok {
+				continue
+			}
+			sg = &Suggestion{Adds: len(infs), Message: msg}
+		}
+
+		if sg.Adds > 0 {
+			sg.Filter = r
+			sg.Message += fmt.Sprintf(" adds %d eligible influencers", sg.Adds)
+			out = append(out, sg)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Adds > out[j].Adds
+	})
+
+	return out
+}
+
+// widenRange suggests a range that takes in most (80%) of the values
+// that fall outside of it and returns how many of them it takes in
+func widenRange(from, to float64, values []float64) (float64, float64, int) {
+	var below, above []float64
+	for _, v := range values {
+		if v < from {
+			below = append(below, v)
+		} else if v > to {
+			above = append(above, v)
+		}
+	}
+
+	sort.Float64s(below)
+	sort.Float64s(above)
+
+	if len(below) > 0 {
+		from = roundNice(below[len(below)/5], false)
+	}
+
+	if len(above) > 0 {
+		to = roundNice(above[len(above)-1-len(above)/5], true)
+	}
+
+	adds := 0
+	for _, v := range values {
+		if v >= from && v <= to {
+			adds++
+		}
+	}
+
+	return from, to, adds
+}
+
+// roundNice rounds to two significant digits (43,210 -> 43,000)
+func roundNice(v float64, up bool) float64 {
+	if v < 100 {
+		if up {
+			return math.Ceil(v)
+		}
+		return math.Floor(v)
+	}
+
+	mag := math.Pow(10, math.Floor(math.Log10(v))-1)
+	if up {
+		return math.Ceil(v/mag) * mag
+	}
+	return math.Floor(v/mag) * mag
+}
+
+// formatCount turns 5000 into "5k" and 1500000 into "1.5m"
+func formatCount(v float64) string {
+	switch {
+	case v >= 1000000:
+		return strconv.FormatFloat(v/1000000, 'f', -1, 64) + "m"
+	case v >= 1000:
+		return strconv.FormatFloat(v/1000, 'f', -1, 64) + "k"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
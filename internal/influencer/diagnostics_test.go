@@ -0,0 +1,299 @@
+package influencer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/swayops/sway/config"
+	"github.com/swayops/sway/internal/budget"
+	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/platforms/instagram"
+)
+
+func TestMatchCampaign(t *testing.T) {
+	db, cfg := newTestBudgetDb(t, 500)
+	defer db.Close()
+
+	var (
+		male   = newTestInfluencer("1", 5000, true)
+		female = newTestInfluencer("2", 50000, false)
+
+		tests = []struct {
+			name string
+			cmp  *common.Campaign
+			inf  *Influencer
+			all  bool
+			ex   []common.Rejection
+		}{
+			{"eligible", newTestCampaign(nil), male, false, nil},
+			{"first rejection", newTestCampaign(func(cmp *common.Campaign) {
+				cmp.Female = false
+				cmp.FollowerTarget = &common.Range{From: 1000, To: 10000}
+			}), female, false, []common.Rejection{common.REJECT_GENDER_M}},
+			{"all rejections", newTestCampaign(func(cmp *common.Campaign) {
+				cmp.Female = false
+				cmp.FollowerTarget = &common.Range{From: 1000, To: 10000}
+			}), female, true, []common.Rejection{common.REJECT_GENDER_M, common.REJECT_FOLLOWERS}},
+			{"out of range", newTestCampaign(func(cmp *common.Campaign) {
+				cmp.Pricing.Rate = 600
+			}), male, false, []common.Rejection{common.REJECT_OUT_OF_RANGE}},
+			{"pacing", newTestCampaign(func(cmp *common.Campaign) {
+				cmp.Pacing = budget.PACING_EVEN
+				cmp.Deals["3"] = &common.Deal{Id: "3", CampaignId: "cid", InfluencerId: "3", Assigned: int32(time.Now().Unix()), MaxYield: 400}
+			}), male, false, []common.Rejection{common.REJECT_PACING}},
+			{"max yield", newTestCampaign(func(cmp *common.Campaign) {
+				cmp.Pricing.Rate = 10
+				cmp.Perks = &common.Perk{Type: 1, Count: 5}
+			}), male, false, []common.Rejection{common.REJECT_MAX_YIELD}},
+			{"no platform", newTestCampaign(func(cmp *common.Campaign) {
+				cmp.Instagram = false
+				cmp.YouTube = true
+			}), male, false, []common.Rejection{common.REJECT_NO_PLATFORM}},
+		}
+	)
+
+	for _, ts := range tests {
+		deal, reasons := ts.inf.matchCampaign(ts.cmp, &dealMatch{
+			campaigns: common.NewCampaigns(nil),
+			db:        db,
+			cfg:       cfg,
+			all:       ts.all,
+		})
+
+		var codes []common.Rejection
+		for _, r := range reasons {
+			codes = append(codes, r.Code)
+		}
+
+		if !reflect.DeepEqual(codes, ts.ex) {
+			t.Errorf("%s: wanted %v, got %v", ts.name, ts.ex, codes)
+		}
+
+		if (deal != nil) != (len(ts.ex) == 0) {
+			t.Errorf("%s: unexpected deal %+v", ts.name, deal)
+		}
+	}
+}
+
+func TestGetAvailableDealsDetail(t *testing.T) {
+	db, cfg := newTestBudgetDb(t, 500)
+	defer db.Close()
+
+	campaigns := common.NewCampaigns(nil)
+	campaigns.SetCampaign("cid", *newTestCampaign(func(cmp *common.Campaign) {
+		cmp.Pricing.Rate = 10
+		cmp.Perks = &common.Perk{Type: 1, Count: 5}
+	}))
+
+	inf := newTestInfluencer("1", 5000, true)
+	deals, rejections := inf.GetAvailableDeals(campaigns, nil, db, "", "", nil, false, 0, cfg)
+	if len(deals) != 0 {
+		t.Fatalf("expected no deals, got %d", len(deals))
+	}
+
+	r := rejections["cid"]
+	if r == nil || r.Code != common.REJECT_MAX_YIELD || r.Detail == "" {
+		t.Fatalf("expected a detailed MAX_YIELD rejection, got %+v", r)
+	}
+
+	if ex := "MAX_YIELD " + r.Detail; r.String() != ex {
+		t.Fatalf("wanted %q, got %q", ex, r.String())
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	db, cfg := newTestBudgetDb(t, 500)
+	defer db.Close()
+
+	cmp := newTestCampaign(func(cmp *common.Campaign) {
+		cmp.Female = false
+		cmp.FollowerTarget = &common.Range{From: 1000, To: 10000}
+	})
+
+	infs := []Influencer{
+		*newTestInfluencer("1", 5000, true),
+		*newTestInfluencer("2", 50000, true),
+		*newTestInfluencer("3", 6000, false),
+		*newTestInfluencer("4", 60000, false),
+	}
+
+	noFee := func(string) float64 { return 0 }
+	d := Diagnose(cmp, infs, common.NewCampaigns(nil), nil, db, cfg, noFee)
+	if d.Influencers != 4 || d.Eligible != 1 || len(d.Campaign) != 0 {
+		t.Fatalf("unexpected diagnostic: %+v", d)
+	}
+
+	funnel := []FunnelStep{
+		{Filter: common.REJECT_GENDER, Label: common.REJECT_GENDER.Label(), Eliminated: 2, Remaining: 2},
+		{Filter: common.REJECT_FOLLOWERS, Label: common.REJECT_FOLLOWERS.Label(), Eliminated: 1, Remaining: 1},
+	}
+
+	if len(d.Funnel) != len(funnel) {
+		t.Fatalf("wanted %d funnel steps, got %d", len(funnel), len(d.Funnel))
+	}
+
+	for i, step := range d.Funnel {
+		if *step != funnel[i] {
+			t.Errorf("wanted %+v, got %+v", funnel[i], *step)
+		}
+	}
+
+	blockers := map[common.Rejection][2]int{
+		common.REJECT_GENDER:    {2, 1},
+		common.REJECT_FOLLOWERS: {2, 1},
+	}
+
+	for _, b := range d.Blockers {
+		if ex := blockers[b.Filter]; ex != [2]int{b.Failed, b.Only} {
+			t.Errorf("%s: wanted %v, got %d/%d", b.Filter, ex, b.Failed, b.Only)
+		}
+	}
+
+	// Budget problems show up on the campaign rather than the funnel
+	cmp.Status = false
+	d = Diagnose(cmp, infs, common.NewCampaigns(nil), nil, db, cfg, noFee)
+	if len(d.Campaign) != 1 || d.Campaign[0].Filter != common.REJECT_INVALID || d.Campaign[0].Failed != 4 || d.Eligible != 1 {
+		t.Fatalf("unexpected diagnostic: %+v", d)
+	}
+}
+
+func TestWidenRange(t *testing.T) {
+	tests := []struct {
+		from, to float64
+		values   []float64
+		exFrom   float64
+		exTo     float64
+		exAdds   int
+	}{
+		{1000, 10000, nil, 1000, 10000, 0},
+		{1000, 10000, []float64{500, 20000}, 500, 20000, 2},
+		{1000, 10000, []float64{43210}, 1000, 44000, 1},
+		// Outliers past 80% stay out
+		{1000, 10000, []float64{11000, 12000, 13000, 14000, 1000000}, 1000, 14000, 4},
+	}
+
+	for _, ts := range tests {
+		from, to, adds := widenRange(ts.from, ts.to, ts.values)
+		if from != ts.exFrom || to != ts.exTo || adds != ts.exAdds {
+			t.Errorf("%v: wanted %v-%v (%d), got %v-%v (%d)", ts.values, ts.exFrom, ts.exTo, ts.exAdds, from, to, adds)
+		}
+	}
+}
+
+func TestRoundNice(t *testing.T) {
+	tests := []struct {
+		v  float64
+		up bool
+		ex float64
+	}{
+		{43210, false, 43000},
+		{43210, true, 44000},
+		{12.5, false, 12},
+		{12.5, true, 13},
+		{999, true, 1000},
+	}
+
+	for _, ts := range tests {
+		if v := roundNice(ts.v, ts.up); v != ts.ex {
+			t.Errorf("%v (up %v): wanted %v, got %v", ts.v, ts.up, ts.ex, v)
+		}
+	}
+}
+
+func TestFormatCount(t *testing.T) {
+	tests := []struct {
+		v  float64
+		ex string
+	}{
+		{500, "500"},
+		{5000, "5k"},
+		{1500000, "1.5m"},
+	}
+
+	for _, ts := range tests {
+		if v := formatCount(ts.v); v != ts.ex {
+			t.Errorf("wanted %q, got %q", ts.ex, v)
+		}
+	}
+}
+
+func newTestCampaign(fn func(cmp *common.Campaign)) *common.Campaign {
+	cmp := &common.Campaign{
+		Id:           "cid",
+		AdvertiserId: "aid",
+		Budget:       1000,
+		Status:       true,
+		Approved:     1,
+		Instagram:    true,
+		Male:         true,
+		Female:       true,
+		Pricing:      &common.Pricing{Model: common.PRICING_FLAT, Rate: 100},
+		Deals: map[string]*common.Deal{
+			"1": {Id: "1", CampaignId: "cid"},
+			"2": {Id: "2", CampaignId: "cid"},
+		},
+	}
+
+	if fn != nil {
+		fn(cmp)
+	}
+	return cmp
+}
+
+func newTestInfluencer(id string, followers float64, male bool) *Influencer {
+	return &Influencer{
+		Id:           id,
+		EmailAddress: id + "@test.com",
+		Male:         male,
+		Female:       !male,
+		Categories:   []string{"food"},
+		BrandSafe:    "t",
+		Instagram: &instagram.Instagram{
+			Followers:   followers,
+			LastUpdated: int32(time.Now().Unix()),
+		},
+	}
+}
+
+func newTestBudgetDb(t *testing.T, spendable float64) (*bolt.DB, *config.Config) {
+	dir, err := ioutil.TempDir("", "budget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := bolt.Open(filepath.Join(dir, "test.db"), 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{}
+	cfg.Bucket.Budget = "budget"
+
+	store := map[string]*budget.Store{
+		"cid": {Spendable: spendable, NextBill: time.Now().AddDate(0, 0, 20).Unix()},
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket([]byte(cfg.Bucket.Budget))
+		if err != nil {
+			return err
+		}
+
+		val, err := json.Marshal(store)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte("aid"), val)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return db, cfg
+}
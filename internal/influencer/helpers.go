@@ -81,6 +81,53 @@ func GetMaxYield(cmp *common.Campaign, yt *youtube.YouTube, fb *facebook.Faceboo
 	return 0
 }
 
+// GetPricingYield returns the expected spend for the influencer's post
+// under the given pricing model
+func (inf *Influencer) GetPricingYield(cmp *common.Campaign, p *common.Pricing) float64 {
+	if cmp != nil && cmp.IsProductBasedBudget() {
+		return 0
+	}
+
+	switch p.GetModel() {
+	case common.PRICING_FLAT:
+		return p.Rate
+	case common.PRICING_CPC:
+		return inf.GetExpectedClicks() * p.Rate
+	case common.PRICING_CPA:
+		return inf.GetExpectedConversions() * p.Rate
+	}
+
+	return GetMaxYield(cmp, inf.YouTube, inf.Facebook, inf.Twitter, inf.Instagram)
+}
+
+// GetExpectedClicks returns the avg clicks for the influencer's completed
+// deals.. estimated off of engagements if they have none
+func (inf *Influencer) GetExpectedClicks() float64 {
+	if len(inf.CompletedDeals) == 0 {
+		return float64(inf.GetAvgEngs()) * budget.CLICK_RATE
+	}
+
+	var clicks int32
+	for _, deal := range inf.CompletedDeals {
+		clicks += deal.TotalStats().GetClicks()
+	}
+	return float64(clicks) / float64(len(inf.CompletedDeals))
+}
+
+// GetExpectedConversions returns the avg conversions for the influencer's
+// completed deals.. estimated off of clicks if they have none
+func (inf *Influencer) GetExpectedConversions() float64 {
+	var convs int
+	for _, deal := range inf.CompletedDeals {
+		convs += len(deal.TotalStats().Conversions)
+	}
+
+	if convs == 0 {
+		return inf.GetExpectedClicks() * budget.CONVERSION_RATE
+	}
+	return float64(convs) / float64(len(inf.CompletedDeals))
+}
+
 func prepend(keywords []string) []string {
 	out := []string{}
 	for _, kw := range keywords {
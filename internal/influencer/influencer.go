@@ -13,8 +13,12 @@ import (
 	"github.com/swayops/sway/config"
 	"github.com/swayops/sway/internal/budget"
 	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/internal/demographics"
 	"github.com/swayops/sway/internal/geo"
+	"github.com/swayops/sway/internal/language"
+	"github.com/swayops/sway/internal/safety"
 	"github.com/swayops/sway/internal/subscriptions"
+	"github.com/swayops/sway/internal/targeting"
 	"github.com/swayops/sway/internal/templates"
 	"github.com/swayops/sway/misc"
 	"github.com/swayops/sway/platforms"
@@ -26,13 +30,11 @@ import (
 	"github.com/swayops/sway/platforms/youtube"
 )
 
-const (
-	TimeoutDays = 25
-)
-
 var (
 	ErrAgency     = errors.New("No talent agency defined! Please contact engage@swayops.com")
 	ErrInviteCode = errors.New("Invite code passed in not found. Please verify URL with the talent agency or contact engage@swayops.com")
+	ErrPlatform   = errors.New("Platform not found!")
+	ErrInsights   = errors.New("Audience demographics were already supplied by the platform or an admin")
 )
 
 // The json struct accepted by the putInfluencer method
@@ -106,6 +108,10 @@ type Influencer struct {
 	Categories []string `json:"categories,omitempty"`
 	// Extracted from Imagga
 	Keywords []string `json:"keywords,omitempty"`
+	// Primary and secondary languages detected from bios and captions
+	Languages []*language.Language `json:"languages,omitempty"`
+	// Risk categories scored from bios, captions and keywords
+	Safety *safety.Report `json:"safety,omitempty"`
 
 	Strikes []*Strike `json:"strikes,omitempty"`
 
@@ -153,6 +159,9 @@ type Influencer struct {
 	// List of campaign IDs that stores whether we want to skip the MAX_YIELD
 	// check for this given campaign
 	SkipYield []string `json:"skipYield,omitempty"`
+
+	// List of campaign IDs that skip the exclusivity conflict check
+	SkipExclusivity []string `json:"skipExclusivity,omitempty"`
 }
 
 type Strike struct {
@@ -380,6 +389,8 @@ func (inf *Influencer) UpdateAll(cfg *config.Config) (private bool, err error) {
 		}
 	}
 
+	inf.setLanguages()
+	inf.setSafety(savePosts)
 	inf.LastSocialUpdate = int32(time.Now().Unix())
 
 	return private, nil
@@ -525,6 +536,70 @@ func (inf *Influencer) GetFollowers() int64 {
 	return fw
 }
 
+// GetDemographics returns the audience demographics of the influencer's
+// biggest platform that has them
+func (inf *Influencer) GetDemographics() *demographics.Demographics {
+	var (
+		d   *demographics.Demographics
+		max float64 = -1
+	)
+
+	if inf.Facebook != nil && inf.Facebook.Audience != nil && inf.Facebook.Followers > max {
+		d, max = inf.Facebook.Audience, inf.Facebook.Followers
+	}
+	if inf.Instagram != nil && inf.Instagram.Audience != nil && inf.Instagram.Followers > max {
+		d, max = inf.Instagram.Audience, inf.Instagram.Followers
+	}
+	if inf.Twitter != nil && inf.Twitter.Audience != nil && inf.Twitter.Followers > max {
+		d, max = inf.Twitter.Audience, inf.Twitter.Followers
+	}
+	if inf.YouTube != nil && inf.YouTube.Audience != nil && inf.YouTube.Subscribers > max {
+		d, max = inf.YouTube.Audience, inf.YouTube.Subscribers
+	}
+	return d
+}
+
+// SetDemographics saves supplied demographics to the given platform.
+// Insights pulled from the platform and admin supplied demographics are
+// never overwritten by the influencer
+func (inf *Influencer) SetDemographics(pl string, d *demographics.Demographics) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+
+	var target **demographics.Demographics
+	switch pl {
+	case platform.Facebook:
+		if inf.Facebook != nil {
+			target = &inf.Facebook.Audience
+		}
+	case platform.Instagram:
+		if inf.Instagram != nil {
+			target = &inf.Instagram.Audience
+		}
+	case platform.Twitter:
+		if inf.Twitter != nil {
+			target = &inf.Twitter.Audience
+		}
+	case platform.YouTube:
+		if inf.YouTube != nil {
+			target = &inf.YouTube.Audience
+		}
+	}
+
+	if target == nil {
+		return ErrPlatform
+	}
+
+	if !d.CanReplace(*target) {
+		return ErrInsights
+	}
+
+	d.Updated = int32(time.Now().Unix())
+	*target = d
+	return nil
+}
+
 func (inf *Influencer) GetAvgEngs() int64 {
 	var engs int64
 
@@ -716,6 +791,48 @@ func (inf *Influencer) GetPostURLs(ts int32) []string {
 	return urls
 }
 
+// setLanguages detects the influencer's languages from their bio, latest
+// posts and completed deal captions. Keeps the old languages if there
+// wasn't enough text to tell
+func (inf *Influencer) setLanguages() {
+	var texts []string
+	if inf.Instagram != nil {
+		texts = append(texts, inf.Instagram.Bio)
+	}
+	texts = append(texts, getCaptions(inf.Facebook, inf.Instagram, inf.Twitter, inf.YouTube)...)
+
+	for _, deal := range inf.CompletedDeals {
+		texts = append(texts, deal.Caption())
+	}
+
+	if langs := language.Detect(texts...); len(langs) > 0 {
+		inf.Languages = langs
+	}
+}
+
+func (inf *Influencer) setSafety(hasPosts bool) {
+	// Posts are only kept for influencers with active deals.. don't
+	// throw out an older report for one built off of just the bio
+	if !hasPosts && inf.Safety != nil {
+		return
+	}
+
+	var texts []string
+	if inf.Instagram != nil {
+		texts = append(texts, inf.Instagram.Bio)
+	}
+	texts = append(texts, getCaptions(inf.Facebook, inf.Instagram, inf.Twitter, inf.YouTube)...)
+
+	for _, deal := range inf.CompletedDeals {
+		texts = append(texts, deal.Caption())
+	}
+
+	if r := safety.Classify(texts, inf.Keywords); r != nil {
+		r.Carry(inf.Safety)
+		inf.Safety = r
+	}
+}
+
 func (inf *Influencer) setSwayRep() {
 	// Considers the following and returns a sway rep score:
 	// - Averages per post (likes, comments, shares etc)
@@ -751,6 +868,24 @@ func (inf *Influencer) setSwayRep() {
 	inf.CurrentRep = rep
 }
 
+// GetConflicts returns the influencer's deals with competitors that
+// fall within the exclusivity window around ts
+func (inf *Influencer) GetConflicts(ex *common.Exclusivity, advertiserId string, ts int32) []*common.Deal {
+	var conflicts []*common.Deal
+	for _, deal := range inf.ActiveDeals {
+		if ex.ConflictsWith(deal, advertiserId, ts) {
+			conflicts = append(conflicts, deal)
+		}
+	}
+
+	for _, deal := range inf.CompletedDeals {
+		if ex.ConflictsWith(deal, advertiserId, ts) {
+			conflicts = append(conflicts, deal)
+		}
+	}
+	return conflicts
+}
+
 func (inf *Influencer) CleanAssignedDeals() []*common.Deal {
 	var (
 		cleanDeals []*common.Deal
@@ -838,27 +973,49 @@ func (inf *Influencer) GetDescription() string {
 }
 
 func (inf *Influencer) GetLatestGeo() *geo.GeoRecord {
+	// Post geotags are more recent than the IP the influencer
+	// signed up with
+	var latest *geo.GeoRecord
+	if inf.Instagram != nil && inf.Instagram.LastLocation != nil {
+		latest = inf.Instagram.LastLocation
+	} else if inf.Twitter != nil && inf.Twitter.LastLocation != nil {
+		latest = inf.Twitter.LastLocation
+	} else if inf.Geo != nil {
+		latest = inf.Geo
+	}
+
 	if inf.Address != nil {
 		// Validity already been checked for state
 		// and country in the setAddress handler
-		return &geo.GeoRecord{
+		addr := &geo.GeoRecord{
 			State:   inf.Address.State,
 			Country: inf.Address.Country,
+			City:    inf.Address.City,
+			Zip:     inf.Address.Zip,
 			Source:  "address",
 		}
+
+		// Addresses don't have coordinates so borrow them from the
+		// latest geo if it's in the same area for radius targeting
+		if latest != nil && latest.HasCoords() && isSameArea(addr, latest) {
+			addr.Lat, addr.Long = latest.Lat, latest.Long
+		}
+		return addr
 	}
 
-	if inf.Instagram != nil && inf.Instagram.LastLocation != nil {
-		return inf.Instagram.LastLocation
-	} else if inf.Twitter != nil && inf.Twitter.LastLocation != nil {
-		return inf.Twitter.LastLocation
+	return latest
+}
+
+func isSameArea(a, b *geo.GeoRecord) bool {
+	if !strings.EqualFold(a.Country, b.Country) {
+		return false
 	}
 
-	if inf.Geo != nil {
-		return inf.Geo
+	if a.Zip != "" && b.Zip != "" {
+		return geo.NormalizeZip(a.Zip, a.Country) == geo.NormalizeZip(b.Zip, b.Country)
 	}
 
-	return nil
+	return a.City != "" && strings.EqualFold(a.City, b.City)
 }
 
 func (inf *Influencer) IsAmerican() bool {
@@ -874,14 +1031,170 @@ func (inf *Influencer) IsAmerican() bool {
 	return false
 }
 
-func (inf *Influencer) GetAvailableDeals(campaigns *common.Campaigns, audiences *common.Audiences, db *bolt.DB, forcedCampaign, forcedDeal string, location *geo.GeoRecord, query bool, agencyFee float64, cfg *config.Config) ([]*common.Deal, map[string]string) {
+// IsCategoryMatch returns whether the influencer matches any of the
+// campaign's categories, audiences or keywords (or it has none of them)
+func (inf *Influencer) IsCategoryMatch(cmp *common.Campaign, audiences *common.Audiences) bool {
+	if len(cmp.Categories) == 0 && len(cmp.Audiences) == 0 && len(cmp.Keywords) == 0 {
+		return true
+	}
+
+	for _, cat := range cmp.Categories {
+		for _, infCat := range inf.Categories {
+			if infCat == cat {
+				return true
+			}
+		}
+	}
+
+	for _, targetAudience := range cmp.Audiences {
+		if audiences.IsAllowed(targetAudience, inf.EmailAddress) {
+			return true
+		}
+	}
+
+	for _, kw := range cmp.Keywords {
+		for _, infKw := range inf.Keywords {
+			if common.IsExactMatch(kw, infKw) {
+				return true
+			}
+		}
+
+		if inf.Instagram != nil && inf.Instagram.Bio != "" {
+			if common.IsExactMatch(inf.Instagram.Bio, kw) {
+				return true
+			}
+		}
+
+		if inf.IsSearchInUsername(kw) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasCampaignHistory returns whether the influencer has (or had) a deal
+// for the campaign or can't get one again
+func (inf *Influencer) hasCampaignHistory(cid string) bool {
+	for _, d := range inf.ActiveDeals {
+		if d.CampaignId == cid {
+			return true
+		}
+	}
+
+	for _, d := range inf.CompletedDeals {
+		if d.CampaignId == cid {
+			return true
+		}
+	}
+
+	return misc.Contains(inf.Timeouts, cid) || misc.Contains(inf.Cancellations, cid) || misc.Contains(inf.Blacklist, cid)
+}
+
+func (inf *Influencer) getGenderRejection(cmp *common.Campaign) common.Rejection {
+	// If you are both (i.e. not applicable) and the campaign wants just one..
+	// skip!
+	if inf.Male && inf.Female && (!cmp.Male || !cmp.Female) {
+		return common.REJECT_GENDER_UNI
+	}
+
+	if !cmp.Male && cmp.Female && !inf.Female {
+		// Only want females
+		return common.REJECT_GENDER_F
+	} else if cmp.Male && !cmp.Female && !inf.Male {
+		// Only want males
+		return common.REJECT_GENDER_M
+	} else if !cmp.Male && !cmp.Female {
+		return common.REJECT_GENDER
+	}
+
+	return ""
+}
+
+// GetExclusion returns why the influencer can't work on the campaign no
+// matter how they got to it (targeting or a private invite)
+func (inf *Influencer) GetExclusion(cmp *common.Campaign) common.Rejection {
+	if out := inf.getExclusions(cmp); len(out) > 0 {
+		return out[0]
+	}
+	return ""
+}
+
+func (inf *Influencer) getExclusions(cmp *common.Campaign) []common.Rejection {
+	var out []common.Rejection
+
+	// Competitor check
+	if cmp.Exclusivity.IsSet() && !misc.Contains(inf.SkipExclusivity, cmp.Id) {
+		if conflicts := inf.GetConflicts(cmp.GetExclusivity(), cmp.AdvertiserId, int32(time.Now().Unix())); len(conflicts) > 0 {
+			out = append(out, common.REJECT_EXCLUSIVITY)
+		}
+	}
+
+	// Brand safety check
+	// If the campaign just wants brand safe and the influencer isn't brand safe..
+	if cmp.BrandSafe && (inf.BrandSafe != "t" || inf.GetFollowers() < 1000) {
+		out = append(out, common.REJECT_BRAND_SAFETY)
+	}
+
+	// Risk categories the campaign wants nothing to do with
+	if len(cmp.ExcludeRisks) > 0 && inf.Safety.Excludes(cmp.ExcludeRisks) {
+		out = append(out, common.REJECT_RISK)
+	}
+
+	return out
+}
+
+// getFreshPlatforms returns the campaign's platforms the influencer has
+// data for from the last 25 days
+// NOTE: Matches priority in GetMaxYield func
+func (inf *Influencer) getFreshPlatforms(cmp *common.Campaign) []string {
+	var out []string
+	if cmp.Instagram && inf.Instagram != nil && misc.WithinLast(inf.Instagram.LastUpdated, 24*25) {
+		out = append(out, platform.Instagram)
+	}
+
+	if cmp.YouTube && inf.YouTube != nil && misc.WithinLast(inf.YouTube.LastUpdated, 24*25) {
+		out = append(out, platform.YouTube)
+	}
+
+	if cmp.Twitter && inf.Twitter != nil && misc.WithinLast(inf.Twitter.LastUpdated, 24*25) {
+		out = append(out, platform.Twitter)
+	}
+
+	if cmp.Facebook && inf.Facebook != nil && misc.WithinLast(inf.Facebook.LastUpdated, 24*25) {
+		out = append(out, platform.Facebook)
+	}
+	return out
+}
+
+// dealMatch is everything GetAvailableDeals needs to match an
+// influencer with a single campaign
+type dealMatch struct {
+	campaigns  *common.Campaigns
+	audiences  *common.Audiences
+	db         *bolt.DB
+	forcedDeal string
+	location   *geo.GeoRecord
+	query      bool
+	agencyFee  float64
+	cfg        *config.Config
+
+	// Keep going after the first rejection so every filter the
+	// influencer fails gets reported (used by Diagnose)
+	all bool
+
+	// Built the first time a campaign has a targeting expression
+	subject *targeting.Subject
+}
+
+func (inf *Influencer) GetAvailableDeals(campaigns *common.Campaigns, audiences *common.Audiences, db *bolt.DB, forcedCampaign, forcedDeal string, location *geo.GeoRecord, query bool, agencyFee float64, cfg *config.Config) ([]*common.Deal, map[string]*common.Reason) {
 	// Iterates over all available deals in the system and matches them
 	// with the given influencer
 	// NOTE: The campaigns being passed only has campaigns with active
 	// advertisers and agencies
 
 	// Used purely for debugging..
-	rejections := make(map[string]string)
+	rejections := make(map[string]*common.Reason)
 
 	var (
 		infDeals []*common.Deal
@@ -903,6 +1216,17 @@ func (inf *Influencer) GetAvailableDeals(campaigns *common.Campaigns, audiences
 		location = inf.GetLatestGeo()
 	}
 
+	m := &dealMatch{
+		campaigns:  campaigns,
+		audiences:  audiences,
+		db:         db,
+		forcedDeal: forcedDeal,
+		location:   location,
+		query:      query,
+		agencyFee:  agencyFee,
+		cfg:        cfg,
+	}
+
 	var store map[string]common.Campaign
 	if forcedCampaign != "" {
 		store = campaigns.GetCampaignAsStore(forcedCampaign)
@@ -913,373 +1237,393 @@ func (inf *Influencer) GetAvailableDeals(campaigns *common.Campaigns, audiences
 	for _, cmp := range store {
 		// Store only contains campaigns with active advertisers with proper
 		// subscriptions!
-
-		// Check for advertiser eligibility!
-		if !subscriptions.CanInfluencerRun(cmp.AgencyId, cmp.Plan, inf.GetFollowers()) {
-			rejections[cmp.Id] = "INVALID_SUBSCRIPTION"
+		targetDeal, reasons := inf.matchCampaign(&cmp, m)
+		if len(reasons) > 0 {
+			rejections[cmp.Id] = reasons[0]
 			continue
 		}
+		infDeals = append(infDeals, targetDeal)
+	}
 
-		targetDeal := &common.Deal{}
-		dealFound := false
-		if !cmp.IsValid() {
-			rejections[cmp.Id] = "INVALID"
-			continue
-		}
+	if forcedCampaign == "" && !query {
+		infDeals = RankDeals(infDeals, campaigns, agencyFee)
+	}
 
-		for _, deal := range cmp.Deals {
-			// Query is only passed in from getDeal so an influencer can view deals they're
-			// currently assigned to
-			if (query || (deal.IsAvailable())) && !dealFound {
-				if forcedDeal != "" && deal.Id != forcedDeal {
-					continue
-				}
-				// Make a copy of the deal rather than assign the pointer
-				*targetDeal = *deal
-				dealFound = true
-			}
-		}
+	return infDeals, rejections
+}
 
-		if !dealFound {
-			// This campaign has no active deals
-			rejections[cmp.Id] = "NO_ACTIVE_DEALS"
-			continue
-		}
+// matchCampaign runs the influencer through the campaign's filters and
+// returns the deal they'd be offered or why they won't be. Unless m.all
+// is set it stops at the first rejection
+func (inf *Influencer) matchCampaign(cmp *common.Campaign, m *dealMatch) (*common.Deal, []*common.Reason) {
+	var (
+		out   []*common.Reason
+		query = m.query
+	)
 
-		// Filter Checks
-		if len(cmp.Categories) > 0 || len(cmp.Audiences) > 0 || len(cmp.Keywords) > 0 {
-			catFound := false
-		L1:
-			for _, cat := range cmp.Categories {
-				for _, infCat := range inf.Categories {
-					if infCat == cat {
-						catFound = true
-						break L1
-					}
-				}
-			}
+	// Records the rejection and returns whether we should bail
+	reject := func(code common.Rejection, detail string) bool {
+		out = append(out, &common.Reason{Code: code, Detail: detail})
+		return !m.all
+	}
 
-			if !catFound {
-				for _, targetAudience := range cmp.Audiences {
-					if audiences.IsAllowed(targetAudience, inf.EmailAddress) {
-						catFound = true
-						break
-					}
-				}
-			}
+	// Check for advertiser eligibility!
+	if !subscriptions.CanInfluencerRun(cmp.AgencyId, cmp.Plan, inf.GetFollowers()) && reject(common.REJECT_SUBSCRIPTION, "") {
+		return nil, out
+	}
 
-			if !catFound {
-				for _, kw := range cmp.Keywords {
-					if catFound {
-						break
-					}
-
-					for _, infKw := range inf.Keywords {
-						if common.IsExactMatch(kw, infKw) {
-							catFound = true
-							break
-						}
-					}
-
-					if inf.Instagram != nil && inf.Instagram.Bio != "" {
-						if common.IsExactMatch(inf.Instagram.Bio, kw) {
-							catFound = true
-							break
-						}
-					}
-
-					if inf.IsSearchInUsername(kw) {
-						catFound = true
-						break
-					}
-				}
-			}
+	if !cmp.IsValid() && reject(common.REJECT_INVALID, "") {
+		return nil, out
+	}
 
-			if !catFound {
-				rejections[cmp.Id] = "CAT_NOT_FOUND"
-				continue
-			}
-		}
+	targetDeal := &common.Deal{}
+	dealFound := false
 
-		// If you already have a/have done deal for this campaign, screw off
-		dealFound = false
-		if !query {
-			// With the query flag beign used by getDeal,
-			// we may be looking for details on an assigned deal
-			for _, d := range inf.ActiveDeals {
-				if d.CampaignId == targetDeal.CampaignId {
-					dealFound = true
-				}
-			}
+	// Private invites hold a deal for the invitee and skip targeting..
+	// hard exclusions (brand safety, risks, competitors) still apply
+	invite := cmp.GetLiveInvite(inf.Id)
+	reserved := cmp.GetReservedDeals(inf.Id)
 
-			for _, d := range inf.CompletedDeals {
-				if d.CampaignId == targetDeal.CampaignId {
-					dealFound = true
-				}
+	for _, deal := range cmp.Deals {
+		// Query is only passed in from getDeal so an influencer can view deals they're
+		// currently assigned to
+		if (query || (deal.IsAvailable())) && !dealFound {
+			if m.forcedDeal != "" && deal.Id != m.forcedDeal && cmp.Deals[m.forcedDeal] != nil {
+				// Forced deals only narrow down their own campaign
+				// so the rest of the auction is still offered
+				continue
 			}
 
-			for _, cid := range inf.Timeouts {
-				if cid == targetDeal.CampaignId {
-					dealFound = true
-				}
+			if !query && (reserved[deal.Id] || (invite != nil && invite.DealId != "" && deal.Id != invite.DealId)) {
+				continue
 			}
+			// Make a copy of the deal rather than assign the pointer
+			*targetDeal = *deal
+			dealFound = true
+		}
+	}
 
-			for _, cid := range inf.Cancellations {
-				if cid == targetDeal.CampaignId {
-					dealFound = true
-				}
-			}
+	// This campaign has no active deals
+	if !dealFound && reject(common.REJECT_NO_DEALS, "") {
+		return nil, out
+	}
 
-			for _, cid := range inf.Blacklist {
-				if cid == targetDeal.CampaignId {
-					dealFound = true
-				}
-			}
-		}
+	// Filter Checks
+	if invite == nil && !inf.IsCategoryMatch(cmp, m.audiences) && reject(common.REJECT_CATEGORY, "") {
+		return nil, out
+	}
 
-		if dealFound {
-			rejections[cmp.Id] = "DEAL_FOUND"
-			continue
+	if cmp.Targeting != "" && !query && invite == nil {
+		if m.subject == nil {
+			m.subject = inf.GetSubject(m.audiences, m.location)
 		}
 
-		// Match Campaign Geo Targeting with Influencer Geo //
-		if !inf.SkipGeo && !misc.Contains(inf.GeoSkips, cmp.Id) && !geo.IsGeoMatch(cmp.Geos, location) && !query {
-			rejections[cmp.Id] = "GEO_MATCH"
-			continue
+		if !targeting.Match(cmp.Targeting, m.subject) && reject(common.REJECT_TARGETING, "") {
+			return nil, out
 		}
+	}
 
-		// Gender check
-		if !query {
-			// If you are both (i.e. not applicable) and the campaign wants just one..
-			// skip!
-			if inf.Male && inf.Female && (!cmp.Male || !cmp.Female) {
-				rejections[cmp.Id] = "GENDER_UNI"
-				continue
-			}
+	// If you already have a/have done deal for this campaign, screw off
+	// With the query flag beign used by getDeal,
+	// we may be looking for details on an assigned deal
+	if !query && inf.hasCampaignHistory(cmp.Id) && reject(common.REJECT_DEAL_FOUND, "") {
+		return nil, out
+	}
 
-			if !cmp.Male && cmp.Female && !inf.Female {
-				// Only want females
-				rejections[cmp.Id] = "GENDER_F"
-				continue
-			} else if cmp.Male && !cmp.Female && !inf.Male {
-				// Only want males
-				rejections[cmp.Id] = "GENDER_M"
-				continue
-			} else if !cmp.Male && !cmp.Female {
-				rejections[cmp.Id] = "GENDER"
-				continue
-			}
+	// Match Campaign Geo Targeting with Influencer Geo //
+	if invite == nil && !inf.SkipGeo && !misc.Contains(inf.GeoSkips, cmp.Id) && !geo.IsGeoMatch(cmp.Geos, m.location) && !query {
+		if reject(common.REJECT_GEO, "") {
+			return nil, out
 		}
+	}
 
-		// Whitelisting is done at the campaign level.. but
-		// lets check the advertiser blacklist!
-		_, ok := cmp.AdvertiserBlacklist[inf.Id]
-		if ok {
-			// We found this influencer in the blacklist!
-			rejections[cmp.Id] = "ADV_BLACKLIST"
-			continue
+	// Gender check
+	if !query && invite == nil {
+		if r := inf.getGenderRejection(cmp); r != "" && reject(r, "") {
+			return nil, out
 		}
+	}
 
-		// Lets also check the campaign blacklist
-		_, ok = cmp.CampaignBlacklist[inf.EmailAddress]
-		if ok {
-			// We found this influencer in the blacklist!
-			rejections[cmp.Id] = "CMP_BLACKLIST"
-			continue
-		}
+	// Whitelisting is done at the campaign level.. but
+	// lets check the advertiser blacklist!
+	if _, ok := cmp.AdvertiserBlacklist[inf.Id]; ok && reject(common.REJECT_ADV_BLACKLIST, "") {
+		// We found this influencer in the blacklist!
+		return nil, out
+	}
 
-		// Whitelist check!
-		if len(cmp.Whitelist) > 0 {
-			schedule, ok := cmp.Whitelist[inf.EmailAddress]
-			if !ok {
-				// There was a whitelist and they're not in it!
-				rejections[cmp.Id] = "CMP_WHITELIST"
-				continue
-			}
+	// Lets also check the campaign blacklist
+	if _, ok := cmp.CampaignBlacklist[inf.EmailAddress]; ok && reject(common.REJECT_CMP_BLACKLIST, "") {
+		// We found this influencer in the blacklist!
+		return nil, out
+	}
 
-			if schedule != nil && schedule.From > 0 && schedule.To > 0 {
-				targetDeal.From = schedule.From
-				targetDeal.To = schedule.To
-			}
+	// Whitelist check!
+	if len(cmp.Whitelist) > 0 && invite == nil {
+		schedule, ok := cmp.Whitelist[inf.EmailAddress]
+		if !ok && reject(common.REJECT_WHITELIST, "") {
+			// There was a whitelist and they're not in it!
+			return nil, out
 		}
 
-		// Brand safety check
-		// If the campaign just wants brand safe and the influencer isn't brand safe..
-		if cmp.BrandSafe && !query {
-			if inf.BrandSafe != "t" || inf.GetFollowers() < 1000 {
-				rejections[cmp.Id] = "BRAND_SAFETY"
-				continue
+		if schedule != nil && schedule.From > 0 && schedule.To > 0 {
+			targetDeal.From = schedule.From
+			targetDeal.To = schedule.To
+		}
+	}
+
+	// Competitor, brand safety and risk checks.. these apply to
+	// invites too
+	if !query {
+		for _, r := range inf.getExclusions(cmp) {
+			if reject(r, "") {
+				return nil, out
 			}
 		}
+	}
 
-		// Follower check
-		if cmp.FollowerTarget != nil && !cmp.FollowerTarget.InRange(inf.GetFollowers()) && !query {
-			rejections[cmp.Id] = "FOLLOWER_TARGETING"
-			continue
+	// Follower check
+	if cmp.FollowerTarget != nil && !cmp.FollowerTarget.InRange(inf.GetFollowers()) && !query && invite == nil {
+		if reject(common.REJECT_FOLLOWERS, "") {
+			return nil, out
 		}
+	}
 
-		// Engagements check
-		if cmp.EngTarget != nil && !cmp.EngTarget.InRange(inf.GetAvgEngs()) && !query {
-			rejections[cmp.Id] = "ENG_TARGETING"
-			continue
+	// Engagements check
+	if cmp.EngTarget != nil && !cmp.EngTarget.InRange(inf.GetAvgEngs()) && !query && invite == nil {
+		if reject(common.REJECT_ENGAGEMENTS, "") {
+			return nil, out
 		}
+	}
 
-		// Fill in and check available spendable
-		budgetStore, err := budget.GetCampaignStoreFromDb(db, cfg, cmp.Id, cmp.AdvertiserId)
-		if err != nil || budgetStore == nil {
-			log.Println("Error when opening store", err)
-			continue
+	// Language check
+	if len(cmp.Languages) > 0 && !language.Speaks(inf.Languages, cmp.Languages) && !query && invite == nil {
+		if reject(common.REJECT_LANGUAGE, "") {
+			return nil, out
 		}
+	}
 
-		if budgetStore.IsClosed(&cmp) {
-			if !query {
-				// Influencer may query for their assigned deal.. but we don't want to
-				// hide the deal if there's no spendable.. we just want to tell them that
-				// there's 0 spendable
-				rejections[cmp.Id] = "BUDGET"
-				continue
-			}
+	// Audience demographics check
+	if len(cmp.AudienceTargets) > 0 && !demographics.IsMatch(cmp.AudienceTargets, inf.GetDemographics()) && !query && invite == nil {
+		if reject(common.REJECT_AUDIENCE, "") {
+			return nil, out
 		}
+	}
 
-		targetDeal.MaxYield = GetMaxYield(&cmp, inf.YouTube, inf.Facebook, inf.Twitter, inf.Instagram)
+	// Fill in and check available spendable
+	budgetStore, err := budget.GetCampaignStoreFromDb(m.db, m.cfg, cmp.Id, cmp.AdvertiserId)
+	if err != nil || budgetStore == nil {
+		log.Println("Error when opening store", err)
+		reject(common.REJECT_BUDGET, "")
+		return nil, out
+	}
 
-		// Lets see if max yield falls into target range for the campaign
-		if cmp.PriceTarget != nil && !cmp.PriceTarget.InRange(targetDeal.MaxYield) && !query {
-			rejections[cmp.Id] = "PRICE_TARGET"
-			continue
-		}
+	// Influencer may query for their assigned deal.. but we don't want to
+	// hide the deal if there's no spendable.. we just want to tell them that
+	// there's 0 spendable
+	if budgetStore.IsClosed(cmp) && !query && reject(common.REJECT_BUDGET, "") {
+		return nil, out
+	}
 
-		// Subtract default margins to give influencers an accurate likely earning value
-		// NOTE: Mimics logic in depleteBudget functionality of sway engine
-		var dspFee, exchangeFee float64
-		fees, ok := campaigns.GetAdvertiserFees(cmp.AdvertiserId)
-		if ok {
-			dspFee = fees.DSP
-			exchangeFee = fees.Exchange
-		} else {
-			dspFee = -1
-			exchangeFee = -1
-		}
+	// Even pacing holds back offers once today's target has been
+	// picked up and loosens price ranges when behind
+	pace := budgetStore.GetPace(cmp, false)
 
-		_, _, _, infPayout := budget.GetMargins(targetDeal.MaxYield, dspFee, exchangeFee, agencyFee)
-		if budgetStore != nil && !cmp.IsProductBasedBudget() {
-			// Generate likely earnings for the influencer
-			// Note: For query lookups, the Earnings at assignDeal time is the one shown
-			if !query || targetDeal.Earnings == 0 {
-				targetDeal.Earnings = misc.TruncateFloat(infPayout, 2)
-			}
+	// Assigned deals keep the pricing they accepted
+	pricing := targetDeal.Pricing
+	if pricing == nil {
+		pricing = cmp.Pricing
+	}
+	targetDeal.MaxYield = inf.GetPricingYield(cmp, pricing)
 
-			// Generate pending spend (based on deals that are assigned
-			// and how much they should spend)
-			pendingSpend, _ := cmp.GetPendingDetails()
+	// Lets see if max yield falls into target range for the campaign
+	var priceTarget *common.FloatRange
+	if cmp.PriceTarget != nil {
+		min, max := pace.Loosen(cmp.PriceTarget.From, cmp.PriceTarget.To)
+		priceTarget = &common.FloatRange{From: min, To: max}
+	}
 
-			// Subtract pending spend remaining spendable
-			availSpend := budgetStore.Spendable - pendingSpend
+	if priceTarget != nil && !priceTarget.InRange(targetDeal.MaxYield) && !query && invite == nil {
+		if reject(common.REJECT_PRICE, "") {
+			return nil, out
+		}
+	}
 
-			if availSpend <= 0 && !cfg.Sandbox && !query {
-				rejections[cmp.Id] = "AVAIL_SPEND"
-				continue
-			}
+	// Usage rights are priced on top of the influencer's value. Assigned
+	// deals keep the rights they accepted
+	rights := targetDeal.Rights
+	if rights == nil {
+		rights = cmp.Rights
+	}
+	targetDeal.MaxYield += targetDeal.MaxYield * rights.GetUplift()
 
-			// If the total $$$ this influencer will generate is above available spend..
-			// BAIL!
-			if targetDeal.MaxYield > availSpend && !cfg.Sandbox && !query {
-				rejections[cmp.Id] = "OUT_OF_RANGE"
-				continue
-			}
+	platforms := inf.getFreshPlatforms(cmp)
+	if cmp.Bidding != nil && invite == nil && !query && len(platforms) > 0 && pricing.GetModel() == common.PRICING_ENGAGEMENT {
+		// RankDeals may price the deal anywhere up to the bid so the
+		// bid has to hold up to the same checks as the value
+		targetDeal.Bid = cmp.Bidding.GetBid(targetDeal.MaxYield, inf.GetFollowers(), inf.Categories, platforms[0])
+		if priceTarget != nil && priceTarget.To > 0 {
+			targetDeal.Bid = capBid(targetDeal.Bid, priceTarget.To*(1+rights.GetUplift()))
+		}
+	}
 
-			targetDeal.Spendable = misc.TruncateFloat(budgetStore.Spendable, 2)
-			if !misc.Contains(inf.SkipYield, cmp.Id) && !query && !cfg.Sandbox && len(cmp.Whitelist) == 0 && cmp.Perks != nil && cmp.Perks.GetType() == "Product" {
-				// NOTE: Skip this for whitelisted campaigns and non-product perk campaigns!
+	// Subtract default margins to give influencers an accurate likely earning value
+	// NOTE: Mimics logic in depleteBudget functionality of sway engine
+	var dspFee, exchangeFee float64
+	fees, ok := m.campaigns.GetAdvertiserFees(cmp.AdvertiserId)
+	if ok {
+		dspFee = fees.DSP
+		exchangeFee = fees.Exchange
+	} else {
+		dspFee = -1
+		exchangeFee = -1
+	}
 
-				// OPTIMIZATION: Goal is to distribute products and funds evenly
-				// given what the campaign's product perk count is and how
-				// many funds we have left
+	if invite != nil && invite.Payout > 0 {
+		// Invites carry a fixed payout so lets work backwards to the spend
+		targetDeal.MaxYield = budget.GetTotalFromPayout(invite.Payout, dspFee, exchangeFee, m.agencyFee)
+	}
 
-				min, max := cmp.GetTargetYield(targetDeal.Spendable)
-				if targetDeal.MaxYield < min || targetDeal.MaxYield > max || targetDeal.MaxYield == 0 {
-					rejections[cmp.Id] = fmt.Sprintf("MAX_YIELD Min: %02f, Max: %02f, Yield: %02f", min, max, targetDeal.MaxYield)
-					continue
-				}
-			}
+	_, _, _, infPayout := budget.GetMargins(targetDeal.MaxYield, dspFee, exchangeFee, m.agencyFee)
+	if !cmp.IsProductBasedBudget() {
+		// Generate likely earnings for the influencer
+		// Note: For query lookups, the Earnings at assignDeal time is the one shown
+		if !query || targetDeal.Earnings == 0 {
+			targetDeal.Earnings = misc.TruncateFloat(infPayout, 2)
 		}
 
-		if !query {
-			// If it's a perk campaign and there's no perks to give.. BAIL!
-			if cmp.Perks != nil && cmp.Perks.Count == 0 {
-				rejections[cmp.Id] = "NO_PERKS"
-				continue
-			}
-		}
+		// Generate pending spend (based on deals that are assigned
+		// and how much they should spend)
+		pendingSpend, _ := cmp.GetPendingDetails()
 
-		// Social Media Checks
-		// NOTE: Matches priority in GetMaxYield func
-		// Also checking to make sure the data has been updated in the last 25 days
-		if cmp.Instagram && inf.Instagram != nil && misc.WithinLast(inf.Instagram.LastUpdated, 24*25) {
-			if !common.IsInList(targetDeal.Platforms, platform.Instagram) {
-				targetDeal.Platforms = append(targetDeal.Platforms, platform.Instagram)
-			}
+		// Subtract pending spend remaining spendable
+		availSpend := budgetStore.Spendable - pendingSpend
+
+		if availSpend <= 0 && !m.cfg.Sandbox && !query && reject(common.REJECT_AVAIL_SPEND, "") {
+			return nil, out
 		}
 
-		if cmp.YouTube && inf.YouTube != nil && misc.WithinLast(inf.YouTube.LastUpdated, 24*25) {
-			if !common.IsInList(targetDeal.Platforms, platform.YouTube) {
-				targetDeal.Platforms = append(targetDeal.Platforms, platform.YouTube)
+		// If the total $$$ this influencer will generate is above available spend..
+		// BAIL!
+		if targetDeal.MaxYield > availSpend && !m.cfg.Sandbox && !query {
+			if reject(common.REJECT_OUT_OF_RANGE, fmt.Sprintf("Yield: %02f, Available: %02f", targetDeal.MaxYield, availSpend)) {
+				return nil, out
 			}
 		}
 
-		if cmp.Twitter && inf.Twitter != nil && misc.WithinLast(inf.Twitter.LastUpdated, 24*25) {
-			if !common.IsInList(targetDeal.Platforms, platform.Twitter) {
-				targetDeal.Platforms = append(targetDeal.Platforms, platform.Twitter)
-			}
+		if !m.cfg.Sandbox {
+			targetDeal.Bid = capBid(targetDeal.Bid, availSpend)
 		}
 
-		if cmp.Facebook && inf.Facebook != nil && misc.WithinLast(inf.Facebook.LastUpdated, 24*25) {
-			if !common.IsInList(targetDeal.Platforms, platform.Facebook) {
-				targetDeal.Platforms = append(targetDeal.Platforms, platform.Facebook)
-			}
+		if pace.IsAhead() && !m.cfg.Sandbox && !query && invite == nil && reject(common.REJECT_PACING, "") {
+			return nil, out
 		}
 
-		// Add deal that has approved platform
-		if len(targetDeal.Platforms) > 0 {
-			targetDeal.Platforms = []string{targetDeal.Platforms[0]}
-			targetDeal.Tags = cmp.Tags
-			targetDeal.Mention = cmp.Mention
-			targetDeal.Task = cmp.Task
-			if cmp.Perks != nil && inf.Id != "385" {
-				var code string
-				if targetDeal.Perk != nil {
-					code = targetDeal.Perk.Code
+		targetDeal.Spendable = misc.TruncateFloat(budgetStore.Spendable, 2)
+		if !misc.Contains(inf.SkipYield, cmp.Id) && !query && !m.cfg.Sandbox && len(cmp.Whitelist) == 0 && invite == nil && cmp.Perks != nil && cmp.Perks.GetType() == "Product" {
+			// NOTE: Skip this for whitelisted campaigns and non-product perk campaigns!
+
+			// OPTIMIZATION: Goal is to distribute products and funds evenly
+			// given what the campaign's product perk count is and how
+			// many funds we have left
+
+			min, max := pace.Loosen(cmp.GetTargetYield(targetDeal.Spendable))
+			if targetDeal.MaxYield < min || targetDeal.MaxYield > max || targetDeal.MaxYield == 0 {
+				if reject(common.REJECT_MAX_YIELD, fmt.Sprintf("Min: %02f, Max: %02f, Yield: %02f", min, max, targetDeal.MaxYield)) {
+					return nil, out
 				}
-				targetDeal.Perk = &common.Perk{
-					Name:         cmp.Perks.Name,
-					Instructions: cmp.Perks.Instructions,
-					Category:     cmp.Perks.GetType(),
-					Code:         code,
-					Count:        1}
 			}
-			targetDeal.RequiresSubmission = cmp.RequiresSubmission
+			targetDeal.Bid = capBid(targetDeal.Bid, max)
+		}
+	}
 
-			if targetDeal.Link == "" {
-				// getDeal queries for an active deal so it already has
-				// a link set!
-				targetDeal.Link = cmp.Link
-			}
+	// If it's a perk campaign and there's no perks to give.. BAIL!
+	if !query && cmp.Perks != nil && cmp.Perks.Count == 0 && reject(common.REJECT_NO_PERKS, "") {
+		return nil, out
+	}
 
-			// Add some display attributes..
-			// These will be saved permanently if they accept deal!
-			targetDeal.CampaignName = cmp.Name
-			targetDeal.CampaignImage = cmp.ImageURL
-			targetDeal.Company = cmp.Company
+	// Social Media Checks
+	// NOTE: Matches priority in GetMaxYield func
+	// Also checking to make sure the data has been updated in the last 25 days
+	for _, pl := range platforms {
+		if !common.IsInList(targetDeal.Platforms, pl) {
+			targetDeal.Platforms = append(targetDeal.Platforms, pl)
+		}
+	}
 
-			targetDeal.TermsAndConditions = cmp.TermsAndConditions
+	// Add deal that has approved platform
+	if len(targetDeal.Platforms) == 0 {
+		reject(common.REJECT_NO_PLATFORM, "")
+	}
 
-			infDeals = append(infDeals, targetDeal)
-		} else {
-			rejections[cmp.Id] = "NO_PLATFORM"
+	if len(out) > 0 {
+		return nil, out
+	}
+
+	targetDeal.Platforms = []string{targetDeal.Platforms[0]}
+
+	targetDeal.Tags = cmp.Tags
+	targetDeal.Mention = cmp.Mention
+	targetDeal.Task = cmp.Task
+	if v := cmp.GetVariant(targetDeal.VariantId); v != nil {
+		// Assigned deals keep their variant's requirements
+		v.Apply(targetDeal)
+	}
+	if cmp.Perks != nil && inf.Id != "385" {
+		var code, sku, variant string
+		if targetDeal.Perk != nil {
+			code, sku, variant = targetDeal.Perk.Code, targetDeal.Perk.SKU, targetDeal.Perk.Variant
+		}
+		targetDeal.Perk = &common.Perk{
+			Name:         cmp.Perks.Name,
+			Instructions: cmp.Perks.Instructions,
+			Category:     cmp.Perks.GetType(),
+			Code:         code,
+			SKU:          sku,
+			Variant:      variant,
+			Count:        1}
+
+		if sku == "" {
+			// Options the influencer can pick from at assignDeal
+			targetDeal.Perk.Variants = cmp.Perks.InStock()
 		}
 	}
+	targetDeal.RequiresSubmission = cmp.RequiresSubmission
+	if targetDeal.Rights == nil {
+		targetDeal.Rights = cmp.Rights.Snapshot()
+	}
 
-	return infDeals, rejections
+	if targetDeal.Pricing == nil {
+		targetDeal.Pricing = cmp.Pricing.Snapshot()
+	}
+
+	if targetDeal.Exclusivity == nil {
+		targetDeal.Exclusivity = cmp.GetExclusivity().Snapshot()
+	}
+
+	targetDeal.Languages = nil
+	if cmp.RequireLanguage {
+		targetDeal.Languages = cmp.Languages
+	}
+
+	if invite != nil {
+		invite.Apply(targetDeal)
+	}
+
+	if targetDeal.Link == "" {
+		// getDeal queries for an active deal so it already has
+		// a link set!
+		targetDeal.Link = cmp.Link
+	}
+
+	// Add some display attributes..
+	// These will be saved permanently if they accept deal!
+	targetDeal.CampaignName = cmp.Name
+	targetDeal.CampaignImage = cmp.ImageURL
+	targetDeal.Company = cmp.Company
+
+	targetDeal.TermsAndConditions = cmp.TermsAndConditions
+
+	return targetDeal, nil
 }
 
 var (
@@ -1464,8 +1808,13 @@ func (inf *Influencer) DealHeadsUp(deal *common.Deal, cfg *config.Config) error
 		firstName = parts[0]
 	}
 
-	email := templates.InfluencerHeadsUpEmail.Render(map[string]interface{}{"Name": firstName, "Company": deal.Company})
-	resp, err := cfg.ReplyMailClient().SendMessage(email, fmt.Sprintf("You have 7 days to complete the deal for %s!", deal.Company), inf.EmailAddress, inf.Name,
+	daysLeft := (deal.GetTimeout() - int32(time.Now().Unix()) + 86399) / 86400
+	if daysLeft < 1 {
+		daysLeft = 1
+	}
+
+	email := templates.InfluencerHeadsUpEmail.Render(map[string]interface{}{"Name": firstName, "Company": deal.Company, "Days": daysLeft})
+	resp, err := cfg.ReplyMailClient().SendMessage(email, fmt.Sprintf("You have %d day(s) to complete the deal for %s!", daysLeft, deal.Company), inf.EmailAddress, inf.Name,
 		[]string{""})
 	if err != nil || len(resp) != 1 || resp[0].RejectReason != "" {
 		return ErrEmail
@@ -1548,6 +1897,55 @@ func (inf *Influencer) SubmissionApproved(deal *common.Deal, cfg *config.Config)
 	return nil
 }
 
+func (inf *Influencer) SubmissionReviewed(deal *common.Deal, cfg *config.Config) error {
+	// Lets the influencer know the advertiser rejected or wants changes
+	// to their latest submission
+	if cfg.Sandbox {
+		return nil
+	}
+
+	if cfg.ReplyMailClient() == nil {
+		return ErrEmail
+	}
+
+	if deal.Submission == nil {
+		return nil
+	}
+
+	parts := strings.Split(inf.Name, " ")
+	var firstName string
+	if len(parts) > 0 {
+		firstName = parts[0]
+	}
+
+	action := "would like a few changes"
+	if deal.Submission.Status == common.SUBMISSION_REJECTED {
+		action = "has rejected it"
+	}
+
+	var comment string
+	if n := len(deal.Submission.Comments); n > 0 {
+		comment = deal.Submission.Comments[n-1].Message
+	}
+
+	email := templates.SubmissionReviewedEmail.Render(map[string]interface{}{"Name": firstName, "Company": deal.Company, "Action": action, "Comment": comment})
+	resp, err := cfg.ReplyMailClient().SendMessage(email, fmt.Sprintf("Your post submission for %s has been reviewed", deal.Company), inf.EmailAddress, inf.Name,
+		[]string{""})
+	if err != nil || len(resp) != 1 || resp[0].RejectReason != "" {
+		return ErrEmail
+	}
+
+	if err := cfg.Loggers.Log("email", map[string]interface{}{
+		"tag":  "submission reviewed",
+		"id":   inf.Id,
+		"cids": []string{deal.CampaignId},
+	}); err != nil {
+		log.Println("Failed to log submission review", inf.Id, deal.CampaignId)
+	}
+
+	return nil
+}
+
 func (inf *Influencer) DealCompletion(deal *common.Deal, cfg *config.Config) error {
 	if cfg.Sandbox {
 		return nil
@@ -1709,7 +2107,7 @@ func (inf *Influencer) DealInstructions(cmp *common.Campaign, deal *common.Deal,
 	}
 
 	requiredLink := "None required"
-	if deal.ShortenedLink != "" {
+	if deal.RequiresLink() {
 		requiredLink = deal.ShortenedLink
 	}
 
@@ -1727,7 +2125,7 @@ func (inf *Influencer) DealInstructions(cmp *common.Campaign, deal *common.Deal,
 		"CouponCode":   coupon,
 		"HasPerks":     hasPerks,
 		"HasCoupon":    hasCoupon,
-		"Timeout":      TimeoutDays,
+		"Timeout":      deal.GetTimeoutDays(),
 		"HasAddress":   hasAddress,
 		"Address":      address,
 	}
@@ -1771,7 +2169,9 @@ func (inf *Influencer) DealInstructions(cmp *common.Campaign, deal *common.Deal,
 	return nil
 }
 
-func (inf *Influencer) DealRejection(reason, postURL string, deal *common.Deal, cfg *config.Config) error {
+// DealRejection emails the influencer about what's missing from their
+// post.. details are listed under the reason if there are any
+func (inf *Influencer) DealRejection(reason, postURL string, deal *common.Deal, cfg *config.Config, details ...string) error {
 	if cfg.Sandbox || reason == "" {
 		return nil
 	}
@@ -1786,7 +2186,7 @@ func (inf *Influencer) DealRejection(reason, postURL string, deal *common.Deal,
 		firstName = parts[0]
 	}
 
-	email := templates.DealRejectionEmail.Render(map[string]interface{}{"Name": firstName, "reason": reason, "url": postURL})
+	email := templates.DealRejectionEmail.Render(map[string]interface{}{"Name": firstName, "reason": reason, "url": postURL, "details": details, "HasDetails": len(details) > 0})
 	resp, err := cfg.ReplyMailClient().SendMessage(email, fmt.Sprintf("Your post for %s is missing a required item!", deal.Company), inf.EmailAddress, inf.Name,
 		[]string{""})
 	if err != nil || len(resp) != 1 || resp[0].RejectReason != "" {
@@ -1794,11 +2194,12 @@ func (inf *Influencer) DealRejection(reason, postURL string, deal *common.Deal,
 	}
 
 	if err := cfg.Loggers.Log("email", map[string]interface{}{
-		"tag":    "deal rejection",
-		"id":     inf.Id,
-		"cids":   []string{deal.CampaignId},
-		"reason": reason,
-		"url":    postURL,
+		"tag":     "deal rejection",
+		"id":      inf.Id,
+		"cids":    []string{deal.CampaignId},
+		"reason":  reason,
+		"url":     postURL,
+		"details": details,
 	}); err != nil {
 		log.Println("Failed to log deal rejection!", inf.Id, deal.CampaignId)
 	}
@@ -1899,7 +2300,13 @@ func (inf *Influencer) PerkNotify(deal *common.Deal, cfg *config.Config) error {
 		firstName = parts[0]
 	}
 
-	email := templates.PerkMailEmail.Render(map[string]interface{}{"Name": firstName, "Company": deal.Company})
+	var carrier, tracking, trackingURL string
+	if deal.Perk != nil && deal.Perk.Shipment.IsTracked() {
+		ship := deal.Perk.Shipment
+		carrier, tracking, trackingURL = strings.ToUpper(ship.Carrier), ship.TrackingNumber, ship.TrackingURL
+	}
+
+	email := templates.PerkMailEmail.Render(map[string]interface{}{"Name": firstName, "Company": deal.Company, "Carrier": carrier, "Tracking": tracking, "TrackingURL": trackingURL})
 	resp, err := cfg.ReplyMailClient().SendMessage(email, fmt.Sprintf("Your perk has been mailed!"), inf.EmailAddress, inf.Name,
 		[]string{""})
 	if err != nil || len(resp) != 1 || resp[0].RejectReason != "" {
@@ -1916,6 +2323,122 @@ func (inf *Influencer) PerkNotify(deal *common.Deal, cfg *config.Config) error {
 	return nil
 }
 
+func (inf *Influencer) DealInvite(cmp *common.Campaign, invite *common.DealInvite, cfg *config.Config) error {
+	if cfg.Sandbox {
+		return nil
+	}
+
+	if cfg.ReplyMailClient() == nil {
+		return ErrEmail
+	}
+
+	parts := strings.Split(inf.Name, " ")
+	var firstName string
+	if len(parts) > 0 {
+		firstName = parts[0]
+	}
+
+	email := templates.DealInviteEmail.Render(map[string]interface{}{
+		"Name":         firstName,
+		"Company":      cmp.Company,
+		"Campaign":     cmp.Name,
+		"Payout":       misc.TruncateFloat(invite.Payout, 2),
+		"Deadline":     time.Unix(invite.Deadline, 0).Format("January 2, 2006"),
+		"Instructions": invite.Instructions,
+	})
+	resp, err := cfg.ReplyMailClient().SendMessage(email, fmt.Sprintf("%s has invited you to a private deal!", cmp.Company), inf.EmailAddress, inf.Name,
+		[]string{""})
+	if err != nil || len(resp) != 1 || resp[0].RejectReason != "" {
+		return ErrEmail
+	}
+
+	if err := cfg.Loggers.Log("email", map[string]interface{}{
+		"tag":  "deal invite",
+		"id":   inf.Id,
+		"cids": []string{cmp.Id},
+	}); err != nil {
+		log.Println("Failed to log deal invite email!", inf.Id, cmp.Id)
+	}
+
+	return nil
+}
+
+func (inf *Influencer) MessageNotify(cmpName, from string, count int, cfg *config.Config) error {
+	// Lets the influencer know there are unread messages on their deal
+	if cfg.Sandbox {
+		return nil
+	}
+
+	if cfg.ReplyMailClient() == nil {
+		return ErrEmail
+	}
+
+	parts := strings.Split(inf.Name, " ")
+	var firstName string
+	if len(parts) > 0 {
+		firstName = parts[0]
+	}
+
+	email := templates.NotifyUnreadMessagesEmail.Render(map[string]interface{}{"Name": firstName, "Count": count, "From": from, "CampaignName": cmpName, "URL": "https://inf.swayops.com/login"})
+	resp, err := cfg.ReplyMailClient().SendMessage(email, fmt.Sprintf("You have unread messages from %s", from), inf.EmailAddress, inf.Name,
+		[]string{""})
+	if err != nil || len(resp) != 1 || resp[0].RejectReason != "" {
+		return ErrEmail
+	}
+
+	if err := cfg.Loggers.Log("email", map[string]interface{}{
+		"tag": "unread messages",
+		"id":  inf.Id,
+	}); err != nil {
+		log.Println("Failed to log unread messages email!", inf.Id)
+	}
+
+	return nil
+}
+
+func (inf *Influencer) ExtensionUpdate(deal *common.Deal, cfg *config.Config) error {
+	// Lets the influencer know whether their extension was approved
+	if cfg.Sandbox {
+		return nil
+	}
+
+	if cfg.ReplyMailClient() == nil {
+		return ErrEmail
+	}
+
+	if deal.Extension == nil {
+		return nil
+	}
+
+	parts := strings.Split(inf.Name, " ")
+	var firstName string
+	if len(parts) > 0 {
+		firstName = parts[0]
+	}
+
+	email := templates.ExtensionEmail.Render(map[string]interface{}{
+		"Name":     firstName,
+		"Company":  deal.Company,
+		"Approved": deal.Extension.Approved > 0,
+		"Deadline": time.Unix(int64(deal.GetTimeout()), 0).Format("January 2, 2006"),
+	})
+	resp, err := cfg.ReplyMailClient().SendMessage(email, fmt.Sprintf("Your extension request for %s has been reviewed", deal.Company), inf.EmailAddress, inf.Name,
+		[]string{""})
+	if err != nil || len(resp) != 1 || resp[0].RejectReason != "" {
+		return ErrEmail
+	}
+
+	if err := cfg.Loggers.Log("email", map[string]interface{}{
+		"tag":  "extension update",
+		"id":   inf.Id,
+		"cids": []string{deal.CampaignId},
+	}); err != nil {
+		log.Println("Failed to log extension update!", inf.Id, deal.CampaignId)
+	}
+
+	return nil
+}
+
 func (inf *Influencer) Audited() bool {
 	return len(inf.Categories) > 0 && (inf.Male || inf.Female) && inf.BrandSafe != ""
 }
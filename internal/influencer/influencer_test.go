@@ -0,0 +1,75 @@
+package influencer
+
+import (
+	"testing"
+
+	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/platforms/instagram"
+	"github.com/swayops/sway/platforms/twitter"
+)
+
+func TestIsCategoryMatch(t *testing.T) {
+	inf := &Influencer{
+		Id:         "1",
+		Categories: []string{"food"},
+		Keywords:   []string{"vegan food"},
+		Instagram:  &instagram.Instagram{UserName: "JaneRunsFast", Bio: "Marathon runner & vegan baker. Business: me@x.com"},
+		Twitter:    &twitter.Twitter{Id: "janebakes"},
+	}
+
+	tests := []struct {
+		name string
+		cmp  *common.Campaign
+		ex   bool
+	}{
+		{"no targeting", &common.Campaign{}, true},
+		{"category", &common.Campaign{Categories: []string{"food"}}, true},
+		{"other category", &common.Campaign{Categories: []string{"fashion"}}, false},
+		{"keyword", &common.Campaign{Keywords: []string{"Vegan Food"}}, true},
+		{"keyword word", &common.Campaign{Keywords: []string{"vegan"}}, true},
+		// Bios match whole phrases in order
+		{"bio phrase", &common.Campaign{Keywords: []string{"marathon runner"}}, true},
+		{"bio out of order", &common.Campaign{Keywords: []string{"runner marathon"}}, false},
+		{"bio partial word", &common.Campaign{Keywords: []string{"marath"}}, false},
+		{"bio stopword", &common.Campaign{Keywords: []string{"business"}}, true},
+		// Handles match any part of the name
+		{"handle substring", &common.Campaign{Keywords: []string{"runs"}}, true},
+		{"handle case", &common.Campaign{Keywords: []string{"RUNSFAST"}}, true},
+		{"other handle", &common.Campaign{Keywords: []string{"bakes"}}, true},
+		{"no match", &common.Campaign{Keywords: []string{"surfing"}}, false},
+	}
+
+	for _, ts := range tests {
+		if v := inf.IsCategoryMatch(ts.cmp, nil); v != ts.ex {
+			t.Errorf("%s: wanted %v, got %v", ts.name, ts.ex, v)
+		}
+	}
+}
+
+func TestScrapIsSearchInUsername(t *testing.T) {
+	sc := &Scrap{
+		InstaData: &instagram.Instagram{UserName: "JaneRunsFast"},
+		TWData:    &twitter.Twitter{Id: "janebakes"},
+	}
+
+	tests := []struct {
+		kw string
+		ex bool
+	}{
+		{"runs", true},
+		{"JANE", true},
+		{"bakes", true},
+		{"janeruns fast", false},
+		{"surfing", false},
+	}
+
+	for _, ts := range tests {
+		if v := sc.IsSearchInUsername(ts.kw); v != ts.ex {
+			t.Errorf("%q: wanted %v, got %v", ts.kw, ts.ex, v)
+		}
+	}
+
+	if (&Scrap{}).IsSearchInUsername("jane") {
+		t.Fatal("expected no match without any profiles")
+	}
+}
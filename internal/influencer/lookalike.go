@@ -0,0 +1,266 @@
+package influencer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/internal/geo"
+)
+
+// Weights for each feature when scoring similarity.. they add up to 1
+var lookalikeWeights = struct {
+	Categories, Keywords, Bio, Followers, Engagement, Geo, Platforms float64
+}{0.25, 0.2, 0.1, 0.15, 0.1, 0.1, 0.1}
+
+// Common bio words that say nothing about the creator
+var bioStopwords = map[string]bool{
+	"with": true, "from": true, "that": true, "this": true, "your": true,
+	"about": true, "just": true, "have": true, "more": true, "here": true,
+	"what": true, "when": true, "love": true, "life": true, "email": true,
+	"business": true, "inquiries": true, "contact": true, "follow": true,
+}
+
+// Profile is the feature vector used to find lookalikes
+type Profile struct {
+	Id   string
+	Type string // "influencer" or "scrap"
+
+	Name      string
+	Email     string
+	Followers int64
+
+	Categories []string
+	Keywords   []string
+	Bio        []string
+	Band       string // Follower band
+	BandIndex  int
+	EngRate    float64 // Avg engagements / followers
+	Country    string
+	State      string
+	Platforms  []string
+}
+
+func getEngRate(engs, followers int64) float64 {
+	if followers == 0 {
+		return 0
+	}
+	return float64(engs) / float64(followers)
+}
+
+func getBioTerms(bio string) []string {
+	var terms []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(bio), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if len(w) > 3 && !bioStopwords[w] && !common.IsInList(terms, w) {
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
+
+func setGeo(p *Profile, g *geo.GeoRecord) {
+	if g != nil {
+		p.Country = strings.ToLower(g.Country)
+		p.State = strings.ToLower(g.State)
+	}
+}
+
+func (inf *Influencer) GetProfile() *Profile {
+	p := &Profile{
+		Id:         inf.Id,
+		Type:       "influencer",
+		Name:       inf.Name,
+		Email:      inf.EmailAddress,
+		Followers:  inf.GetFollowers(),
+		Categories: inf.Categories,
+		Keywords:   inf.Keywords,
+		Bio:        getBioTerms(inf.GetDescription()),
+		Platforms:  inf.getPlatforms(),
+	}
+
+	p.Band, p.BandIndex = common.GetFollowerBand(p.Followers), common.GetFollowerBandIndex(p.Followers)
+	p.EngRate = getEngRate(inf.GetAvgEngs(), p.Followers)
+	setGeo(p, inf.GetLatestGeo())
+
+	return p
+}
+
+func (sc *Scrap) GetProfile() *Profile {
+	p := &Profile{
+		Id:         sc.Id,
+		Type:       "scrap",
+		Name:       sc.Name,
+		Email:      sc.EmailAddress,
+		Followers:  sc.GetFollowers(),
+		Categories: sc.Categories,
+		Keywords:   sc.Keywords,
+		Bio:        getBioTerms(sc.GetDescription()),
+		Platforms:  sc.getPlatforms(),
+	}
+
+	p.Band, p.BandIndex = common.GetFollowerBand(p.Followers), common.GetFollowerBandIndex(p.Followers)
+	p.EngRate = getEngRate(sc.GetAvgEngs(), p.Followers)
+	setGeo(p, sc.Geo)
+
+	return p
+}
+
+// overlap returns the jaccard index of the two lists and the shared values
+func overlap(a, b []string) (float64, []string) {
+	if len(a) == 0 || len(b) == 0 {
+		return 0, nil
+	}
+
+	var shared []string
+	for _, v := range a {
+		if common.IsInList(b, v) && !common.IsInList(shared, v) {
+			shared = append(shared, v)
+		}
+	}
+
+	union := len(a) + len(b) - len(shared)
+	return float64(len(shared)) / float64(union), shared
+}
+
+func topTerms(terms []string, max int) string {
+	if len(terms) > max {
+		terms = terms[:max]
+	}
+	return strings.Join(terms, ", ")
+}
+
+// Similarity returns how alike two profiles are (0 to 1) along with
+// human readable reasons
+func (p *Profile) Similarity(o *Profile) (float64, []string) {
+	var (
+		w       = lookalikeWeights
+		score   float64
+		reasons []string
+	)
+
+	if sim, shared := overlap(p.Categories, o.Categories); sim > 0 {
+		score += w.Categories * sim
+		reasons = append(reasons, "Shares categories: "+topTerms(shared, 5))
+	}
+
+	if sim, shared := overlap(p.Keywords, o.Keywords); sim > 0 {
+		score += w.Keywords * sim
+		reasons = append(reasons, "Similar content: "+topTerms(shared, 5))
+	}
+
+	if sim, shared := overlap(p.Bio, o.Bio); sim > 0 {
+		score += w.Bio * sim
+		reasons = append(reasons, "Bio mentions: "+topTerms(shared, 5))
+	}
+
+	switch math.Abs(float64(p.BandIndex - o.BandIndex)) {
+	case 0:
+		score += w.Followers
+		reasons = append(reasons, "Same follower range ("+p.Band+")")
+	case 1:
+		score += w.Followers / 2
+	}
+
+	if p.EngRate > 0 && o.EngRate > 0 {
+		// Ratio of the lower rate to the higher one
+		sim := math.Min(p.EngRate, o.EngRate) / math.Max(p.EngRate, o.EngRate)
+		score += w.Engagement * sim
+		if sim >= 0.75 {
+			reasons = append(reasons, fmt.Sprintf("Similar engagement rate (%0.1f%%)", o.EngRate*100))
+		}
+	}
+
+	if p.Country != "" && p.Country == o.Country {
+		if p.State != "" && p.State == o.State {
+			score += w.Geo
+			reasons = append(reasons, "Same location ("+strings.ToUpper(o.State+", "+o.Country)+")")
+		} else {
+			score += w.Geo / 2
+			reasons = append(reasons, "Same country ("+strings.ToUpper(o.Country)+")")
+		}
+	}
+
+	if sim, shared := overlap(p.Platforms, o.Platforms); sim > 0 {
+		score += w.Platforms * sim
+		reasons = append(reasons, "Also on "+topTerms(shared, 4))
+	}
+
+	return score, reasons
+}
+
+type Lookalike struct {
+	Id        string   `json:"id"`
+	Type      string   `json:"type"` // "influencer" or "scrap"
+	Name      string   `json:"name"`
+	Email     string   `json:"email,omitempty"`
+	Followers int64    `json:"followers"`
+	Score     float64  `json:"score"`             // Avg similarity to the seeds
+	SeedId    string   `json:"seedId"`            // The seed they're most like
+	Reasons   []string `json:"reasons,omitempty"` // Why they're like SeedId
+}
+
+type lookalikes []*Lookalike
+
+func (l lookalikes) Len() int           { return len(l) }
+func (l lookalikes) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+func (l lookalikes) Less(i, j int) bool { return l[i].Score > l[j].Score }
+
+// GetLookalikes scores every candidate against the seeds and returns
+// the best matches first
+func GetLookalikes(seeds, candidates []*Profile, minScore float64, max int) []*Lookalike {
+	if len(seeds) == 0 {
+		return nil
+	}
+
+	var out lookalikes
+	for _, cand := range candidates {
+		isSeed := false
+		for _, seed := range seeds {
+			if seed.Id == cand.Id && seed.Type == cand.Type {
+				isSeed = true
+				break
+			}
+		}
+
+		if isSeed {
+			continue
+		}
+
+		var (
+			total float64
+			best  = -1.0
+			match = &Lookalike{
+				Id:        cand.Id,
+				Type:      cand.Type,
+				Name:      cand.Name,
+				Email:     cand.Email,
+				Followers: cand.Followers,
+			}
+		)
+
+		for _, seed := range seeds {
+			score, reasons := seed.Similarity(cand)
+			total += score
+			if score > best {
+				best = score
+				match.SeedId, match.Reasons = seed.Id, reasons
+			}
+		}
+
+		if match.Score = total / float64(len(seeds)); match.Score >= minScore {
+			out = append(out, match)
+		}
+	}
+
+	sort.Stable(out)
+	if max > 0 && len(out) > max {
+		out = out[:max]
+	}
+
+	return out
+}
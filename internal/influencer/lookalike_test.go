@@ -0,0 +1,150 @@
+package influencer
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestGetBioTerms(t *testing.T) {
+	tests := []struct {
+		bio string
+		ex  []string
+	}{
+		{"", nil},
+		{"Vegan chef from LA. Business inquiries: me@x.com", []string{"vegan", "chef"}},
+		{"Travel, travel, TRAVEL and surfing!", []string{"travel", "surfing"}},
+	}
+
+	for _, ts := range tests {
+		if v := getBioTerms(ts.bio); !reflect.DeepEqual(v, ts.ex) {
+			t.Errorf("%q: wanted %v, got %v", ts.bio, ts.ex, v)
+		}
+	}
+}
+
+func TestOverlap(t *testing.T) {
+	tests := []struct {
+		a, b   []string
+		sim    float64
+		shared []string
+	}{
+		{nil, []string{"a"}, 0, nil},
+		{[]string{"a", "b"}, []string{"c"}, 0, nil},
+		{[]string{"a", "b"}, []string{"a", "b"}, 1, []string{"a", "b"}},
+		{[]string{"a", "b", "c"}, []string{"b", "c", "d"}, 0.5, []string{"b", "c"}},
+	}
+
+	for _, ts := range tests {
+		sim, shared := overlap(ts.a, ts.b)
+		if sim != ts.sim || !reflect.DeepEqual(shared, ts.shared) {
+			t.Errorf("%v/%v: wanted %v %v, got %v %v", ts.a, ts.b, ts.sim, ts.shared, sim, shared)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	var (
+		seed = &Profile{
+			Id:         "1",
+			Categories: []string{"food", "travel"},
+			Keywords:   []string{"vegan", "recipes"},
+			Bio:        []string{"chef"},
+			Band:       "10k-50k",
+			BandIndex:  1,
+			EngRate:    0.04,
+			Country:    "us",
+			State:      "ca",
+			Platforms:  []string{"instagram"},
+		}
+
+		twin = &Profile{
+			Id:         "2",
+			Categories: seed.Categories,
+			Keywords:   seed.Keywords,
+			Bio:        seed.Bio,
+			Band:       seed.Band,
+			BandIndex:  seed.BandIndex,
+			EngRate:    seed.EngRate,
+			Country:    seed.Country,
+			State:      seed.State,
+			Platforms:  seed.Platforms,
+		}
+
+		stranger = &Profile{
+			Id:         "3",
+			Categories: []string{"gaming"},
+			BandIndex:  4,
+			Country:    "de",
+			Platforms:  []string{"youtube"},
+		}
+
+		// Neighbouring band, same country but different state
+		cousin = &Profile{
+			Id:         "4",
+			Categories: []string{"food"},
+			BandIndex:  2,
+			EngRate:    0.02,
+			Country:    "us",
+			State:      "ny",
+		}
+	)
+
+	if score, reasons := seed.Similarity(twin); math.Abs(score-1) > 1e-9 || len(reasons) != 7 {
+		t.Errorf("expected a perfect match, got %v %v", score, reasons)
+	}
+
+	if score, reasons := seed.Similarity(stranger); score != 0 || len(reasons) != 0 {
+		t.Errorf("expected no match, got %v %v", score, reasons)
+	}
+
+	w := lookalikeWeights
+	ex := w.Categories*0.5 + w.Followers/2 + w.Engagement*0.5 + w.Geo/2
+	score, reasons := seed.Similarity(cousin)
+	if math.Abs(score-ex) > 1e-9 {
+		t.Errorf("wanted %v, got %v", ex, score)
+	}
+
+	if !reflect.DeepEqual(reasons, []string{"Shares categories: food", "Same country (US)"}) {
+		t.Errorf("unexpected reasons: %v", reasons)
+	}
+}
+
+func TestGetLookalikes(t *testing.T) {
+	var (
+		food   = &Profile{Id: "1", Type: "influencer", Categories: []string{"food"}, BandIndex: 1, Platforms: []string{"instagram"}}
+		travel = &Profile{Id: "2", Type: "influencer", Categories: []string{"travel"}, BandIndex: 1, Platforms: []string{"instagram"}}
+
+		candidates = []*Profile{
+			food,
+			{Id: "1", Type: "scrap", Categories: []string{"food"}, BandIndex: 1},
+			{Id: "3", Type: "influencer", Categories: []string{"food", "travel"}, BandIndex: 1, Platforms: []string{"instagram"}},
+			{Id: "4", Type: "influencer", Categories: []string{"travel"}, BandIndex: 3},
+			{Id: "5", Type: "influencer", Categories: []string{"gaming"}, BandIndex: 4},
+		}
+	)
+
+	if out := GetLookalikes(nil, candidates, 0, 0); out != nil {
+		t.Fatalf("expected no lookalikes without seeds, got %+v", out)
+	}
+
+	out := GetLookalikes([]*Profile{food, travel}, candidates, 0.01, 0)
+
+	var ids []string
+	for _, l := range out {
+		ids = append(ids, l.Type+":"+l.Id)
+	}
+
+	// Seeds are skipped but a scrap with a seed's id isn't
+	if ex := []string{"influencer:3", "scrap:1", "influencer:4"}; !reflect.DeepEqual(ids, ex) {
+		t.Fatalf("wanted %v, got %v", ex, ids)
+	}
+
+	if out[2].SeedId != "2" {
+		t.Errorf("expected travel seed to be the closest, got %s", out[2].SeedId)
+	}
+
+	if out := GetLookalikes([]*Profile{food, travel}, candidates, 0.01, 1); len(out) != 1 || out[0].Id != "3" {
+		t.Errorf("expected only the best lookalike, got %+v", out)
+	}
+}
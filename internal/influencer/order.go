@@ -13,5 +13,9 @@ func (od OrderedDeals) Swap(i, j int) {
 }
 
 func (od OrderedDeals) Less(i, j int) bool {
+	// Highest bids first
+	if bi, bj := od[i].GetBid(), od[j].GetBid(); bi != bj {
+		return bi > bj
+	}
 	return od[i].Spendable > od[j].Spendable
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/swayops/sway/internal/common"
 	"github.com/swayops/sway/internal/geo"
 	"github.com/swayops/sway/internal/subscriptions"
+	"github.com/swayops/sway/internal/targeting"
 	"github.com/swayops/sway/internal/templates"
 	"github.com/swayops/sway/misc"
 	"github.com/swayops/sway/platforms/facebook"
@@ -263,6 +264,11 @@ func (sc *Scrap) Match(cmp common.Campaign, audiences *common.Audiences, db *bol
 		return false
 	}
 
+	// Scraps don't have audience demographics or languages
+	if len(cmp.AudienceTargets) > 0 || len(cmp.Languages) > 0 {
+		return false
+	}
+
 	// Gender check
 	if !cmp.Male && cmp.Female && !sc.Female {
 		// Only want females
@@ -345,6 +351,10 @@ func (sc *Scrap) Match(cmp common.Campaign, audiences *common.Audiences, db *bol
 		}
 	}
 
+	if cmp.Targeting != "" && !targeting.Match(cmp.Targeting, sc.GetSubject(audiences)) {
+		return false
+	}
+
 	if !forecast {
 		// Check if there's an available deal
 		var dealFound bool
@@ -391,7 +401,7 @@ func (sc *Scrap) Match(cmp common.Campaign, audiences *common.Audiences, db *bol
 				return false
 			}
 
-			min, max := cmp.GetTargetYield(budgetStore.Spendable)
+			min, max := budgetStore.GetPace(&cmp, false).Loosen(cmp.GetTargetYield(budgetStore.Spendable))
 			if maxYield < min || maxYield > max || maxYield == 0 {
 				return false
 			}
@@ -0,0 +1,102 @@
+package influencer
+
+import (
+	"strings"
+
+	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/internal/geo"
+	"github.com/swayops/sway/internal/search"
+	"github.com/swayops/sway/platforms/facebook"
+	"github.com/swayops/sway/platforms/instagram"
+	"github.com/swayops/sway/platforms/twitter"
+	"github.com/swayops/sway/platforms/youtube"
+)
+
+// getCaptions returns the text of the latest posts across all platforms
+func getCaptions(fb *facebook.Facebook, insta *instagram.Instagram, tw *twitter.Twitter, yt *youtube.YouTube) []string {
+	var texts []string
+	if insta != nil {
+		for _, post := range insta.LatestPosts {
+			texts = append(texts, post.Caption)
+		}
+	}
+
+	if tw != nil {
+		for _, t := range tw.LatestTweets {
+			texts = append(texts, t.Text)
+		}
+	}
+
+	if fb != nil {
+		for _, post := range fb.LatestPosts {
+			texts = append(texts, post.Caption)
+		}
+	}
+
+	if yt != nil {
+		for _, post := range yt.LatestPosts {
+			texts = append(texts, post.Title, post.Description)
+		}
+	}
+
+	return texts
+}
+
+func newSearchDoc(id, typ, name string, followers int64, g *geo.GeoRecord, categories []string) *search.Document {
+	doc := &search.Document{
+		Id:        id,
+		Type:      typ,
+		Name:      name,
+		Followers: followers,
+		Fields: map[string][]string{
+			search.FIELD_CATEGORIES: categories,
+		},
+		Facets: map[string][]string{
+			search.FACET_BAND:     {common.GetFollowerBand(followers)},
+			search.FACET_CATEGORY: categories,
+		},
+	}
+
+	if g != nil && g.State != "" {
+		doc.Facets[search.FACET_STATE] = []string{strings.ToUpper(g.State)}
+	}
+
+	return doc
+}
+
+// GetSearchDoc returns the influencer as a document for the search index
+func (inf *Influencer) GetSearchDoc() *search.Document {
+	doc := newSearchDoc(inf.Id, "influencer", inf.Name, inf.GetFollowers(), inf.GetLatestGeo(), inf.Categories)
+
+	names := []string{inf.Name}
+	if inf.Instagram != nil {
+		names = append(names, inf.Instagram.FullName)
+	}
+	if inf.Twitter != nil {
+		names = append(names, inf.Twitter.FullName)
+	}
+
+	doc.Fields[search.FIELD_NAME] = names
+	doc.Fields[search.FIELD_HANDLE] = inf.getHandles()
+	doc.Fields[search.FIELD_BIO] = []string{inf.GetDescription()}
+	doc.Fields[search.FIELD_KEYWORDS] = inf.Keywords
+	doc.Fields[search.FIELD_CAPTIONS] = getCaptions(inf.Facebook, inf.Instagram, inf.Twitter, inf.YouTube)
+	doc.Facets[search.FACET_PLATFORM] = inf.getPlatforms()
+
+	return doc
+}
+
+// GetSearchDoc returns the scrap as a document for the search index.
+// Scrap IDs are prefixed with "sc-" so they don't clash with influencers
+func (sc *Scrap) GetSearchDoc() *search.Document {
+	doc := newSearchDoc("sc-"+sc.Id, "scrap", sc.Name, sc.GetFollowers(), sc.Geo, sc.Categories)
+
+	doc.Fields[search.FIELD_NAME] = []string{sc.FullName}
+	doc.Fields[search.FIELD_HANDLE] = append([]string{sc.Name}, sc.getHandles()...)
+	doc.Fields[search.FIELD_BIO] = []string{sc.GetDescription()}
+	doc.Fields[search.FIELD_KEYWORDS] = sc.Keywords
+	doc.Fields[search.FIELD_CAPTIONS] = getCaptions(sc.FBData, sc.InstaData, sc.TWData, sc.YTData)
+	doc.Facets[search.FACET_PLATFORM] = sc.getPlatforms()
+
+	return doc
+}
@@ -0,0 +1,122 @@
+package influencer
+
+import (
+	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/internal/geo"
+	"github.com/swayops/sway/internal/targeting"
+	"github.com/swayops/sway/platforms"
+)
+
+func (inf *Influencer) getPlatforms() []string {
+	var networks []string
+	if inf.Facebook != nil {
+		networks = append(networks, platform.Facebook)
+	}
+	if inf.Instagram != nil {
+		networks = append(networks, platform.Instagram)
+	}
+	if inf.Twitter != nil {
+		networks = append(networks, platform.Twitter)
+	}
+	if inf.YouTube != nil {
+		networks = append(networks, platform.YouTube)
+	}
+	return networks
+}
+
+func (inf *Influencer) getHandles() []string {
+	var handles []string
+	if inf.Facebook != nil {
+		handles = append(handles, inf.Facebook.Id)
+	}
+	if inf.Instagram != nil {
+		handles = append(handles, inf.Instagram.UserName)
+	}
+	if inf.Twitter != nil {
+		handles = append(handles, inf.Twitter.Id)
+	}
+	if inf.YouTube != nil {
+		handles = append(handles, inf.YouTube.UserName)
+	}
+	return handles
+}
+
+// GetSubject returns what campaign targeting expressions are evaluated
+// against. Location is optional and defaults to the latest geo
+func (inf *Influencer) GetSubject(audiences *common.Audiences, location *geo.GeoRecord) *targeting.Subject {
+	if location == nil {
+		location = inf.GetLatestGeo()
+	}
+
+	sub := &targeting.Subject{
+		Categories: inf.Categories,
+		Keywords:   inf.Keywords,
+		Handles:    inf.getHandles(),
+		Platforms:  inf.getPlatforms(),
+		Followers:  inf.GetFollowers(),
+		Geo:        location,
+		InAudience: func(id string) bool {
+			return audiences.IsAllowed(id, inf.EmailAddress)
+		},
+	}
+
+	if inf.Instagram != nil {
+		sub.Bio = inf.Instagram.Bio
+	}
+
+	return sub
+}
+
+func (sc *Scrap) getPlatforms() []string {
+	var networks []string
+	if sc.Facebook {
+		networks = append(networks, platform.Facebook)
+	}
+	if sc.Instagram {
+		networks = append(networks, platform.Instagram)
+	}
+	if sc.Twitter {
+		networks = append(networks, platform.Twitter)
+	}
+	if sc.YouTube {
+		networks = append(networks, platform.YouTube)
+	}
+	return networks
+}
+
+func (sc *Scrap) getHandles() []string {
+	var handles []string
+	if sc.FBData != nil {
+		handles = append(handles, sc.FBData.Id)
+	}
+	if sc.InstaData != nil {
+		handles = append(handles, sc.InstaData.UserName)
+	}
+	if sc.TWData != nil {
+		handles = append(handles, sc.TWData.Id)
+	}
+	if sc.YTData != nil {
+		handles = append(handles, sc.YTData.UserName)
+	}
+	return handles
+}
+
+func (sc *Scrap) GetSubject(audiences *common.Audiences) *targeting.Subject {
+	sub := &targeting.Subject{
+		Categories: sc.Categories,
+		Keywords:   sc.Keywords,
+		Handles:    sc.getHandles(),
+		Platforms:  sc.getPlatforms(),
+		Followers:  sc.GetFollowers(),
+		Geo:        sc.Geo,
+		InAudience: func(id string) bool {
+			return audiences.IsAllowed(id, sc.EmailAddress)
+		},
+	}
+
+	if sc.InstaData != nil {
+		sub.Bio = sc.InstaData.Bio
+	}
+
+	return sub
+}
@@ -0,0 +1,149 @@
+package language
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// Fewest stopword hits needed before we guess at a language
+	MIN_HITS = 3
+	// Languages under this share of hits are ignored
+	MIN_CONFIDENCE = 0.2
+	// Primary and secondary
+	MAX_LANGUAGES = 2
+)
+
+// Language is a detected language with the share of stopword hits
+// that belonged to it
+type Language struct {
+	Code       string  `json:"code"` // ISO 639-1
+	Confidence float64 `json:"confidence"`
+}
+
+// Most common words per language.. words shared by several languages
+// ("a", "de", "la") still count for each of them
+var stopwords = map[string][]string{
+	"en": {"the", "and", "you", "that", "was", "for", "are", "with", "his", "they", "this", "have", "from", "one", "had", "but", "what", "all", "were", "when", "your", "can", "there", "out", "about", "just", "my", "is", "it", "so", "love", "today", "new", "get", "our", "how", "me", "check", "now", "of"},
+	"es": {"el", "la", "de", "que", "y", "en", "los", "del", "se", "las", "por", "un", "para", "con", "una", "su", "al", "lo", "como", "más", "pero", "sus", "le", "ya", "muy", "hoy", "gracias", "mi", "es", "este", "esta", "todo", "nuevo", "nueva", "qué", "también", "hola", "amor", "día", "cuando"},
+	"fr": {"le", "la", "de", "et", "les", "des", "est", "un", "une", "du", "en", "que", "qui", "dans", "pour", "pas", "sur", "au", "avec", "ce", "il", "je", "vous", "nous", "mon", "ma", "mes", "très", "aujourd'hui", "merci", "c'est", "bonjour", "tout", "plus", "mais", "ou", "cette", "été", "aussi", "être"},
+	"pt": {"de", "que", "e", "do", "da", "em", "um", "para", "com", "não", "uma", "os", "no", "se", "na", "por", "mais", "as", "dos", "como", "mas", "ao", "das", "meu", "minha", "muito", "hoje", "obrigado", "obrigada", "você", "é", "também", "isso", "esse", "essa", "novo", "nova", "bom", "dia", "tudo"},
+	"de": {"der", "die", "und", "in", "den", "von", "zu", "das", "mit", "sich", "des", "auf", "für", "ist", "im", "dem", "nicht", "ein", "eine", "als", "auch", "es", "an", "werden", "aus", "er", "hat", "dass", "sie", "nach", "bei", "ich", "mein", "heute", "danke", "wir", "oder", "neue", "sehr"},
+	"it": {"di", "che", "il", "la", "e", "per", "un", "in", "una", "sono", "mi", "non", "con", "si", "da", "del", "della", "le", "lo", "ma", "gli", "ho", "anche", "questo", "questa", "oggi", "grazie", "mio", "mia", "tutto", "nuovo", "nuova", "molto", "sempre", "come", "bella", "ciao", "perché", "cosa", "dei"},
+}
+
+var lookup map[string][]string
+
+func init() {
+	// word -> languages it belongs to
+	lookup = make(map[string][]string)
+	for code, words := range stopwords {
+		seen := make(map[string]bool)
+		for _, w := range words {
+			if seen[w] {
+				continue
+			}
+			seen[w] = true
+			lookup[w] = append(lookup[w], code)
+		}
+	}
+}
+
+func IsSupported(code string) bool {
+	_, ok := stopwords[code]
+	return ok
+}
+
+func tokenize(text string) []string {
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\'' && r != '#' && r != '@' && r != '/' && r != '.' && r != ':'
+	}) {
+		// Skip hashtags, mentions and links
+		if strings.HasPrefix(w, "#") || strings.HasPrefix(w, "@") || strings.Contains(w, "/") {
+			continue
+		}
+
+		w = strings.Trim(w, "'.:")
+		if w != "" {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// Detect returns up to MAX_LANGUAGES languages for the given texts,
+// most likely first. Returns nil if there isn't enough text to tell
+func Detect(texts ...string) []*Language {
+	var (
+		hits  = make(map[string]float64)
+		total float64
+	)
+
+	for _, text := range texts {
+		for _, w := range tokenize(text) {
+			langs := lookup[w]
+			for _, code := range langs {
+				// Words shared by several languages count for less
+				hits[code] += 1 / float64(len(langs))
+			}
+
+			if len(langs) > 0 {
+				total += 1
+			}
+		}
+	}
+
+	if total < MIN_HITS {
+		return nil
+	}
+
+	var out []*Language
+	for code, h := range hits {
+		if conf := h / total; conf >= MIN_CONFIDENCE {
+			out = append(out, &Language{Code: code, Confidence: conf})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Confidence != out[j].Confidence {
+			return out[i].Confidence > out[j].Confidence
+		}
+		return out[i].Code < out[j].Code
+	})
+
+	if len(out) > MAX_LANGUAGES {
+		out = out[:MAX_LANGUAGES]
+	}
+
+	return out
+}
+
+// IsAny returns whether the text's primary language is one of the codes.
+// Text that's too short to tell (i.e. just hashtags) passes
+func IsAny(text string, codes []string) bool {
+	langs := Detect(text)
+	if len(langs) == 0 {
+		return true
+	}
+
+	for _, code := range codes {
+		if langs[0].Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Speaks returns whether any of the detected languages are one of the codes
+func Speaks(langs []*Language, codes []string) bool {
+	for _, l := range langs {
+		for _, code := range codes {
+			if l.Code == code {
+				return true
+			}
+		}
+	}
+	return false
+}
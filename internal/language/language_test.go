@@ -0,0 +1,114 @@
+package language
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		ex   []string
+	}{
+		{"", nil},
+		{"Hello, World!", []string{"hello", "world"}},
+		{"Love this #summer look @brand https://sway.com/x", []string{"love", "this", "look"}},
+		{"C'est très bien.", []string{"c'est", "très", "bien"}},
+		{"'quoted' 123 words...", []string{"quoted", "words"}},
+	}
+
+	for _, ts := range tests {
+		if v := tokenize(ts.text); !reflect.DeepEqual(v, ts.ex) {
+			t.Errorf("%q: wanted %v, got %v", ts.text, ts.ex, v)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		texts []string
+		ex    []string
+	}{
+		{nil, nil},
+		// Not enough stopwords to tell
+		{[]string{"#ootd #style @brand"}, nil},
+		{[]string{"Check out the new look, I just love it and you will too"}, []string{"en"}},
+		{[]string{"Hoy es un día muy bonito, gracias a todos por el amor"}, []string{"es"}},
+		{[]string{"Aujourd'hui je suis avec mes amis, merci pour tout"}, []string{"fr"}},
+		{[]string{"Heute ist ein sehr schöner Tag und ich bin mit meinen Freunden"}, []string{"de"}},
+		// Spread across posts
+		{[]string{"The best", "day for", "the beach"}, []string{"en"}},
+		// Bilingual captions keep both
+		{[]string{"Love this new look, check it out now", "Me encanta este nuevo look, gracias por todo"}, []string{"en", "es"}},
+	}
+
+	for _, ts := range tests {
+		var codes []string
+		for _, l := range Detect(ts.texts...) {
+			codes = append(codes, l.Code)
+		}
+
+		if !reflect.DeepEqual(codes, ts.ex) {
+			t.Errorf("%q: wanted %v, got %v", ts.texts, ts.ex, codes)
+		}
+	}
+}
+
+func TestDetectConfidence(t *testing.T) {
+	langs := Detect("The best day for the beach with my friends and family")
+	if len(langs) == 0 || langs[0].Code != "en" || langs[0].Confidence != 1 {
+		t.Fatalf("unexpected languages: %+v", langs)
+	}
+
+	if len(langs) > MAX_LANGUAGES {
+		t.Fatalf("too many languages: %+v", langs)
+	}
+}
+
+func TestIsAny(t *testing.T) {
+	tests := []struct {
+		text  string
+		codes []string
+		ex    bool
+	}{
+		{"Check out the new look, I just love it and you will too", []string{"en"}, true},
+		{"Check out the new look, I just love it and you will too", []string{"es", "fr"}, false},
+		{"Hoy es un día muy bonito, gracias a todos por el amor", []string{"en", "es"}, true},
+		// Too short to tell
+		{"#ad @brand", []string{"fr"}, true},
+	}
+
+	for _, ts := range tests {
+		if v := IsAny(ts.text, ts.codes); v != ts.ex {
+			t.Errorf("%q in %v: wanted %v, got %v", ts.text, ts.codes, ts.ex, v)
+		}
+	}
+}
+
+func TestSpeaks(t *testing.T) {
+	langs := []*Language{{Code: "en", Confidence: 0.7}, {Code: "es", Confidence: 0.3}}
+
+	tests := []struct {
+		langs []*Language
+		codes []string
+		ex    bool
+	}{
+		{langs, []string{"es"}, true},
+		{langs, []string{"fr", "en"}, true},
+		{langs, []string{"de"}, false},
+		{nil, []string{"en"}, false},
+		{langs, nil, false},
+	}
+
+	for _, ts := range tests {
+		if v := Speaks(ts.langs, ts.codes); v != ts.ex {
+			t.Errorf("%v: wanted %v, got %v", ts.codes, ts.ex, v)
+		}
+	}
+}
+
+func TestIsSupported(t *testing.T) {
+	if !IsSupported("en") || !IsSupported("pt") || IsSupported("xx") || IsSupported("") {
+		t.Fatal("unexpected supported languages")
+	}
+}
@@ -11,6 +11,14 @@ func getEngagements(st *common.Stats) int32 {
 	return st.Likes + st.Dislikes + st.Comments + st.Shares
 }
 
+// getRevenue returns the order value of the coupon redemptions
+func getRevenue(st *common.Stats) (v float64) {
+	for _, r := range st.Redemptions {
+		v += r.Value
+	}
+	return
+}
+
 func getEngagementsFromReport(st *ReportStats) int32 {
 	return st.Likes + st.Comments + st.Shares
 }
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/boltdb/bolt"
@@ -11,6 +13,7 @@ import (
 	"github.com/swayops/sway/config"
 	"github.com/swayops/sway/internal/auth"
 	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/internal/disclosure"
 	"github.com/swayops/sway/internal/templates"
 	"github.com/swayops/sway/misc"
 	"github.com/swayops/sway/platforms/pdf"
@@ -99,6 +102,15 @@ func GenerateCampaignReport(c *gin.Context, auth *auth.Auth, db *bolt.DB, cid st
 		setChannelLevelSheet(xf, from, to, st.Channel)
 		setInfluencerLevelSheet(xf, from, to, st.Influencer)
 		setContentLevelSheet(xf, from, to, st.Post)
+		if len(st.Variant) > 0 {
+			setVariantLevelSheet(xf, from, to, st.Variant)
+		}
+		if conflicts := GetExclusivityConflicts(cmp, auth); len(conflicts) > 0 {
+			setConflictSheet(xf, conflicts)
+		}
+		if audit := GetDisclosureAudit(cmp); len(audit) > 0 {
+			setDisclosureSheet(xf, audit)
+		}
 
 		c.Header("Content-Type", misc.XLSTContentType)
 		if _, err := xf.WriteTo(c.Writer); err != nil {
@@ -165,6 +177,10 @@ func setHighLevelSheet(xf misc.Sheeter, cmp *common.Campaign, from, to time.Time
 		sheet.AddRow("Total Clicks", tot.Clicks)
 		sheet.AddRow("Total Unique Clicks", tot.Uniques)
 		sheet.AddRow("Total Conversions", tot.Conversions)
+		if tot.Redemptions > 0 {
+			sheet.AddRow("Total Coupon Redemptions", tot.Redemptions)
+			sheet.AddRow("Coupon Revenue", fmt.Sprintf("$%0.2f", tot.Revenue))
+		}
 
 		sheet.AddRow("")
 
@@ -311,6 +327,217 @@ func setContentLevelSheet(xf misc.Sheeter, from, to time.Time, content map[strin
 	}
 }
 
+func setVariantLevelSheet(xf misc.Sheeter, from, to time.Time, variant map[string]*ReportStats) {
+	sheet := xf.AddSheet("Variant Level")
+	sheet.AddHeader(
+		"Variant",
+		"Engagements",
+		"Likes",
+		"Comments",
+		"Shares",
+		"Clicks / Uniques",
+		"Est Views",
+		"Conversions",
+		"Spent",
+		"CPM",
+		"CPE",
+		"CPV",
+		"% of Total Engagements",
+	)
+
+	var totalEng float64
+	for _, st := range variant {
+		totalEng += float64(st.Likes + st.Comments + st.Shares + st.Clicks)
+	}
+
+	for name, st := range variant {
+		var eng float64
+		if totalEng > 0 {
+			eng = (float64(st.Likes+st.Comments+st.Shares+st.Clicks) / totalEng) * 100
+		}
+		sheet.AddRow(
+			name,
+			st.Engagements,
+			st.Likes,
+			st.Comments,
+			st.Shares,
+			fmt.Sprintf("%d / %d", st.Clicks, st.Uniques),
+			st.Views,
+			st.Conversions,
+			fmt.Sprintf("$%0.2f", st.Spent),
+			fmt.Sprintf("$%0.2f", getCPM(st.Spent, float64(st.Views))),
+			fmt.Sprintf("$%0.2f", getCPE(st.Spent, float64(getEngagementsFromReport(st)))),
+			fmt.Sprintf("$%0.2f", getCPV(st.Spent, float64(st.Views))),
+			getPerc(eng),
+		)
+	}
+}
+
+// GetExclusivityConflicts returns the campaign's deals whose influencers
+// also worked with a competitor within the exclusivity window
+func GetExclusivityConflicts(cmp *common.Campaign, auth *auth.Auth) []*common.ExclusivityConflict {
+	if !cmp.Exclusivity.IsSet() {
+		return nil
+	}
+
+	var conflicts []*common.ExclusivityConflict
+	for _, deal := range cmp.Deals {
+		if deal.InfluencerId == "" || deal.Assigned == 0 {
+			continue
+		}
+
+		inf, ok := auth.Influencers.Get(deal.InfluencerId)
+		if !ok {
+			continue
+		}
+
+		ts := deal.Completed
+		if ts == 0 {
+			ts = deal.Assigned
+		}
+
+		for _, other := range inf.GetConflicts(cmp.GetExclusivity(), cmp.AdvertiserId, ts) {
+			conflicts = append(conflicts, &common.ExclusivityConflict{
+				DealId:          deal.Id,
+				InfluencerId:    inf.Id,
+				InfluencerName:  inf.Name,
+				Category:        cmp.Exclusivity.Category,
+				OtherCampaignId: other.CampaignId,
+				OtherCompany:    other.Company,
+				OtherCompleted:  other.Completed,
+			})
+		}
+	}
+
+	return conflicts
+}
+
+func setConflictSheet(xf misc.Sheeter, conflicts []*common.ExclusivityConflict) {
+	sheet := xf.AddSheet("Exclusivity Conflicts")
+	sheet.AddHeader(
+		"Influencer",
+		"Deal ID",
+		"Category",
+		"Competitor",
+		"Competitor Campaign ID",
+		"Competitor Post Date",
+	)
+
+	for _, cf := range conflicts {
+		posted := "Pending"
+		if cf.OtherCompleted > 0 {
+			posted = time.Unix(int64(cf.OtherCompleted), 0).Format("January 2, 2006")
+		}
+
+		sheet.AddRow(
+			cf.InfluencerName,
+			cf.DealId,
+			cf.Category,
+			cf.OtherCompany,
+			cf.OtherCampaignId,
+			posted,
+		)
+	}
+}
+
+// DisclosureAudit is a deal's latest sponsorship disclosure check
+type DisclosureAudit struct {
+	DealId         string `json:"dealId"`
+	InfluencerId   string `json:"infId"`
+	InfluencerName string `json:"infName,omitempty"`
+	Completed      int32  `json:"completed,omitempty"`
+
+	*disclosure.Result
+}
+
+// GetDisclosureAudit returns the disclosure checks for completed deals
+// along with active deals whose posts failed
+func GetDisclosureAudit(cmp *common.Campaign) []*DisclosureAudit {
+	var audit []*DisclosureAudit
+	for _, deal := range cmp.Deals {
+		if !deal.IsComplete() && (!deal.IsActive() || deal.Disclosure == nil) {
+			continue
+		}
+
+		res := deal.Disclosure
+		if res == nil {
+			// Completed before we checked disclosures
+			res = &disclosure.Result{PostURL: deal.PostUrl, Platform: deal.AssignedPlatform}
+		}
+
+		audit = append(audit, &DisclosureAudit{
+			DealId:         deal.Id,
+			InfluencerId:   deal.InfluencerId,
+			InfluencerName: deal.InfluencerName,
+			Completed:      deal.Completed,
+			Result:         res,
+		})
+	}
+
+	sort.Slice(audit, func(i, j int) bool {
+		if audit[i].Completed != audit[j].Completed {
+			return audit[i].Completed > audit[j].Completed
+		}
+		return audit[i].DealId < audit[j].DealId
+	})
+
+	return audit
+}
+
+func setDisclosureSheet(xf misc.Sheeter, audit []*DisclosureAudit) {
+	sheet := xf.AddSheet("Disclosure Compliance")
+	sheet.AddHeader(
+		"Influencer",
+		"Deal ID",
+		"Channel",
+		"Post URL",
+		"Market",
+		"Rules",
+		"Result",
+		"Disclosure",
+		"Position",
+		"Reasons",
+		"Checked",
+	)
+
+	for _, a := range audit {
+		var (
+			result  = "Fail"
+			checked = "Not checked"
+			found   = a.Term
+		)
+
+		if a.Checked == 0 {
+			result = "Not checked"
+		} else {
+			checked = time.Unix(int64(a.Checked), 0).Format("January 2, 2006")
+			if a.Pass {
+				result = "Pass"
+			}
+		}
+
+		if a.Native {
+			found = "Paid partnership label"
+		} else if found != "" && !strings.Contains(found, " ") {
+			found = "#" + found
+		}
+
+		sheet.AddRow(
+			a.InfluencerName,
+			a.DealId,
+			a.Platform,
+			a.PostURL,
+			strings.ToUpper(a.Market),
+			a.Rule,
+			result,
+			found,
+			a.Position,
+			a.Reason(),
+			checked,
+		)
+	}
+}
+
 func getPerc(val float64) string {
 	if val < 1 {
 		return "<1%"
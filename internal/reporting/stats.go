@@ -19,6 +19,7 @@ type TargetStats struct {
 	Channel    map[string]*ReportStats `json:"channel,omitempty"`
 	Influencer map[string]*ReportStats `json:"influencer,omitempty"`
 	Post       map[string]*ReportStats `json:"post,omitempty"`
+	Variant    map[string]*ReportStats `json:"variant,omitempty"` // Only filled for campaigns with variants
 }
 
 type Totals struct {
@@ -30,6 +31,10 @@ type Totals struct {
 	Uniques     int32 `json:"uniques,omitempty"`
 	Conversions int32 `json:"conversions,omitempty"`
 
+	// Coupon codes used at checkout by the influencer's audience
+	Redemptions int32   `json:"redemptions,omitempty"`
+	Revenue     float64 `json:"revenue,omitempty"`
+
 	Comments int32 `json:"comments,omitempty"`
 	Shares   int32 `json:"shares,omitempty"`
 
@@ -46,7 +51,9 @@ type ReportStats struct {
 	Clicks      int32 `json:"clicks,omitempty"`
 	Uniques     int32 `json:"uniques,omitempty"`
 	Conversions int32 `json:"conversions,omitempty"`
+	Redemptions int32 `json:"redemptions,omitempty"`
 
+	Revenue     float64 `json:"revenue,omitempty"`
 	Spent       float64 `json:"spent,omitempty"`
 	Rep         float64 `json:"rep,omitempty"`
 	Engagements int32   `json:"engagements,omitempty"`
@@ -93,6 +100,8 @@ func GetCampaignStats(cid string, db *bolt.DB, cfg *config.Config, from, to time
 			tg.Total.Comments += st.Comments
 			tg.Total.Perks += st.Perks
 			tg.Total.Conversions += int32(len(st.Conversions))
+			tg.Total.Redemptions += int32(len(st.Redemptions))
+			tg.Total.Revenue += getRevenue(st)
 
 			// This assumes each influencer can do the deal once
 			tg.Total.Influencers++
@@ -119,6 +128,14 @@ func GetCampaignStats(cid string, db *bolt.DB, cfg *config.Config, from, to time
 
 			fillContentLevelStats(deal.PostUrl, deal.AssignedPlatform, deal.Published(), tg.Post, st, deal.InfluencerId, deal.Id)
 
+			if len(cmp.Variants) > 0 {
+				if tg.Variant == nil {
+					tg.Variant = make(map[string]*ReportStats)
+				}
+
+				fillVariantStats(cmp.GetVariantName(deal.VariantId), tg.Variant, st)
+			}
+
 			continue
 		}
 	}
@@ -138,6 +155,8 @@ func fillReportStats(key string, data map[string]*ReportStats, st *common.Stats,
 	}
 
 	stats.Conversions += int32(len(st.Conversions))
+	stats.Redemptions += int32(len(st.Redemptions))
+	stats.Revenue += getRevenue(st)
 	stats.Likes += st.Likes
 	stats.Comments += st.Comments
 	stats.Shares += st.Shares
@@ -159,6 +178,8 @@ func fillContentLevelStats(key, platformId string, ts int32, data map[string]*Re
 	}
 
 	stats.Conversions += int32(len(st.Conversions))
+	stats.Redemptions += int32(len(st.Redemptions))
+	stats.Revenue += getRevenue(st)
 	stats.Likes += st.Likes
 	stats.Clicks += st.GetClicks()
 	stats.Uniques += st.GetUniqueClicks()
@@ -174,6 +195,28 @@ func fillContentLevelStats(key, platformId string, ts int32, data map[string]*Re
 	return data
 }
 
+func fillVariantStats(key string, data map[string]*ReportStats, st *common.Stats) map[string]*ReportStats {
+	stats, ok := data[key]
+	if !ok {
+		stats = &ReportStats{}
+		data[key] = stats
+	}
+
+	stats.Conversions += int32(len(st.Conversions))
+	stats.Redemptions += int32(len(st.Redemptions))
+	stats.Revenue += getRevenue(st)
+	stats.Likes += st.Likes
+	stats.Comments += st.Comments
+	stats.Shares += st.Shares
+	stats.Views += st.Views
+	stats.Clicks += st.GetClicks()
+	stats.Uniques += st.GetUniqueClicks()
+	stats.Engagements += getEngagements(st)
+	stats.Spent += st.Influencer + st.TotalMarkup()
+
+	return data
+}
+
 func GetInfluencerStats(inf influencer.Influencer, cfg *config.Config, from, to time.Time, cid, agid string) (*ReportStats, error) {
 	stats := &ReportStats{}
 	dates := common.GetDateRange(from, to)
@@ -195,6 +238,8 @@ func GetInfluencerStats(inf influencer.Influencer, cfg *config.Config, from, to
 		stats.AgencySpent += st.Agency
 		stats.Engagements += eng
 		stats.Conversions += int32(len(st.Conversions))
+		stats.Redemptions += int32(len(st.Redemptions))
+		stats.Revenue += getRevenue(st)
 	}
 	return stats, nil
 }
@@ -224,6 +269,8 @@ func GetCampaignBreakdown(cid string, db *bolt.DB, cfg *config.Config, startOffs
 			val.Spent += tot.Total.Spent
 			val.Perks += tot.Total.Perks
 			val.Conversions += tot.Total.Conversions
+			val.Redemptions += tot.Total.Redemptions
+			val.Revenue += tot.Total.Revenue
 			if val.Influencers == 0 {
 				// Only needs to be set once
 				val.Influencers = tot.Total.Influencers
@@ -278,6 +325,8 @@ func GetInfluencerBreakdown(inf influencer.Influencer, cfg *config.Config, offse
 			val.Views += r.Views
 			val.Spent += r.Spent
 			val.Conversions += r.Conversions
+			val.Redemptions += r.Redemptions
+			val.Revenue += r.Revenue
 			val.AgencySpent += r.AgencySpent
 			val.Engagements += r.Engagements
 		}
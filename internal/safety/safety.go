@@ -0,0 +1,357 @@
+package safety
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Risk categories campaigns can exclude
+const (
+	PROFANITY = "profanity"
+	ADULT     = "adult"
+	VIOLENCE  = "violence"
+	DRUGS     = "drugs"
+	ALCOHOL   = "alcohol"
+	POLITICS  = "politics"
+)
+
+const (
+	STATUS_REVIEW  = "review"  // Borderline.. waiting on an admin
+	STATUS_FLAGGED = "flagged" // Over the flag threshold
+
+	// Scores are the share of texts a category shows up in.. we
+	// divide by at least this many so a single caption can't flag
+	// someone with hardly any posts
+	MIN_TEXTS = 10
+
+	DEFAULT_REVIEW = 0.2
+	DEFAULT_FLAG   = 0.4
+
+	// Terms kept per category to show admins why a score is high
+	MAX_MATCHES = 5
+)
+
+var ErrCategory = errors.New("Unknown risk category")
+
+var Categories = []string{PROFANITY, ADULT, VIOLENCE, DRUGS, ALCOHOL, POLITICS}
+
+// Terms are matched against whole words (or runs of words) in captions,
+// bios and Imagga keywords. Hashtags count as words. Everyday words
+// that mostly show up innocently ("shots", "vote", "kill") are left out
+var defaultLexicons = map[string][]string{
+	PROFANITY: {"fuck", "fucking", "fucked", "motherfucker", "shit", "bullshit", "bitch", "asshole", "bastard", "cunt", "dick", "piss", "wtf", "stfu"},
+	ADULT:     {"nsfw", "porn", "porno", "nude", "nudes", "naked", "xxx", "onlyfans", "erotic", "lingerie", "strip club", "stripper", "escort"},
+	VIOLENCE:  {"gun", "guns", "rifle", "shootout", "weapon", "weapons", "murder", "stab", "assault", "gore", "fight club"},
+	DRUGS:     {"weed", "marijuana", "cannabis", "420", "stoned", "blunt", "cocaine", "heroin", "meth", "mdma", "molly", "lsd", "shrooms", "xanax", "high af", "drugs"},
+	ALCOHOL:   {"alcohol", "vodka", "whiskey", "tequila", "champagne", "cocktail", "cocktails", "booze", "drunk", "hangover", "liquor"},
+	POLITICS:  {"politics", "political", "election", "trump", "biden", "democrat", "democrats", "republican", "republicans", "gop", "maga", "liberal", "conservative", "senate", "congress", "protest"},
+}
+
+// Rule customizes a category from the config
+type Rule struct {
+	Terms   []string `json:"terms,omitempty"`   // Added to the default lexicon
+	Replace bool     `json:"replace,omitempty"` // Terms replace the default lexicon instead
+
+	Review float64 `json:"review,omitempty"` // Score that puts the category up for admin review
+	Flag   float64 `json:"flag,omitempty"`   // Score that flags the influencer outright
+}
+
+type lexicon struct {
+	terms        []string // Normalized with spaces on both ends
+	review, flag float64
+}
+
+var lexicons = struct {
+	sync.RWMutex
+	m map[string]*lexicon
+}{}
+
+func init() {
+	if err := Configure(nil); err != nil {
+		panic(err)
+	}
+}
+
+// Configure sets the lexicons and thresholds.. categories without a rule
+// use the defaults
+func Configure(rules map[string]*Rule) error {
+	m := make(map[string]*lexicon, len(Categories))
+	for _, cat := range Categories {
+		var (
+			terms = defaultLexicons[cat]
+			lex   = &lexicon{review: DEFAULT_REVIEW, flag: DEFAULT_FLAG}
+		)
+
+		if r := rules[cat]; r != nil {
+			if r.Replace {
+				terms = r.Terms
+			} else {
+				terms = append(append([]string{}, terms...), r.Terms...)
+			}
+
+			if r.Review > 0 {
+				lex.review = r.Review
+			}
+			if r.Flag > 0 {
+				lex.flag = r.Flag
+			}
+		}
+
+		if lex.review > lex.flag {
+			return errors.New("Review threshold is over the flag threshold for " + cat)
+		}
+
+		seen := make(map[string]bool, len(terms))
+		for _, t := range terms {
+			if t = normalize(t); t != " " && !seen[t] {
+				seen[t] = true
+				lex.terms = append(lex.terms, t)
+			}
+		}
+		m[cat] = lex
+	}
+
+	for cat := range rules {
+		if !IsCategory(cat) {
+			return ErrCategory
+		}
+	}
+
+	lexicons.Lock()
+	lexicons.m = m
+	lexicons.Unlock()
+	return nil
+}
+
+func IsCategory(cat string) bool {
+	for _, c := range Categories {
+		if c == cat {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize lowercases the text and joins its words with single
+// spaces (padded on both ends) so terms only match whole words
+func normalize(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	return " " + strings.Join(words, " ") + " "
+}
+
+type Score struct {
+	Score   float64  `json:"score"`
+	Status  string   `json:"status,omitempty"`
+	Matches []string `json:"matches,omitempty"` // Terms that were found
+}
+
+// Report is the latest classification for an influencer
+type Report struct {
+	Scores  map[string]*Score `json:"scores,omitempty"` // Only categories that matched
+	Texts   int               `json:"texts"`            // Captions, bios and keyword sets looked at
+	Updated int32             `json:"updated,omitempty"`
+
+	// Admin decisions keyed off of category.. true keeps the
+	// influencer flagged, false clears them
+	Overrides map[string]bool `json:"overrides,omitempty"`
+	Reviewed  int32           `json:"reviewed,omitempty"`
+}
+
+// Classify scores every risk category by the share of texts it shows up
+// in. Image keywords are treated as one text. Returns nil if there's
+// nothing to look at
+func Classify(texts []string, keywords []string) *Report {
+	docs := make([]string, 0, len(texts)+1)
+	for _, t := range texts {
+		if strings.TrimSpace(t) != "" {
+			docs = append(docs, normalize(t))
+		}
+	}
+
+	if len(keywords) > 0 {
+		docs = append(docs, normalize(strings.Join(keywords, ", ")))
+	}
+
+	if len(docs) == 0 {
+		return nil
+	}
+
+	lexicons.RLock()
+	defer lexicons.RUnlock()
+
+	r := &Report{Texts: len(docs), Updated: int32(time.Now().Unix())}
+
+	div := float64(len(docs))
+	if div < MIN_TEXTS {
+		div = MIN_TEXTS
+	}
+
+	for _, cat := range Categories {
+		var (
+			lex     = lexicons.m[cat]
+			hits    int
+			matches = make(map[string]int)
+		)
+
+		for _, doc := range docs {
+			var hit bool
+			for _, t := range lex.terms {
+				if strings.Contains(doc, t) {
+					matches[strings.TrimSpace(t)]++
+					hit = true
+				}
+			}
+
+			if hit {
+				hits++
+			}
+		}
+
+		if hits == 0 {
+			continue
+		}
+
+		sc := &Score{Score: float64(hits) / div}
+		if sc.Score >= lex.flag {
+			sc.Status = STATUS_FLAGGED
+		} else if sc.Score >= lex.review {
+			sc.Status = STATUS_REVIEW
+		}
+
+		for t := range matches {
+			sc.Matches = append(sc.Matches, t)
+		}
+
+		// Most common first
+		sort.Slice(sc.Matches, func(i, j int) bool {
+			a, b := sc.Matches[i], sc.Matches[j]
+			if matches[a] != matches[b] {
+				return matches[a] > matches[b]
+			}
+			return a < b
+		})
+
+		if len(sc.Matches) > MAX_MATCHES {
+			sc.Matches = sc.Matches[:MAX_MATCHES]
+		}
+
+		if r.Scores == nil {
+			r.Scores = make(map[string]*Score)
+		}
+		r.Scores[cat] = sc
+	}
+
+	return r
+}
+
+// Carry keeps admin decisions from the previous report. A category
+// that was cleared while borderline goes back to flagged if its score
+// crosses the flag threshold
+func (r *Report) Carry(old *Report) {
+	if r == nil || old == nil {
+		return
+	}
+
+	for cat, flagged := range old.Overrides {
+		if !flagged && r.status(cat) == STATUS_FLAGGED {
+			continue
+		}
+
+		if r.Overrides == nil {
+			r.Overrides = make(map[string]bool)
+		}
+		r.Overrides[cat] = flagged
+	}
+
+	if len(r.Overrides) > 0 {
+		r.Reviewed = old.Reviewed
+	}
+}
+
+func (r *Report) status(cat string) string {
+	if sc := r.Scores[cat]; sc != nil {
+		return sc.Status
+	}
+	return ""
+}
+
+func (r *Report) IsFlagged(cat string) bool {
+	if r == nil {
+		return false
+	}
+
+	if flagged, ok := r.Overrides[cat]; ok {
+		return flagged
+	}
+	return r.status(cat) == STATUS_FLAGGED
+}
+
+func (r *Report) NeedsReview(cat string) bool {
+	if r == nil {
+		return false
+	}
+
+	if _, ok := r.Overrides[cat]; ok {
+		return false
+	}
+	return r.status(cat) == STATUS_REVIEW
+}
+
+// Flagged returns the categories the influencer is flagged for
+func (r *Report) Flagged() []string {
+	var out []string
+	for _, cat := range Categories {
+		if r.IsFlagged(cat) {
+			out = append(out, cat)
+		}
+	}
+	return out
+}
+
+// Pending returns the borderline categories waiting on an admin
+func (r *Report) Pending() []string {
+	var out []string
+	for _, cat := range Categories {
+		if r.NeedsReview(cat) {
+			out = append(out, cat)
+		}
+	}
+	return out
+}
+
+// Excludes returns whether a campaign excluding the categories should
+// skip the influencer. Borderline scores only wait on an admin and
+// don't exclude until they're flagged
+func (r *Report) Excludes(cats []string) bool {
+	for _, cat := range cats {
+		if r.IsFlagged(cat) {
+			return true
+		}
+	}
+	return false
+}
+
+// Review records an admin's decision for each category
+func (r *Report) Review(decisions map[string]bool) error {
+	for cat := range decisions {
+		if !IsCategory(cat) {
+			return ErrCategory
+		}
+	}
+
+	if r.Overrides == nil {
+		r.Overrides = make(map[string]bool)
+	}
+
+	for cat, flagged := range decisions {
+		r.Overrides[cat] = flagged
+	}
+	r.Reviewed = int32(time.Now().Unix())
+	return nil
+}
@@ -0,0 +1,155 @@
+package safety
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	if r := Classify([]string{" ", ""}, nil); r != nil {
+		t.Fatalf("expected no report, got %+v", r)
+	}
+
+	tests := []struct {
+		name     string
+		texts    []string
+		keywords []string
+		cat      string
+		score    float64
+		status   string
+	}{
+		{"clean", []string{"Sunday brunch with the girls", "Golden hour at the beach"}, nil, ALCOHOL, 0, ""},
+		{"one caption", []string{"Vodka tasting tonight!"}, nil, ALCOHOL, 0.1, ""},
+		{"everyday words", []string{"Don't forget to vote", "This look is killing it", "Wine o'clock", "Photo shoot day"}, nil, POLITICS, 0, ""},
+		{"whole words only", []string{"Shitake mushrooms", "Gunther's birthday", "Scrap booking"}, nil, PROFANITY, 0, ""},
+		{"hashtags", []string{"#nsfw", "#NSFW", "ok"}, nil, ADULT, 0.2, STATUS_REVIEW},
+		{"keywords", []string{"a", "b", "c"}, []string{"gun", "rifle"}, VIOLENCE, 0.1, ""},
+		{"flagged", []string{"so drunk", "hangover", "tequila", "booze", "more booze", "x"}, nil, ALCOHOL, 0.5, STATUS_FLAGGED},
+		{"share of texts", []string{"weed", "weed", "weed", "weed", "a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n", "o", "p"}, nil, DRUGS, 0.2, STATUS_REVIEW},
+	}
+
+	for _, ts := range tests {
+		r := Classify(ts.texts, ts.keywords)
+		if r == nil {
+			t.Errorf("%s: expected a report", ts.name)
+			continue
+		}
+
+		sc := r.Scores[ts.cat]
+		if ts.score == 0 {
+			if sc != nil {
+				t.Errorf("%s: expected no %s score, got %+v", ts.name, ts.cat, sc)
+			}
+			continue
+		}
+
+		if sc == nil || sc.Score != ts.score || sc.Status != ts.status {
+			t.Errorf("%s: wanted %v/%q, got %+v", ts.name, ts.score, ts.status, sc)
+		}
+	}
+
+	r := Classify([]string{"booze", "booze and a hangover", "hangover", "drunk"}, nil)
+	if ex := []string{"booze", "hangover", "drunk"}; !reflect.DeepEqual(r.Scores[ALCOHOL].Matches, ex) {
+		t.Fatalf("wanted %v, got %v", ex, r.Scores[ALCOHOL].Matches)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	defer Configure(nil)
+
+	if err := Configure(map[string]*Rule{"nope": {}}); err != ErrCategory {
+		t.Fatalf("wanted %v, got %v", ErrCategory, err)
+	}
+
+	if err := Configure(map[string]*Rule{ALCOHOL: {Review: 0.5, Flag: 0.3}}); err == nil {
+		t.Fatal("expected a bad threshold to fail")
+	}
+
+	if err := Configure(map[string]*Rule{ALCOHOL: {Terms: []string{"Wine"}, Replace: true, Review: 0.05}}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := Classify([]string{"wine night", "vodka"}, nil)
+	if sc := r.Scores[ALCOHOL]; sc == nil || sc.Score != 0.1 || sc.Status != STATUS_REVIEW || !reflect.DeepEqual(sc.Matches, []string{"wine"}) {
+		t.Fatalf("unexpected score: %+v", sc)
+	}
+}
+
+func TestReview(t *testing.T) {
+	r := &Report{Scores: map[string]*Score{
+		ALCOHOL:  {Score: 0.5, Status: STATUS_FLAGGED},
+		POLITICS: {Score: 0.2, Status: STATUS_REVIEW},
+		DRUGS:    {Score: 0.1},
+	}}
+
+	if v := r.Flagged(); !reflect.DeepEqual(v, []string{ALCOHOL}) {
+		t.Fatalf("unexpected flagged: %v", v)
+	}
+
+	if v := r.Pending(); !reflect.DeepEqual(v, []string{POLITICS}) {
+		t.Fatalf("unexpected pending: %v", v)
+	}
+
+	tests := []struct {
+		cats []string
+		ex   bool
+	}{
+		{nil, false},
+		{[]string{DRUGS}, false},
+		{[]string{POLITICS}, false}, // Pending review doesn't exclude
+		{[]string{POLITICS, ALCOHOL}, true},
+	}
+
+	for _, ts := range tests {
+		if v := r.Excludes(ts.cats); v != ts.ex {
+			t.Errorf("%v: wanted %v, got %v", ts.cats, ts.ex, v)
+		}
+	}
+
+	if err := r.Review(map[string]bool{"nope": true}); err != ErrCategory {
+		t.Fatalf("wanted %v, got %v", ErrCategory, err)
+	}
+
+	if err := r.Review(map[string]bool{ALCOHOL: false, POLITICS: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Reviewed == 0 || r.IsFlagged(ALCOHOL) || !r.IsFlagged(POLITICS) || len(r.Pending()) != 0 {
+		t.Fatalf("unexpected report after review: %+v", r)
+	}
+
+	var nilReport *Report
+	if nilReport.Excludes([]string{ALCOHOL}) || nilReport.NeedsReview(ALCOHOL) {
+		t.Fatal("expected a nil report to exclude nothing")
+	}
+}
+
+func TestCarry(t *testing.T) {
+	old := &Report{
+		Overrides: map[string]bool{ALCOHOL: false, POLITICS: false, DRUGS: true},
+		Reviewed:  100,
+	}
+
+	// Politics crossed the flag threshold since it was cleared
+	r := &Report{Scores: map[string]*Score{
+		ALCOHOL:  {Score: 0.2, Status: STATUS_REVIEW},
+		POLITICS: {Score: 0.5, Status: STATUS_FLAGGED},
+	}}
+	r.Carry(old)
+
+	if ex := map[string]bool{ALCOHOL: false, DRUGS: true}; !reflect.DeepEqual(r.Overrides, ex) || r.Reviewed != 100 {
+		t.Fatalf("wanted %v, got %v (%d)", ex, r.Overrides, r.Reviewed)
+	}
+
+	if !r.IsFlagged(POLITICS) || r.IsFlagged(ALCOHOL) || !r.IsFlagged(DRUGS) {
+		t.Fatalf("unexpected flags: %v", r.Flagged())
+	}
+
+	// Nothing to carry
+	fresh := &Report{}
+	fresh.Carry(nil)
+	fresh.Carry(&Report{Reviewed: 100})
+	if fresh.Overrides != nil || fresh.Reviewed != 0 {
+		t.Fatalf("unexpected report: %+v", fresh)
+	}
+}
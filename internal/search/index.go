@@ -0,0 +1,587 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	FIELD_NAME       = "name"
+	FIELD_HANDLE     = "handle"
+	FIELD_BIO        = "bio"
+	FIELD_KEYWORDS   = "keywords"
+	FIELD_CATEGORIES = "categories"
+	FIELD_CAPTIONS   = "captions"
+
+	FACET_PLATFORM = "platform"
+	FACET_BAND     = "band" // Follower band
+	FACET_STATE    = "state"
+	FACET_CATEGORY = "category"
+
+	DEFAULT_LIMIT = 25
+	MAX_LIMIT     = 500
+
+	// Most terms a single prefix or fuzzy term can expand to
+	MAX_EXPANSIONS = 50
+
+	// How much a term counts for depending on how it was matched
+	exactFactor  = 1.0
+	stemFactor   = 0.9
+	prefixFactor = 0.6
+	fuzzyFactor  = 0.4
+)
+
+// Field boosts.. a hit on a handle is worth a lot more than one
+// buried in a caption
+var Boosts = map[string]float64{
+	FIELD_HANDLE:     4,
+	FIELD_NAME:       3,
+	FIELD_KEYWORDS:   2,
+	FIELD_CATEGORIES: 2,
+	FIELD_BIO:        1.5,
+	FIELD_CAPTIONS:   1,
+}
+
+// Fields whose values can also be looked up as a whole (i.e. every doc
+// with the keyword "vegan food")
+var exactFields = map[string]bool{
+	FIELD_HANDLE:     true,
+	FIELD_KEYWORDS:   true,
+	FIELD_CATEGORIES: true,
+}
+
+// Document is anything that can be searched
+type Document struct {
+	Id        string
+	Type      string // "influencer" or "scrap"
+	Name      string
+	Followers int64
+
+	Fields map[string][]string // Field -> texts
+	Facets map[string][]string // Facet -> values
+}
+
+type entry struct {
+	doc    *Document
+	terms  []string
+	values [][2]string // Field and value pairs in Index.values
+}
+
+type posting struct {
+	fields map[string]float64 // Field -> boosted term frequency
+}
+
+// Index is an in memory inverted index. It's safe for concurrent use
+type Index struct {
+	mux sync.RWMutex
+
+	docs     map[string]*entry
+	postings map[string]map[string]*posting        // Term -> doc ID -> posting
+	values   map[string]map[string]map[string]bool // Field -> value -> doc IDs
+
+	// Sorted terms used for prefix and fuzzy matching.. rebuilt lazily
+	// since docs are updated a lot more than they're searched
+	terms []string
+	dirty bool
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[string]*entry),
+		postings: make(map[string]map[string]*posting),
+		values:   make(map[string]map[string]map[string]bool),
+	}
+}
+
+// Put adds or replaces the doc
+func (idx *Index) Put(doc *Document) {
+	if doc == nil || doc.Id == "" {
+		return
+	}
+
+	idx.mux.Lock()
+	idx.remove(doc.Id)
+	idx.add(doc)
+	idx.mux.Unlock()
+}
+
+func (idx *Index) Delete(id string) {
+	idx.mux.Lock()
+	idx.remove(id)
+	idx.mux.Unlock()
+}
+
+// Replace swaps out all docs of the given type
+func (idx *Index) Replace(typ string, docs []*Document) {
+	idx.mux.Lock()
+	defer idx.mux.Unlock()
+
+	for id, e := range idx.docs {
+		if e.doc.Type == typ {
+			idx.remove(id)
+		}
+	}
+
+	for _, doc := range docs {
+		if doc != nil && doc.Id != "" {
+			idx.remove(doc.Id)
+			idx.add(doc)
+		}
+	}
+}
+
+func (idx *Index) Len() int {
+	idx.mux.RLock()
+	defer idx.mux.RUnlock()
+	return len(idx.docs)
+}
+
+func (idx *Index) add(doc *Document) {
+	var (
+		e     = &entry{doc: doc}
+		freqs = make(map[string]map[string]float64)
+	)
+
+	for field, texts := range doc.Fields {
+		if _, ok := Boosts[field]; !ok {
+			continue
+		}
+
+		for _, text := range texts {
+			if exactFields[field] && text != "" {
+				idx.addValue(e, field, text)
+			}
+
+			for _, tok := range Tokenize(text) {
+				count(freqs, tok, field, exactFactor)
+				// Index the stem too so "stories" finds "story"
+				if stem := Stem(tok); stem != tok {
+					count(freqs, stem, field, stemFactor)
+				}
+			}
+		}
+	}
+
+	for term, fields := range freqs {
+		p := &posting{fields: make(map[string]float64, len(fields))}
+		for field, tf := range fields {
+			// Dampen repeated terms so a caption full of the same
+			// hashtag doesn't drown everything out
+			w := 1 + math.Log(tf)
+			if tf < 1 {
+				w = tf
+			}
+			p.fields[field] = w * Boosts[field]
+		}
+
+		docs, ok := idx.postings[term]
+		if !ok {
+			docs = make(map[string]*posting)
+			idx.postings[term] = docs
+			idx.dirty = true
+		}
+		docs[doc.Id] = p
+		e.terms = append(e.terms, term)
+	}
+
+	idx.docs[doc.Id] = e
+}
+
+func (idx *Index) addValue(e *entry, field, value string) {
+	values, ok := idx.values[field]
+	if !ok {
+		values = make(map[string]map[string]bool)
+		idx.values[field] = values
+	}
+
+	ids, ok := values[value]
+	if !ok {
+		ids = make(map[string]bool)
+		values[value] = ids
+	}
+
+	if !ids[e.doc.Id] {
+		ids[e.doc.Id] = true
+		e.values = append(e.values, [2]string{field, value})
+	}
+}
+
+// count adds a hit for the term in the field.. stems count for a
+// little less than the original word
+func count(freqs map[string]map[string]float64, term, field string, factor float64) {
+	fields, ok := freqs[term]
+	if !ok {
+		fields = make(map[string]float64)
+		freqs[term] = fields
+	}
+	fields[field] += factor
+}
+
+func (idx *Index) remove(id string) {
+	e, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+
+	for _, term := range e.terms {
+		docs := idx.postings[term]
+		delete(docs, id)
+		if len(docs) == 0 {
+			delete(idx.postings, term)
+			idx.dirty = true
+		}
+	}
+
+	for _, fv := range e.values {
+		ids := idx.values[fv[0]][fv[1]]
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(idx.values[fv[0]], fv[1])
+		}
+	}
+
+	delete(idx.docs, id)
+}
+
+// Lookup returns every doc that has the exact value (case sensitive)
+// in one of the exactFields, biggest first
+func (idx *Index) Lookup(field, value string) []*Hit {
+	idx.mux.RLock()
+	defer idx.mux.RUnlock()
+
+	ids := idx.values[field][value]
+	hits := make([]*Hit, 0, len(ids))
+	for id := range ids {
+		hits = append(hits, newHit(idx.docs[id].doc))
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Followers != hits[j].Followers {
+			return hits[i].Followers > hits[j].Followers
+		}
+		return hits[i].Id < hits[j].Id
+	})
+
+	return hits
+}
+
+func (idx *Index) sortTerms() {
+	idx.mux.Lock()
+	defer idx.mux.Unlock()
+
+	if !idx.dirty {
+		return
+	}
+
+	terms := make([]string, 0, len(idx.postings))
+	for term := range idx.postings {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+
+	idx.terms, idx.dirty = terms, false
+}
+
+// Query is a search request. An empty Text returns every doc that
+// passes the filters, biggest first
+type Query struct {
+	Text string `json:"text,omitempty"`
+	Type string `json:"type,omitempty"` // "influencer" or "scrap".. empty for both
+
+	Fields  []string            `json:"fields,omitempty"`  // Only match these fields
+	Filters map[string][]string `json:"filters,omitempty"` // Facet -> allowed values
+
+	Prefix bool `json:"prefix,omitempty"` // Treat the last word as a prefix (search as you type)
+	Fuzzy  bool `json:"fuzzy,omitempty"`  // Allow typos
+	Any    bool `json:"any,omitempty"`    // Match any of the words rather than all of them
+
+	Offset int  `json:"offset,omitempty"`
+	Limit  int  `json:"limit,omitempty"`
+	All    bool `json:"-"` // Skip pagination.. for internal lookups
+}
+
+type Hit struct {
+	Id        string   `json:"id"`
+	Type      string   `json:"type"`
+	Name      string   `json:"name,omitempty"`
+	Followers int64    `json:"followers"`
+	Score     float64  `json:"score"`
+	Fields    []string `json:"fields,omitempty"` // Fields that matched
+}
+
+type Results struct {
+	Total  int                       `json:"total"`
+	Offset int                       `json:"offset"`
+	Limit  int                       `json:"limit"`
+	Hits   []*Hit                    `json:"hits"`
+	Facets map[string]map[string]int `json:"facets"` // Facet -> value -> count
+}
+
+// expand returns the indexed terms a query word can match and how
+// much each one counts for
+func (idx *Index) expand(word string, prefix, fuzzy bool) map[string]float64 {
+	out := make(map[string]float64)
+	if _, ok := idx.postings[word]; ok {
+		out[word] = exactFactor
+	}
+
+	if stem := Stem(word); stem != word {
+		if _, ok := idx.postings[stem]; ok {
+			out[stem] = stemFactor
+		}
+	}
+
+	if prefix {
+		n := 0
+		for i := sort.SearchStrings(idx.terms, word); i < len(idx.terms) && n < MAX_EXPANSIONS; i++ {
+			term := idx.terms[i]
+			if !strings.HasPrefix(term, word) {
+				break
+			}
+
+			if _, ok := out[term]; !ok {
+				out[term] = prefixFactor
+				n++
+			}
+		}
+	}
+
+	if max := maxEdits(word); fuzzy && max > 0 {
+		n := 0
+		for _, term := range idx.terms {
+			if n >= MAX_EXPANSIONS {
+				break
+			}
+
+			if _, ok := out[term]; ok {
+				continue
+			}
+
+			if d := editDistance(word, term, max); d <= max {
+				out[term] = fuzzyFactor / float64(d)
+				n++
+			}
+		}
+	}
+
+	return out
+}
+
+func (idx *Index) isAllowed(doc *Document, q *Query) bool {
+	if q.Type != "" && doc.Type != q.Type {
+		return false
+	}
+
+	for facet, allowed := range q.Filters {
+		if len(allowed) == 0 {
+			continue
+		}
+
+		found := false
+		for _, v := range doc.Facets[facet] {
+			for _, a := range allowed {
+				if strings.EqualFold(v, a) {
+					found = true
+					break
+				}
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (q *Query) isField(field string) bool {
+	if len(q.Fields) == 0 {
+		return true
+	}
+
+	for _, f := range q.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Search scores every doc that matches the query and returns the
+// requested page along with facet counts across all matches
+func (idx *Index) Search(q *Query) *Results {
+	if q.Limit <= 0 {
+		q.Limit = DEFAULT_LIMIT
+	} else if q.Limit > MAX_LIMIT {
+		q.Limit = MAX_LIMIT
+	}
+
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+
+	idx.mux.RLock()
+	dirty := idx.dirty
+	idx.mux.RUnlock()
+
+	if dirty && (q.Prefix || q.Fuzzy) {
+		idx.sortTerms()
+	}
+
+	idx.mux.RLock()
+	defer idx.mux.RUnlock()
+
+	var words []string
+	for _, w := range Tokenize(q.Text) {
+		if !containsString(words, w) {
+			words = append(words, w)
+		}
+	}
+
+	hits := make(map[string]*Hit)
+	if len(words) == 0 {
+		for id, e := range idx.docs {
+			if idx.isAllowed(e.doc, q) {
+				hits[id] = newHit(e.doc)
+			}
+		}
+	} else {
+		var (
+			total   = float64(len(idx.docs))
+			matched = make(map[string]int) // Doc ID -> number of words it matched
+		)
+
+		for i, word := range words {
+			// Best score per doc for this word so a word that expands
+			// to many terms doesn't count more than once
+			best := make(map[string]float64)
+			fields := make(map[string][]string)
+
+			for term, factor := range idx.expand(word, q.Prefix && i == len(words)-1, q.Fuzzy) {
+				docs := idx.postings[term]
+				idf := math.Log(1 + total/float64(len(docs)))
+
+				for id, p := range docs {
+					var w float64
+					for field, fw := range p.fields {
+						if q.isField(field) {
+							w += fw
+							if !containsString(fields[id], field) {
+								fields[id] = append(fields[id], field)
+							}
+						}
+					}
+
+					if score := w * idf * factor; score > best[id] {
+						best[id] = score
+					}
+				}
+			}
+
+			for id, score := range best {
+				if score == 0 {
+					continue
+				}
+
+				e := idx.docs[id]
+				if !idx.isAllowed(e.doc, q) {
+					continue
+				}
+
+				h, ok := hits[id]
+				if !ok {
+					h = newHit(e.doc)
+					hits[id] = h
+				}
+
+				h.Score += score
+				for _, f := range fields[id] {
+					if !containsString(h.Fields, f) {
+						h.Fields = append(h.Fields, f)
+					}
+				}
+				matched[id]++
+			}
+		}
+
+		if !q.Any {
+			for id := range hits {
+				if matched[id] < len(words) {
+					delete(hits, id)
+				}
+			}
+		}
+	}
+
+	res := &Results{
+		Total:  len(hits),
+		Offset: q.Offset,
+		Limit:  q.Limit,
+		Hits:   make([]*Hit, 0, len(hits)),
+		Facets: make(map[string]map[string]int),
+	}
+
+	for id, h := range hits {
+		res.Hits = append(res.Hits, h)
+		for facet, values := range idx.docs[id].doc.Facets {
+			counts, ok := res.Facets[facet]
+			if !ok {
+				counts = make(map[string]int)
+				res.Facets[facet] = counts
+			}
+
+			for _, v := range values {
+				counts[v]++
+			}
+		}
+	}
+
+	sort.Slice(res.Hits, func(i, j int) bool {
+		a, b := res.Hits[i], res.Hits[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Followers != b.Followers {
+			return a.Followers > b.Followers
+		}
+		return a.Id < b.Id
+	})
+
+	if q.All {
+		res.Offset, res.Limit = 0, len(res.Hits)
+	} else if q.Offset >= len(res.Hits) {
+		res.Hits = res.Hits[:0]
+	} else {
+		res.Hits = res.Hits[q.Offset:]
+		if len(res.Hits) > q.Limit {
+			res.Hits = res.Hits[:q.Limit]
+		}
+	}
+
+	for _, h := range res.Hits {
+		h.Score = math.Round(h.Score*1000) / 1000
+	}
+
+	return res
+}
+
+func newHit(doc *Document) *Hit {
+	return &Hit{
+		Id:        doc.Id,
+		Type:      doc.Type,
+		Name:      doc.Name,
+		Followers: doc.Followers,
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTestIndex() *Index {
+	idx := NewIndex()
+	idx.Put(&Document{
+		Id:        "1",
+		Type:      "influencer",
+		Name:      "Jane",
+		Followers: 50000,
+		Fields: map[string][]string{
+			FIELD_NAME:       {"Jane Doe"},
+			FIELD_HANDLE:     {"jane_runs"},
+			FIELD_BIO:        {"Marathon runner and vegan baker"},
+			FIELD_KEYWORDS:   {"running shoes", "vegan"},
+			FIELD_CATEGORIES: {"fitness"},
+			FIELD_CAPTIONS:   {"Long run stories from today"},
+		},
+		Facets: map[string][]string{
+			FACET_PLATFORM: {"instagram"},
+			FACET_STATE:    {"CA"},
+		},
+	})
+
+	idx.Put(&Document{
+		Id:        "2",
+		Type:      "influencer",
+		Name:      "Bob",
+		Followers: 5000,
+		Fields: map[string][]string{
+			FIELD_NAME:       {"Bob Smith"},
+			FIELD_HANDLE:     {"bobcooks"},
+			FIELD_BIO:        {"Home cook"},
+			FIELD_KEYWORDS:   {"vegan"},
+			FIELD_CATEGORIES: {"food"},
+			FIELD_CAPTIONS:   {"Vegan running fuel"},
+		},
+		Facets: map[string][]string{
+			FACET_PLATFORM: {"instagram", "youtube"},
+			FACET_STATE:    {"NY"},
+		},
+	})
+
+	idx.Put(&Document{
+		Id:        "sc-3",
+		Type:      "scrap",
+		Name:      "fitfam",
+		Followers: 100000,
+		Fields: map[string][]string{
+			FIELD_HANDLE:     {"fitfam"},
+			FIELD_KEYWORDS:   {"Vegan"},
+			FIELD_CATEGORIES: {"fitness"},
+		},
+		Facets: map[string][]string{
+			FACET_PLATFORM: {"twitter"},
+		},
+	})
+
+	return idx
+}
+
+func hitIds(hits []*Hit) []string {
+	ids := []string{}
+	for _, h := range hits {
+		ids = append(ids, h.Id)
+	}
+	return ids
+}
+
+func TestSearch(t *testing.T) {
+	idx := newTestIndex()
+
+	tests := []struct {
+		name string
+		q    *Query
+		ex   []string
+	}{
+		{"everything biggest first", &Query{}, []string{"sc-3", "1", "2"}},
+		{"type", &Query{Type: "influencer"}, []string{"1", "2"}},
+		{"facet filter", &Query{Filters: map[string][]string{FACET_STATE: {"ny"}}}, []string{"2"}},
+		{"handle beats captions", &Query{Text: "running"}, []string{"1", "2"}},
+		{"stem", &Query{Text: "story"}, []string{"1"}},
+		{"all words", &Query{Text: "vegan baker"}, []string{"1"}},
+		{"any word", &Query{Text: "baker cook", Any: true}, []string{"1", "2"}},
+		{"fields", &Query{Text: "running", Fields: []string{FIELD_CAPTIONS}}, []string{"2", "1"}},
+		{"no prefix", &Query{Text: "mara"}, []string{}},
+		{"prefix", &Query{Text: "mara", Prefix: true}, []string{"1"}},
+		{"no fuzzy", &Query{Text: "maraton"}, []string{}},
+		{"fuzzy", &Query{Text: "maraton", Fuzzy: true}, []string{"1"}},
+		{"pagination", &Query{Limit: 1, Offset: 1}, []string{"1"}},
+		{"past the end", &Query{Offset: 10}, []string{}},
+	}
+
+	for _, ts := range tests {
+		if ids := hitIds(idx.Search(ts.q).Hits); !reflect.DeepEqual(ids, ts.ex) {
+			t.Errorf("%s: wanted %v, got %v", ts.name, ts.ex, ids)
+		}
+	}
+
+	res := idx.Search(&Query{Text: "vegan"})
+	if res.Total != 3 || res.Facets[FACET_PLATFORM]["instagram"] != 2 || res.Facets[FACET_PLATFORM]["twitter"] != 1 {
+		t.Errorf("unexpected facets: %d %v", res.Total, res.Facets)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	idx := newTestIndex()
+
+	tests := []struct {
+		field, value string
+		ex           []string
+	}{
+		{FIELD_KEYWORDS, "vegan", []string{"1", "2"}},
+		{FIELD_KEYWORDS, "Vegan", []string{"sc-3"}},
+		// Whole values only
+		{FIELD_KEYWORDS, "running", []string{}},
+		{FIELD_KEYWORDS, "running shoes", []string{"1"}},
+		{FIELD_CATEGORIES, "fitness", []string{"sc-3", "1"}},
+		{FIELD_HANDLE, "bobcooks", []string{"2"}},
+		// Not an exact field
+		{FIELD_BIO, "Home cook", []string{}},
+	}
+
+	for _, ts := range tests {
+		if ids := hitIds(idx.Lookup(ts.field, ts.value)); !reflect.DeepEqual(ids, ts.ex) {
+			t.Errorf("%s %q: wanted %v, got %v", ts.field, ts.value, ts.ex, ids)
+		}
+	}
+}
+
+func TestPutAndDelete(t *testing.T) {
+	idx := newTestIndex()
+
+	// Replacing a doc drops its old terms and values
+	idx.Put(&Document{
+		Id:     "2",
+		Type:   "influencer",
+		Fields: map[string][]string{FIELD_KEYWORDS: {"baking"}},
+	})
+
+	if ids := hitIds(idx.Lookup(FIELD_KEYWORDS, "vegan")); !reflect.DeepEqual(ids, []string{"1"}) {
+		t.Errorf("unexpected vegan docs: %v", ids)
+	}
+
+	if ids := hitIds(idx.Search(&Query{Text: "cook"}).Hits); len(ids) != 0 {
+		t.Errorf("unexpected cook docs: %v", ids)
+	}
+
+	idx.Delete("1")
+	if idx.Len() != 2 {
+		t.Fatalf("unexpected index size %d", idx.Len())
+	}
+
+	if ids := hitIds(idx.Search(&Query{Text: "marathon", Prefix: true}).Hits); len(ids) != 0 {
+		t.Errorf("unexpected marathon docs: %v", ids)
+	}
+
+	idx.Replace("scrap", nil)
+	if ids := hitIds(idx.Search(&Query{}).Hits); !reflect.DeepEqual(ids, []string{"2"}) {
+		t.Errorf("unexpected docs after replace: %v", ids)
+	}
+}
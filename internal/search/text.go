@@ -0,0 +1,164 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Words that show up everywhere and would match every doc
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "from": true, "has": true,
+	"have": true, "i": true, "in": true, "is": true, "it": true, "me": true,
+	"my": true, "of": true, "on": true, "or": true, "so": true, "that": true,
+	"the": true, "this": true, "to": true, "was": true, "we": true, "with": true,
+	"you": true, "your": true,
+}
+
+// Tokenize lowercases the text and splits it into words. Hashtags and
+// mentions keep their word (#fitness -> fitness) and handles are split
+// on underscores and dots as well as kept whole (jane_doe -> jane_doe,
+// jane, doe)
+func Tokenize(text string) []string {
+	var tokens []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.'
+	}) {
+		w = strings.Trim(w, "_.")
+		if w == "" {
+			continue
+		}
+
+		parts := strings.FieldsFunc(w, func(r rune) bool {
+			return r == '_' || r == '.'
+		})
+
+		if len(parts) > 1 {
+			tokens = append(tokens, w)
+		}
+
+		for _, p := range parts {
+			if !stopwords[p] {
+				tokens = append(tokens, p)
+			}
+		}
+	}
+	return tokens
+}
+
+func isVowel(b byte) bool {
+	switch b {
+	case 'a', 'e', 'i', 'o', 'u':
+		return true
+	}
+	return false
+}
+
+func hasVowel(w string) bool {
+	for i := 0; i < len(w); i++ {
+		if isVowel(w[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Stem strips common english suffixes so that plurals and verb forms
+// land on the same term (stories -> story, running -> run, baked -> bake).
+// It's deliberately light.. over stemming does more harm than good for
+// names and handles
+func Stem(w string) string {
+	if len(w) <= 3 {
+		return w
+	}
+
+	switch {
+	case strings.HasSuffix(w, "ies") && len(w) > 4:
+		return w[:len(w)-3] + "y"
+	case strings.HasSuffix(w, "sses"):
+		return w[:len(w)-2]
+	case strings.HasSuffix(w, "ing"):
+		if stem := w[:len(w)-3]; len(stem) >= 3 && hasVowel(stem) {
+			return undouble(stem)
+		}
+	case strings.HasSuffix(w, "ed"):
+		if stem := w[:len(w)-2]; len(stem) >= 3 && hasVowel(stem) {
+			if strings.HasSuffix(w, "ied") {
+				return w[:len(w)-3] + "y"
+			}
+			return undouble(stem)
+		}
+	case strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") && !strings.HasSuffix(w, "us") && !strings.HasSuffix(w, "is"):
+		return w[:len(w)-1]
+	}
+
+	return w
+}
+
+// undouble turns "runn" into "run" and "bak" into "bake"
+func undouble(w string) string {
+	n := len(w)
+	switch {
+	case n >= 2 && w[n-1] == w[n-2] && !isVowel(w[n-1]) && w[n-1] != 'l' && w[n-1] != 's' && w[n-1] != 'z':
+		return w[:n-1]
+	case n == 3 && !isVowel(w[0]) && isVowel(w[1]) && !isVowel(w[2]) && w[2] != 'w' && w[2] != 'x' && w[2] != 'y':
+		return w + "e"
+	}
+	return w
+}
+
+// maxEdits is how many typos we allow for a term of the given length
+func maxEdits(w string) int {
+	switch n := len(w); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance returns the levenshtein distance between a and b or
+// max + 1 if it's over max
+func editDistance(a, b string, max int) int {
+	if d := len(a) - len(b); d > max || -d > max {
+		return max + 1
+	}
+
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = prev[j] + 1
+			if v := cur[j-1] + 1; v < cur[j] {
+				cur[j] = v
+			}
+			if v := prev[j-1] + cost; v < cur[j] {
+				cur[j] = v
+			}
+
+			if cur[j] < rowMin {
+				rowMin = cur[j]
+			}
+		}
+
+		if rowMin > max {
+			return max + 1
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,69 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		ex   []string
+	}{
+		{"", nil},
+		{"The Best of LA", []string{"best", "la"}},
+		{"#Fitness and @jane_doe!", []string{"fitness", "jane_doe", "jane", "doe"}},
+		{"running.shoes 2018", []string{"running.shoes", "running", "shoes", "2018"}},
+		{"__trailing__", []string{"trailing"}},
+	}
+
+	for _, ts := range tests {
+		if v := Tokenize(ts.text); !reflect.DeepEqual(v, ts.ex) {
+			t.Errorf("%q: wanted %v, got %v", ts.text, ts.ex, v)
+		}
+	}
+}
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"stories": "story",
+		"running": "run",
+		"baked":   "bake",
+		"carried": "carry",
+		"shoes":   "shoe",
+		"dresses": "dress",
+		"glass":   "glass",
+		"yoga":    "yoga",
+		"bus":     "bus",
+		"ring":    "ring",
+		"red":     "red",
+		"falling": "fall",
+	}
+
+	for w, ex := range tests {
+		if v := Stem(w); v != ex {
+			t.Errorf("%s: wanted %s, got %s", w, ex, v)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		ex   int
+	}{
+		{"fitness", "fitness", 1, 0},
+		{"fitness", "fitnes", 1, 1},
+		{"fitness", "fitnesss", 1, 1},
+		{"fitness", "fintess", 2, 2},
+		{"fitness", "fashion", 2, 3},
+		{"yoga", "yogalife", 2, 3},
+	}
+
+	for _, ts := range tests {
+		if v := editDistance(ts.a, ts.b, ts.max); v != ts.ex {
+			t.Errorf("%s/%s: wanted %d, got %d", ts.a, ts.b, ts.ex, v)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package shipping
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	FAKE = "fake"
+
+	// Hex HMAC-SHA256 of the request body
+	FAKE_SIGNATURE = "X-Fake-Signature"
+)
+
+// Fake is a local carrier for sandbox and tests. Statuses are set
+// by hand or posted to the webhook as signed JSON events
+type Fake struct {
+	mux    sync.RWMutex
+	events map[string][]*Event
+	secret []byte
+}
+
+func NewFake(secret string) *Fake {
+	return &Fake{events: make(map[string][]*Event), secret: []byte(secret)}
+}
+
+func (f *Fake) Name() string {
+	return FAKE
+}
+
+func (f *Fake) TrackingURL(number string) string {
+	return ""
+}
+
+// Set adds an event for the tracking number as of now
+func (f *Fake) Set(number, status string) {
+	f.mux.Lock()
+	f.events[number] = append(f.events[number], &Event{
+		TrackingNumber: number,
+		Status:         status,
+		Ts:             int32(time.Now().Unix()),
+	})
+	f.mux.Unlock()
+}
+
+func (f *Fake) Track(number string) ([]*Event, error) {
+	f.mux.RLock()
+	defer f.mux.RUnlock()
+
+	out := make([]*Event, 0, len(f.events[number]))
+	for _, ev := range f.events[number] {
+		cp := *ev
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+
+// Sign returns the signature for a webhook body
+func (f *Fake) Sign(body []byte) string {
+	h := hmac.New(sha256.New, f.secret)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (f *Fake) Verify(r *http.Request) error {
+	if len(f.secret) == 0 {
+		return ErrSignature
+	}
+
+	sig, err := hex.DecodeString(r.Header.Get(FAKE_SIGNATURE))
+	if err != nil || len(sig) == 0 {
+		return ErrSignature
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return err
+	}
+	// Put the body back for ParseWebhook
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	h := hmac.New(sha256.New, f.secret)
+	h.Write(body)
+	if !hmac.Equal(sig, h.Sum(nil)) {
+		return ErrSignature
+	}
+	return nil
+}
+
+func (f *Fake) ParseWebhook(r *http.Request) ([]*Event, error) {
+	defer r.Body.Close()
+
+	var events []*Event
+	if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
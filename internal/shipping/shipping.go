@@ -0,0 +1,210 @@
+package shipping
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	STATUS_PENDING   = "pending" // Perk hasn't been sent yet
+	STATUS_SHIPPED   = "shipped" // Sent out.. no carrier updates yet
+	STATUS_TRANSIT   = "in_transit"
+	STATUS_OUT       = "out_for_delivery"
+	STATUS_DELIVERED = "delivered"
+	STATUS_EXCEPTION = "exception" // Delayed, damaged, bad address etc
+	STATUS_RETURNED  = "returned"
+
+	MAX_EVENTS        = 50 // Per shipment
+	trackingMaxLength = 64
+
+	// Shipments the carrier still has after this long (stuck in an
+	// exception or never updated) stop holding the deal's timeout
+	MAX_TRANSIT_DAYS = 21
+)
+
+var (
+	ErrCarrier   = errors.New("Unknown shipping carrier")
+	ErrTracking  = errors.New("Please provide a valid tracking number")
+	ErrSignature = errors.New("Invalid webhook signature")
+)
+
+var statuses = map[string]bool{
+	STATUS_SHIPPED:   true,
+	STATUS_TRANSIT:   true,
+	STATUS_OUT:       true,
+	STATUS_DELIVERED: true,
+	STATUS_EXCEPTION: true,
+	STATUS_RETURNED:  true,
+}
+
+func IsStatus(status string) bool {
+	return statuses[status]
+}
+
+// Carrier is anything we can get tracking updates from.. either pushed
+// to us via webhook or polled by the engine
+type Carrier interface {
+	Name() string
+	TrackingURL(number string) string
+
+	// Track returns every event the carrier has for the tracking number
+	Track(number string) ([]*Event, error)
+
+	// Verify returns an error unless the webhook request was signed
+	// by the carrier
+	Verify(r *http.Request) error
+
+	// ParseWebhook returns the events in a webhook request sent by
+	// the carrier
+	ParseWebhook(r *http.Request) ([]*Event, error)
+}
+
+// Set at startup by the config
+var carriers = struct {
+	sync.RWMutex
+	m map[string]Carrier
+}{m: make(map[string]Carrier)}
+
+func Register(c Carrier) {
+	carriers.Lock()
+	carriers.m[c.Name()] = c
+	carriers.Unlock()
+}
+
+func Get(name string) (Carrier, error) {
+	carriers.RLock()
+	c, ok := carriers.m[strings.ToLower(name)]
+	carriers.RUnlock()
+
+	if !ok {
+		return nil, ErrCarrier
+	}
+	return c, nil
+}
+
+type Event struct {
+	TrackingNumber string `json:"trackingNumber,omitempty"`
+	Status         string `json:"status,omitempty"`
+	Message        string `json:"message,omitempty"`
+	Location       string `json:"location,omitempty"`
+	Ts             int32  `json:"ts,omitempty"`
+}
+
+// Shipment is a single perk mailed out for a deal
+type Shipment struct {
+	Carrier        string `json:"carrier,omitempty"`
+	TrackingNumber string `json:"trackingNumber,omitempty"`
+	TrackingURL    string `json:"trackingURL,omitempty"`
+	Status         string `json:"status,omitempty"`
+
+	Shipped   int32 `json:"shipped,omitempty"`
+	Delivered int32 `json:"delivered,omitempty"`
+	Updated   int32 `json:"updated,omitempty"`
+
+	Events []*Event `json:"events,omitempty"`
+}
+
+// New returns a shipment sent out now. Carrier and tracking number are
+// optional but shipments without them never get updates
+func New(carrier, number string) (*Shipment, error) {
+	var (
+		now = int32(time.Now().Unix())
+		s   = &Shipment{Status: STATUS_SHIPPED, Shipped: now, Updated: now}
+	)
+
+	number = strings.TrimSpace(number)
+	if len(number) > trackingMaxLength {
+		return nil, ErrTracking
+	}
+
+	if carrier == "" {
+		if number != "" {
+			return nil, ErrCarrier
+		}
+		return s, nil
+	}
+
+	c, err := Get(carrier)
+	if err != nil {
+		return nil, err
+	}
+
+	if number == "" {
+		return nil, ErrTracking
+	}
+
+	s.Carrier, s.TrackingNumber, s.TrackingURL = c.Name(), number, c.TrackingURL(number)
+	return s, nil
+}
+
+// AddTracking sets the carrier and tracking number for a shipment
+// that went out without them
+func (s *Shipment) AddTracking(carrier, number string) error {
+	t, err := New(carrier, number)
+	if err != nil {
+		return err
+	}
+
+	if !t.IsTracked() {
+		return ErrTracking
+	}
+
+	s.Carrier, s.TrackingNumber, s.TrackingURL = t.Carrier, t.TrackingNumber, t.TrackingURL
+	return nil
+}
+
+func (s *Shipment) IsTracked() bool {
+	return s != nil && s.Carrier != "" && s.TrackingNumber != ""
+}
+
+func (s *Shipment) IsDelivered() bool {
+	return s != nil && s.Delivered > 0
+}
+
+// IsPending returns whether the carrier still has the package
+func (s *Shipment) IsPending() bool {
+	return s.IsTracked() && !s.IsDelivered() && s.Status != STATUS_RETURNED
+}
+
+// IsOverdue returns whether the shipment has been out for longer
+// than MAX_TRANSIT_DAYS
+func (s *Shipment) IsOverdue() bool {
+	return s != nil && s.Shipped > 0 && int32(time.Now().Unix())-s.Shipped > MAX_TRANSIT_DAYS*24*60*60
+}
+
+// Apply adds the carrier's event and returns whether it changed the
+// shipment. Events we've already seen are ignored
+func (s *Shipment) Apply(ev *Event) bool {
+	if ev == nil || !IsStatus(ev.Status) {
+		return false
+	}
+
+	for _, old := range s.Events {
+		if old.Status == ev.Status && old.Ts == ev.Ts {
+			return false
+		}
+	}
+
+	if ev.Ts == 0 {
+		ev.Ts = int32(time.Now().Unix())
+	}
+
+	s.Events = append(s.Events, ev)
+	if len(s.Events) > MAX_EVENTS {
+		s.Events = s.Events[len(s.Events)-MAX_EVENTS:]
+	}
+
+	// Carriers don't always send events in order
+	if ev.Ts >= s.Updated {
+		s.Status, s.Updated = ev.Status, ev.Ts
+	}
+
+	if ev.Status == STATUS_DELIVERED && s.Delivered == 0 {
+		s.Delivered = ev.Ts
+	}
+
+	return true
+}
@@ -0,0 +1,131 @@
+package shipping
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIsInTransit(t *testing.T) {
+	Register(NewFake("secret"))
+
+	var (
+		now     = int32(time.Now().Unix())
+		overdue = now - (MAX_TRANSIT_DAYS+1)*24*60*60
+
+		tests = []struct {
+			name    string
+			s       *Shipment
+			pending bool
+			overdue bool
+		}{
+			{"nil", nil, false, false},
+			{"untracked", &Shipment{Status: STATUS_SHIPPED, Shipped: now}, false, false},
+			{"tracked", &Shipment{Carrier: FAKE, TrackingNumber: "1", Status: STATUS_TRANSIT, Shipped: now}, true, false},
+			{"delivered", &Shipment{Carrier: FAKE, TrackingNumber: "1", Status: STATUS_DELIVERED, Shipped: now, Delivered: now}, false, false},
+			{"returned", &Shipment{Carrier: FAKE, TrackingNumber: "1", Status: STATUS_RETURNED, Shipped: now}, false, false},
+			{"stuck", &Shipment{Carrier: FAKE, TrackingNumber: "1", Status: STATUS_EXCEPTION, Shipped: overdue}, true, true},
+		}
+	)
+
+	for _, ts := range tests {
+		if v := ts.s.IsPending(); v != ts.pending {
+			t.Errorf("%s: wanted pending %v, got %v", ts.name, ts.pending, v)
+		}
+
+		if v := ts.s.IsOverdue(); v != ts.overdue {
+			t.Errorf("%s: wanted overdue %v, got %v", ts.name, ts.overdue, v)
+		}
+	}
+}
+
+func TestAddTracking(t *testing.T) {
+	Register(NewFake("secret"))
+
+	s, err := New("", "")
+	if err != nil || s.IsTracked() {
+		t.Fatalf("unexpected shipment: %+v %v", s, err)
+	}
+
+	if err = s.AddTracking("", ""); err != ErrTracking {
+		t.Fatalf("wanted %v, got %v", ErrTracking, err)
+	}
+
+	if err = s.AddTracking("nope", "1"); err != ErrCarrier {
+		t.Fatalf("wanted %v, got %v", ErrCarrier, err)
+	}
+
+	if err = s.AddTracking(FAKE, " 123 "); err != nil || !s.IsTracked() || s.TrackingNumber != "123" || s.Status != STATUS_SHIPPED {
+		t.Fatalf("unexpected shipment: %+v %v", s, err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	s := &Shipment{Carrier: FAKE, TrackingNumber: "1", Status: STATUS_SHIPPED, Shipped: 100, Updated: 100}
+
+	tests := []struct {
+		ev      *Event
+		changed bool
+		status  string
+	}{
+		{&Event{Status: "bogus", Ts: 200}, false, STATUS_SHIPPED},
+		{&Event{Status: STATUS_TRANSIT, Ts: 200}, true, STATUS_TRANSIT},
+		{&Event{Status: STATUS_TRANSIT, Ts: 200}, false, STATUS_TRANSIT},
+		{&Event{Status: STATUS_DELIVERED, Ts: 400}, true, STATUS_DELIVERED},
+		// Late events are kept but don't change the status
+		{&Event{Status: STATUS_OUT, Ts: 300}, true, STATUS_DELIVERED},
+	}
+
+	for i, ts := range tests {
+		if v := s.Apply(ts.ev); v != ts.changed || s.Status != ts.status {
+			t.Errorf("%d: wanted %v/%s, got %v/%s", i, ts.changed, ts.status, v, s.Status)
+		}
+	}
+
+	if s.Delivered != 400 || len(s.Events) != 3 {
+		t.Fatalf("unexpected shipment: %+v", s)
+	}
+}
+
+func TestFakeVerify(t *testing.T) {
+	var (
+		f    = NewFake("secret")
+		body = []byte(`[{"trackingNumber": "1", "status": "delivered"}]`)
+	)
+
+	tests := []struct {
+		name string
+		f    *Fake
+		sig  string
+		ex   error
+	}{
+		{"unsigned", f, "", ErrSignature},
+		{"bad signature", f, NewFake("other").Sign(body), ErrSignature},
+		{"not hex", f, "zz", ErrSignature},
+		{"no secret", NewFake(""), NewFake("").Sign(body), ErrSignature},
+		{"signed", f, f.Sign(body), nil},
+	}
+
+	for _, ts := range tests {
+		r, _ := http.NewRequest("POST", "/shipmentHook/fake", bytes.NewReader(body))
+		if ts.sig != "" {
+			r.Header.Set(FAKE_SIGNATURE, ts.sig)
+		}
+
+		if err := ts.f.Verify(r); err != ts.ex {
+			t.Errorf("%s: wanted %v, got %v", ts.name, ts.ex, err)
+			continue
+		}
+
+		if ts.ex != nil {
+			continue
+		}
+
+		// The body is still there for ParseWebhook
+		events, err := ts.f.ParseWebhook(r)
+		if err != nil || len(events) != 1 || events[0].Status != STATUS_DELIVERED {
+			t.Errorf("%s: unexpected events %v %v", ts.name, events, err)
+		}
+	}
+}
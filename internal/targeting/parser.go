@@ -0,0 +1,348 @@
+package targeting
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/swayops/sway/platforms"
+)
+
+const (
+	MAX_LENGTH = 1000 // Characters
+	MAX_DEPTH  = 20   // Nested parentheses and NOTs
+
+	FIELD_CATEGORY  = "category"
+	FIELD_KEYWORD   = "keyword"
+	FIELD_AUDIENCE  = "audience"
+	FIELD_BIO       = "bio"
+	FIELD_PLATFORM  = "platform"
+	FIELD_FOLLOWERS = "followers"
+	FIELD_COUNTRY   = "country"
+	FIELD_STATE     = "state"
+	FIELD_CITY      = "city"
+	FIELD_ZIP       = "zip"
+)
+
+var (
+	ErrEmpty    = errors.New("Targeting expression is empty")
+	ErrLength   = fmt.Errorf("Targeting expression is over %d characters", MAX_LENGTH)
+	ErrDepth    = fmt.Errorf("Targeting expression is nested over %d levels deep", MAX_DEPTH)
+	ErrFollower = errors.New("Follower ranges look like followers:>10k, followers:<1m or followers:10k-50k")
+)
+
+var fields = map[string]bool{
+	FIELD_CATEGORY:  true,
+	FIELD_KEYWORD:   true,
+	FIELD_AUDIENCE:  true,
+	FIELD_BIO:       true,
+	FIELD_PLATFORM:  true,
+	FIELD_FOLLOWERS: true,
+	FIELD_COUNTRY:   true,
+	FIELD_STATE:     true,
+	FIELD_CITY:      true,
+	FIELD_ZIP:       true,
+}
+
+type tokenType int
+
+const (
+	tokTerm tokenType = iota
+	tokAnd
+	tokOr
+	tokNot
+	tokOpen
+	tokClose
+)
+
+type token struct {
+	typ   tokenType
+	field string // Empty for bare words
+	value string
+	pos   int
+}
+
+func lex(src string) ([]*token, error) {
+	var (
+		toks []*token
+		rs   = []rune(src)
+	)
+
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			toks = append(toks, &token{typ: tokOpen, pos: i})
+			i++
+		case r == ')':
+			toks = append(toks, &token{typ: tokClose, pos: i})
+			i++
+		case r == '-' && (i+1 < len(rs) && !unicode.IsSpace(rs[i+1])):
+			// -supplements is short for NOT supplements
+			toks = append(toks, &token{typ: tokNot, pos: i})
+			i++
+		default:
+			start := i
+			word, next, err := readWord(rs, i)
+			if err != nil {
+				return nil, err
+			}
+			i = next
+
+			switch strings.ToUpper(word) {
+			case "AND", "&&":
+				toks = append(toks, &token{typ: tokAnd, pos: start})
+				continue
+			case "OR", "||":
+				toks = append(toks, &token{typ: tokOr, pos: start})
+				continue
+			case "NOT", "!":
+				toks = append(toks, &token{typ: tokNot, pos: start})
+				continue
+			}
+
+			t := &token{typ: tokTerm, value: word, pos: start}
+			// field:value or field:"quoted value"
+			if idx := strings.Index(word, ":"); idx > 0 && rs[start] != '"' {
+				t.field = strings.ToLower(word[:idx])
+				t.value = word[idx+1:]
+				if t.value == "" && i < len(rs) && rs[i] == '"' {
+					if t.value, i, err = readWord(rs, i); err != nil {
+						return nil, err
+					}
+				}
+			}
+
+			if t.value == "" {
+				return nil, fmt.Errorf("Missing value at position %d", start)
+			}
+
+			toks = append(toks, t)
+		}
+	}
+
+	return toks, nil
+}
+
+// readWord reads a bare or quoted word starting at i
+func readWord(rs []rune, i int) (string, int, error) {
+	if rs[i] == '"' {
+		end := i + 1
+		for end < len(rs) && rs[end] != '"' {
+			end++
+		}
+
+		if end == len(rs) {
+			return "", 0, fmt.Errorf("Unclosed quote at position %d", i)
+		}
+		return strings.TrimSpace(string(rs[i+1 : end])), end + 1, nil
+	}
+
+	end := i
+	for end < len(rs) && !unicode.IsSpace(rs[end]) && rs[end] != '(' && rs[end] != ')' && rs[end] != '"' {
+		end++
+	}
+	return string(rs[i:end]), end, nil
+}
+
+// Grammar:
+//
+//	expr    = and { "OR" and }
+//	and     = unary { ["AND"] unary }
+//	unary   = "NOT" unary | primary
+//	primary = "(" expr ")" | [field ":"] value
+//
+// Words next to each other are AND-ed
+type parser struct {
+	toks  []*token
+	pos   int
+	depth int
+}
+
+func (p *parser) peek() *token {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return nil
+}
+
+func (p *parser) next() *token {
+	t := p.peek()
+	if t != nil {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for t := p.peek(); t != nil && t.typ == tokOr; t = p.peek() {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for t := p.peek(); t != nil && t.typ != tokOr && t.typ != tokClose; t = p.peek() {
+		if t.typ == tokAnd {
+			p.next()
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	t := p.peek()
+	if t != nil && t.typ == tokNot {
+		p.next()
+		if p.depth++; p.depth > MAX_DEPTH {
+			return nil, ErrDepth
+		}
+
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		p.depth--
+		return &notNode{n}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	if t == nil {
+		return nil, errors.New("Unexpected end of targeting expression")
+	}
+
+	switch t.typ {
+	case tokOpen:
+		if p.depth++; p.depth > MAX_DEPTH {
+			return nil, ErrDepth
+		}
+
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if c := p.next(); c == nil || c.typ != tokClose {
+			return nil, fmt.Errorf("Missing closing parenthesis for position %d", t.pos)
+		}
+
+		p.depth--
+		return n, nil
+	case tokTerm:
+		return newPredicate(t)
+	default:
+		return nil, unexpected(t)
+	}
+}
+
+func unexpected(t *token) error {
+	if t.typ == tokClose {
+		return fmt.Errorf("Unexpected closing parenthesis at position %d", t.pos)
+	}
+	return fmt.Errorf("Unexpected operator at position %d", t.pos)
+}
+
+func newPredicate(t *token) (node, error) {
+	pred := &predicate{field: t.field, value: strings.ToLower(t.value)}
+	if pred.field == "" {
+		return pred, nil
+	}
+
+	if !fields[pred.field] {
+		return nil, fmt.Errorf("Unknown targeting field %q at position %d", t.field, t.pos)
+	}
+
+	switch pred.field {
+	case FIELD_PLATFORM:
+		if _, ok := platform.ALL_PLATFORMS[pred.value]; !ok {
+			return nil, fmt.Errorf("Unknown platform %q at position %d", t.value, t.pos)
+		}
+	case FIELD_FOLLOWERS:
+		var err error
+		if pred.min, pred.max, err = parseRange(pred.value); err != nil {
+			return nil, err
+		}
+	}
+
+	return pred, nil
+}
+
+// parseRange parses ">10k", "<1m", ">=5000" and "10k-50k"
+func parseRange(v string) (min, max int64, err error) {
+	switch {
+	case strings.HasPrefix(v, ">="):
+		min, err = parseCount(v[2:])
+	case strings.HasPrefix(v, ">"):
+		min, err = parseCount(v[1:])
+		min++
+	case strings.HasPrefix(v, "<="):
+		max, err = parseCount(v[2:])
+	case strings.HasPrefix(v, "<"):
+		max, err = parseCount(v[1:])
+		max--
+	default:
+		parts := strings.SplitN(v, "-", 2)
+		if len(parts) != 2 {
+			return 0, 0, ErrFollower
+		}
+
+		if min, err = parseCount(parts[0]); err != nil {
+			return 0, 0, err
+		}
+		max, err = parseCount(parts[1])
+	}
+
+	if err != nil || min < 0 || max < 0 || (max > 0 && max < min) {
+		return 0, 0, ErrFollower
+	}
+
+	return min, max, nil
+}
+
+func parseCount(v string) (int64, error) {
+	mult := 1.0
+	switch {
+	case strings.HasSuffix(v, "k"):
+		mult, v = 1000, v[:len(v)-1]
+	case strings.HasSuffix(v, "m"):
+		mult, v = 1000000, v[:len(v)-1]
+	}
+
+	f, err := strconv.ParseFloat(strings.Replace(v, ",", "", -1), 64)
+	if err != nil {
+		return 0, ErrFollower
+	}
+
+	return int64(f * mult), nil
+}
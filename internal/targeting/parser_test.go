@@ -0,0 +1,101 @@
+package targeting
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		src, ex string
+	}{
+		{"fitness", "fitness"},
+		{"Fitness yoga", "fitness AND yoga"},
+		{"fitness && yoga || food", "fitness AND yoga OR food"},
+		{"fitness AND (yoga OR pilates)", "fitness AND (yoga OR pilates)"},
+		{"-supplements fitness", "NOT supplements AND fitness"},
+		{"NOT (a AND b)", "NOT (a AND b)"},
+		{"! a", "NOT a"},
+		{"Category:Food state:CA", "category:food AND state:ca"},
+		{`bio:"vegan chef"`, `bio:"vegan chef"`},
+		{`"new york"`, `"new york"`},
+		{"platform:instagram OR platform:youtube", "platform:instagram OR platform:youtube"},
+		{"followers:10k-50k", "followers:10k-50k"},
+	}
+
+	for _, ts := range tests {
+		v, err := Normalize(ts.src)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", ts.src, err)
+			continue
+		}
+
+		if v != ts.ex {
+			t.Errorf("%q: wanted %q, got %q", ts.src, ts.ex, v)
+		}
+
+		// Normalized expressions parse back the same way
+		if again, err := Normalize(v); err != nil || again != v {
+			t.Errorf("%q: didn't round trip, got %q %v", v, again, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	long := make([]byte, MAX_LENGTH+1)
+	for i := range long {
+		long[i] = 'a'
+	}
+
+	deep := ""
+	for i := 0; i <= MAX_DEPTH; i++ {
+		deep += "("
+	}
+	deep += "a"
+
+	tests := []string{
+		"",
+		"   ",
+		string(long),
+		deep,
+		"fitness AND",
+		"OR fitness",
+		"(fitness",
+		"fitness)",
+		"()",
+		`"fitness`,
+		"color:red",
+		"platform:myspace",
+		"followers:lots",
+		"followers:50k-10k",
+		"category:",
+	}
+
+	for _, src := range tests {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("%q: expected an error", src)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		v        string
+		min, max int64
+		err      bool
+	}{
+		{">10k", 10001, 0, false},
+		{">=10k", 10000, 0, false},
+		{"<1m", 0, 999999, false},
+		{"<=1m", 0, 1000000, false},
+		{"10k-50k", 10000, 50000, false},
+		{"1.5k-2,000", 1500, 2000, false},
+		{"50k-10k", 0, 0, true},
+		{"10k", 0, 0, true},
+		{">abc", 0, 0, true},
+	}
+
+	for _, ts := range tests {
+		min, max, err := parseRange(ts.v)
+		if (err != nil) != ts.err || min != ts.min || max != ts.max {
+			t.Errorf("%q: wanted %d-%d (err %v), got %d-%d (%v)", ts.v, ts.min, ts.max, ts.err, min, max, err)
+		}
+	}
+}
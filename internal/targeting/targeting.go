@@ -0,0 +1,222 @@
+package targeting
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/internal/geo"
+)
+
+// Subject is what an expression is evaluated against.. built from
+// either an influencer or a scrap
+type Subject struct {
+	Categories []string
+	Keywords   []string
+	Bio        string
+	Handles    []string
+	Platforms  []string
+	Followers  int64
+	Geo        *geo.GeoRecord
+
+	// Returns whether the subject is in the audience
+	InAudience func(id string) bool
+}
+
+type node interface {
+	eval(s *Subject) bool
+	String() string
+}
+
+type andNode struct{ left, right node }
+
+func (n *andNode) eval(s *Subject) bool { return n.left.eval(s) && n.right.eval(s) }
+func (n *andNode) String() string       { return paren(n.left, false) + " AND " + paren(n.right, false) }
+
+type orNode struct{ left, right node }
+
+func (n *orNode) eval(s *Subject) bool { return n.left.eval(s) || n.right.eval(s) }
+func (n *orNode) String() string       { return n.left.String() + " OR " + n.right.String() }
+
+type notNode struct{ n node }
+
+func (n *notNode) eval(s *Subject) bool { return !n.n.eval(s) }
+func (n *notNode) String() string       { return "NOT " + paren(n.n, true) }
+
+// paren wraps ORs (and ANDs too if strict) in parentheses so the
+// string parses back the same way
+func paren(n node, strict bool) string {
+	switch n.(type) {
+	case *orNode:
+		return "(" + n.String() + ")"
+	case *andNode:
+		if strict {
+			return "(" + n.String() + ")"
+		}
+	}
+	return n.String()
+}
+
+type predicate struct {
+	field, value string
+	min, max     int64 // Follower range
+}
+
+func (p *predicate) String() string {
+	v := p.value
+	if strings.ContainsAny(v, " ()\"") {
+		v = strconv.Quote(v)
+	}
+
+	if p.field == "" {
+		return v
+	}
+	return p.field + ":" + v
+}
+
+func (p *predicate) eval(s *Subject) bool {
+	switch p.field {
+	case "":
+		// Bare words match anything an advertiser would think of as
+		// the creator's niche
+		return inList(s.Categories, p.value) || isKeyword(s, p.value) || isInBio(s, p.value)
+	case FIELD_CATEGORY:
+		return inList(s.Categories, p.value)
+	case FIELD_KEYWORD:
+		return isKeyword(s, p.value)
+	case FIELD_BIO:
+		return isInBio(s, p.value)
+	case FIELD_AUDIENCE:
+		return s.InAudience != nil && s.InAudience(p.value)
+	case FIELD_PLATFORM:
+		return inList(s.Platforms, p.value)
+	case FIELD_FOLLOWERS:
+		return s.Followers >= p.min && (p.max == 0 || s.Followers <= p.max)
+	case FIELD_COUNTRY:
+		return s.Geo != nil && strings.EqualFold(s.Geo.Country, p.value)
+	case FIELD_STATE:
+		return s.Geo != nil && strings.EqualFold(s.Geo.State, p.value)
+	case FIELD_CITY:
+		return s.Geo != nil && strings.EqualFold(s.Geo.City, p.value)
+	case FIELD_ZIP:
+		return s.Geo != nil && geo.NormalizeZip(s.Geo.Zip, s.Geo.Country) == geo.NormalizeZip(p.value, s.Geo.Country)
+	}
+	return false
+}
+
+func inList(list []string, v string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// isKeyword mirrors the campaign keyword check.. image keywords or
+// a handle that contains the word
+func isKeyword(s *Subject, kw string) bool {
+	for _, infKw := range s.Keywords {
+		if common.IsExactMatch(kw, infKw) {
+			return true
+		}
+	}
+
+	for _, h := range s.Handles {
+		if strings.Contains(strings.ToLower(h), kw) {
+			return true
+		}
+	}
+	return false
+}
+
+func isInBio(s *Subject, kw string) bool {
+	return s.Bio != "" && common.IsExactMatch(s.Bio, kw)
+}
+
+// Expr is a compiled targeting expression
+type Expr struct {
+	root node
+}
+
+// Parse compiles the expression or returns an error describing
+// what's wrong with it
+func Parse(src string) (*Expr, error) {
+	src = strings.TrimSpace(src)
+	if src == "" {
+		return nil, ErrEmpty
+	}
+
+	if len(src) > MAX_LENGTH {
+		return nil, ErrLength
+	}
+
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{toks: toks}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t != nil {
+		return nil, unexpected(t)
+	}
+
+	return &Expr{root: root}, nil
+}
+
+// Normalize returns the expression with consistent casing, operators
+// and parentheses.. this is what's saved on the campaign
+func Normalize(src string) (string, error) {
+	e, err := Parse(src)
+	if err != nil {
+		return "", err
+	}
+	return e.String(), nil
+}
+
+func (e *Expr) String() string {
+	return e.root.String()
+}
+
+func (e *Expr) Eval(s *Subject) bool {
+	return e.root.eval(s)
+}
+
+// Most expressions we'll hold compiled.. forecasts can send anything
+const MAX_CACHED = 1000
+
+var cache = struct {
+	sync.RWMutex
+	exprs map[string]*Expr
+}{exprs: make(map[string]*Expr)}
+
+// Match compiles the expression once and evaluates it against the
+// subject. Empty expressions match everyone and invalid ones (which
+// are caught when the campaign is saved) match no one
+func Match(src string, s *Subject) bool {
+	if src == "" {
+		return true
+	}
+
+	cache.RLock()
+	e, ok := cache.exprs[src]
+	cache.RUnlock()
+
+	if !ok {
+		e, _ = Parse(src)
+		cache.Lock()
+		if len(cache.exprs) >= MAX_CACHED {
+			cache.exprs = make(map[string]*Expr)
+		}
+		cache.exprs[src] = e
+		cache.Unlock()
+	}
+
+	return e != nil && e.Eval(s)
+}
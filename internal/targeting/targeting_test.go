@@ -0,0 +1,93 @@
+package targeting
+
+import (
+	"testing"
+
+	"github.com/swayops/sway/internal/geo"
+)
+
+func TestMatch(t *testing.T) {
+	s := &Subject{
+		Categories: []string{"fitness", "food"},
+		Keywords:   []string{"yoga"},
+		Bio:        "Vegan chef in LA. Runner.",
+		Handles:    []string{"Jane_Runs"},
+		Platforms:  []string{"instagram"},
+		Followers:  25000,
+		Geo:        &geo.GeoRecord{Country: "US", State: "CA", City: "Los Angeles", Zip: "90210-1234"},
+		InAudience: func(id string) bool {
+			return id == "aud1"
+		},
+	}
+
+	tests := []struct {
+		src string
+		ex  bool
+	}{
+		{"", true},
+		{"fitness", true},
+		{"beauty", false},
+		// Bare words check categories, keywords and bios
+		{"yoga", true},
+		{"vegan", true},
+		{"runs", true},
+		{"category:fitness -category:beauty", true},
+		{"category:yoga", false},
+		{"keyword:jane", true},
+		{`keyword:"yoga mat"`, true},
+		{"keyword:pilates", false},
+		{"bio:runner", true},
+		{"bio:run", false},
+		{`bio:"vegan chef"`, true},
+		{"audience:aud1", true},
+		{"audience:aud2", false},
+		{"platform:instagram", true},
+		{"platform:youtube", false},
+		{"followers:10k-50k", true},
+		{"followers:>50k", false},
+		{"followers:<=25k", true},
+		{"country:us state:ca", true},
+		{"state:ny", false},
+		{`city:"los angeles"`, true},
+		{"zip:90210", true},
+		{"zip:90211", false},
+		{"beauty OR food", true},
+		{"fitness AND NOT food", false},
+		{"(beauty OR food) AND platform:instagram", true},
+		{"NOT (beauty OR state:ny)", true},
+		// Invalid expressions match no one
+		{"fitness AND", false},
+	}
+
+	for _, ts := range tests {
+		if v := Match(ts.src, s); v != ts.ex {
+			t.Errorf("%q: wanted %v, got %v", ts.src, ts.ex, v)
+		}
+	}
+}
+
+func TestMatchWithoutGeo(t *testing.T) {
+	s := &Subject{Categories: []string{"fitness"}}
+
+	for _, src := range []string{"country:us", "state:ca", "city:la", "zip:90210", "audience:aud1"} {
+		if Match(src, s) {
+			t.Errorf("%q: expected no match without geo or audiences", src)
+		}
+	}
+
+	if !Match("NOT country:us", s) {
+		t.Error("expected NOT to match without geo")
+	}
+}
+
+func TestZipCountry(t *testing.T) {
+	// Only US zips are cut down to 5 digits
+	ca := &Subject{Geo: &geo.GeoRecord{Country: "CA", Zip: "M5V 3L9"}}
+	if !Match("zip:m5v3l9", ca) {
+		t.Error("expected canadian postal code to match")
+	}
+
+	if Match("zip:m5v", ca) {
+		t.Error("expected partial postal code not to match")
+	}
+}
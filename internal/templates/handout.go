@@ -10,6 +10,10 @@ const handoutTmpl = `
 
       Thank you for participating in this endorsement for {{Company}}. Please follow the deal details we have printed out below or you can find this info available to you within your Sway App at: https://inf.swayops.com/login <br><br>
 
+   {{#Variant}}
+   <b>Your pick:</b> {{Variant}}<br><br>
+   {{/Variant}}
+
     <b>Required items that must appear in your post:</b><br>
    <ul style="font-size:16px;">
       {{#Instructions}}
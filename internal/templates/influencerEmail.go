@@ -86,7 +86,7 @@ const headsUpEmail = `
 		Hey {{Name}},
 	</p>
 	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
-		This is a system notification to let you know that you only have 7 days left to complete your Sway deal for {{Company}}. After the 7 days, we will unfortunately be forced to retract the deal from you!
+		This is a system notification to let you know that you only have {{Days}} day(s) left to complete your Sway deal for {{Company}}. After that, we will unfortunately be forced to retract the deal from you!
 	</p>
 	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
 		If you would like to access the deal requirements, please visit <a href="https://inf.swayops.com/login">https://inf.swayops.com/login</a> <br/> Feel free to call or email me or our team with any questions.
@@ -250,6 +250,13 @@ const dealRejectionEmail = `
 	</p>
 	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
 		Your most recent deal post ( {{url}} ) is missing a required item. Unfortunately our engine can't pickup your completed deal because of this. Please double check that you included the <b>{{reason}}</b> in your post and the system will automatically authorize your post.	</p>
+	{{#HasDetails}}
+	<ul style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		{{#details}}
+		<li>{{.}}</li>
+		{{/details}}
+	</ul>
+	{{/HasDetails}}
 	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
 		Regards,<br/>
 		~ Karlie M<br/>
@@ -288,6 +295,11 @@ const perkMailEmail = `
 	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
 		We are emailing to inform you that we have just sent out your perk for the deal you have accepted for {{Company}}. Please allow atleast 5-7 business days for the product to arrive.
 	</p>
+	{{#Tracking}}
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		It was shipped with {{Carrier}} and your tracking number is <b>{{Tracking}}</b>.{{#TrackingURL}} You can follow its progress <a href="{{TrackingURL}}">here</a>.{{/TrackingURL}} Your deadline to post starts once it has been delivered.
+	</p>
+	{{/Tracking}}
 	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
 		Feel free to call or email us with any questions.
 	</p>
@@ -501,6 +513,83 @@ const auditEmailTmpl = `
 </div>
 `
 
+const submissionReviewedEmail = `
+<div>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		Hi {{Name}},
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		{{Company}} has reviewed your post submission and {{Action}}. Here is what they had to say:
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		<i>{{Comment}}</i>
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		Please submit a new revision through our influencer app at <a href="https://inf.swayops.com/login">https://inf.swayops.com/login</a> . Do not make the post until your submission has been approved!
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		Regards,<br/>
+		~ The Sway notification system<br/>
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		<img src="http://swayops.com/swayEmailLogo.png" alt="" height="40" />
+		<br/>
+		Engage@SwayOps.com | Office: 650-667-7929 | Address: 4461 Crossvine Dr, Prosper TX, 75078
+	</p>
+</div>
+`
+
+const extensionEmail = `
+<div>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		Hi {{Name}},
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		{{#Approved}}Good news! {{Company}} has approved your extension request. You now have until <b>{{Deadline}}</b> to complete the deal.{{/Approved}}{{^Approved}}Unfortunately {{Company}} was unable to approve your extension request. Your deal must still be completed by <b>{{Deadline}}</b>.{{/Approved}}
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		If you would like to access the deal requirements, please visit <a href="https://inf.swayops.com/login">https://inf.swayops.com/login</a> <br/> Feel free to call or email me or our team with any questions.
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		All the best,<br/>
+		~ The Sway team<br/>
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		<img src="http://swayops.com/swayEmailLogo.png" alt="" height="40" />
+		<br/>
+		engage@SwayOps.com | Office: 650-667-7929 | Address: 4461 Crossvine Dr, Prosper TX, 75078
+	</p>
+</div>
+`
+
+const dealInviteEmail = `
+<div>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		Hi {{Name}},
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		{{Company}} has personally invited you to their campaign <b>{{Campaign}}</b>! This deal pays <b>${{Payout}}</b> and is reserved for you until <b>{{Deadline}}</b>.
+	</p>
+	{{#Instructions}}
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		{{Instructions}}
+	</p>
+	{{/Instructions}}
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		To accept or decline this invite simply go into our influencer app at <a href="https://inf.swayops.com/login">https://inf.swayops.com/login</a>.<br/> Feel free to call or email me with any questions.
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		All the best,<br/>
+		~ The Sway team<br/>
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		<img src="http://swayops.com/swayEmailLogo.png" alt="" height="40" />
+		<br/>
+		engage@SwayOps.com | Office: 650-667-7929 | Address: 4461 Crossvine Dr, Prosper TX, 75078
+	</p>
+</div>
+`
+
 var (
 	AuditEmail                  = MustacheMust(auditEmailTmpl)
 	InfluencerEmail             = MustacheMust(infEmailTmpl)
@@ -518,4 +607,7 @@ var (
 	DealInstructionsEmail       = MustacheMust(dealInstructionsEmail)
 	SubmissionInstructionsEmail = MustacheMust(submissionInstructionsEmail)
 	SubmissionApprovedEmail     = MustacheMust(submissionApprovedEmail)
+	DealInviteEmail             = MustacheMust(dealInviteEmail)
+	SubmissionReviewedEmail     = MustacheMust(submissionReviewedEmail)
+	ExtensionEmail              = MustacheMust(extensionEmail)
 )
@@ -55,6 +55,29 @@ const notifyEmptyPerk = `
 </div>
 `
 
+const notifyLowStock = `
+<div>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		Hi {{Name}},
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		We are emailing to inform you that {{Perk}} ({{Variant}}) is running low for your campaign {{Campaign}} (Campaign ID: {{ID}}). There are {{Count}} left to send to influencers. Once an option runs out influencers will no longer be able to pick it, so if you would like to send more please edit the campaign and increase the count for that option, and follow shipping instructions on how to send Sway the product.
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		Feel free to call or email me with any questions.
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		Regards,<br/>
+		~ Karlie M<br/>
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		<img src="http://swayops.com/swayEmailLogo.png" alt="" height="40" />
+		<br/>
+		Karlie@SwayOps.com | Office: 650-667-7929 | Address: 4461 Crossvine Dr, Prosper TX, 75078
+	</p>
+</div>
+`
+
 const notifyBillingEmail = `
 <div>
 	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
@@ -142,11 +165,58 @@ const notifyPost = `
 </div>
 `
 
+const notifySubmissionCommentEmail = `
+<div>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		Hi {{Name}},
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		{{InfluencerName}} has just commented on their submission for campaign {{CampaignName}}:
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		<i>{{Comment}}</i>
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		Regards,<br/>
+		~ Karlie M<br/>
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		<img src="http://swayops.com/swayEmailLogo.png" alt="" height="40" />
+		<br/>
+		Karlie@SwayOps.com | Office: 650-667-7929 | Address: 4461 Crossvine Dr, Prosper TX, 75078
+	</p>
+</div>
+`
+
+const notifyUnreadMessages = `
+<div>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		Hi {{Name}},
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		You have {{Count}} unread message(s) from {{From}} regarding the campaign {{CampaignName}}. Please log in at <a href="{{URL}}">{{URL}}</a> to view and reply.
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		Regards,<br/>
+		~ The Sway team<br/>
+	</p>
+	<p style="font-size:14px; color:#000000; margin:0 0 12px 0;">
+		<img src="http://swayops.com/swayEmailLogo.png" alt="" height="40" />
+		<br/>
+		engage@SwayOps.com | Office: 650-667-7929 | Address: 4461 Crossvine Dr, Prosper TX, 75078
+	</p>
+</div>
+`
+
 var (
 	NotifyEmail           = MustacheMust(notifyTmpl)
 	NotifyPerkEmail       = MustacheMust(notifyPerk)
 	NotifyEmptyPerkEmail  = MustacheMust(notifyEmptyPerk)
+	NotifyLowStockEmail   = MustacheMust(notifyLowStock)
 	NotifyBillingEmail    = MustacheMust(notifyBillingEmail)
 	NotifySubmissionEmail = MustacheMust(notifySubmissionEmail)
 	NotifyPostEmail       = MustacheMust(notifyPost)
+
+	NotifySubmissionCommentEmail = MustacheMust(notifySubmissionCommentEmail)
+	NotifyUnreadMessagesEmail    = MustacheMust(notifyUnreadMessages)
 )
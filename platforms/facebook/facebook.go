@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/swayops/sway/config"
+	"github.com/swayops/sway/internal/demographics"
 )
 
 var (
@@ -26,6 +27,8 @@ type Facebook struct {
 	LatestPosts []*Post `json:"posts,omitempty"`       // Posts since last update.. will later check these for deal satisfaction
 
 	ProfilePicture string `json:"profile_picture,omitempty"`
+
+	Audience *demographics.Demographics `json:"audience,omitempty"`
 }
 
 func New(id string, cfg *config.Config) (*Facebook, error) {
@@ -11,7 +11,7 @@ import (
 )
 
 const (
-	postUrl      = "%s%s/posts?access_token=%s|%s"
+	postUrl      = "%s%s/posts?access_token=%s|%s&fields=message,created_time,full_picture"
 	likesUrl     = "%s%s/likes?access_token=%s|%s&summary=true"
 	commentsUrl  = "%s%s/comments?access_token=%s|%s&summary=true"
 	sharesUrl    = "%s?id=%s"
@@ -28,6 +28,7 @@ type Data struct {
 	Id        string `json:"id"`
 	Caption   string `json:"message"`
 	Published FbTime `json:"created_time"`
+	Picture   string `json:"full_picture"`
 }
 
 type Summary struct {
@@ -72,6 +73,7 @@ func getBasicInfo(id string, cfg *config.Config) (likes, comments, shares float6
 			Id:          p.Id,
 			Caption:     p.Caption,
 			Published:   p.Published,
+			Picture:     p.Picture,
 			LastUpdated: int32(time.Now().Unix()),
 			PostURL:     getPostUrl(p.Id),
 		}
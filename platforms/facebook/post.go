@@ -18,7 +18,8 @@ type Post struct {
 	Comments float64 `json:"comments,omitempty"`
 
 	// Type
-	Type string `json:"type,omitempty"` // "video", "photo", "shared_story", "link"
+	Type    string `json:"type,omitempty"`    // "video", "photo", "shared_story", "link"
+	Picture string `json:"picture,omitempty"` // Full size image attached to the post
 
 	LastUpdated int32  `json:"lastUpdated,omitempty"`
 	PostURL     string `json:"postURL,omitempty"`
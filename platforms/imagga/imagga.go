@@ -95,3 +95,42 @@ func clean(keywords []string) []string {
 	}
 	return out
 }
+
+// GetTags returns every tag found in the image and its confidence (0-100)
+func GetTags(image string, sandbox bool) (tags map[string]float64, err error) {
+	if sandbox || image == "" {
+		return
+	}
+
+	params := &url.Values{}
+	params.Add("url", image)
+
+	req, _ := http.NewRequest("GET", "https://api.imagga.com/v1/tagging?"+params.Encode(), nil)
+	req.SetBasicAuth(apiKey, apiSecret)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+
+	var load Load
+	defer resp.Body.Close()
+	if err = json.NewDecoder(resp.Body).Decode(&load); err != nil {
+		return
+	}
+
+	if len(load.Results) == 0 {
+		err = ErrResponse
+		return
+	}
+
+	tags = make(map[string]float64)
+	for _, res := range load.Results {
+		for _, tag := range res.Tags {
+			if kw := strings.ToLower(tag.Tag); tag.Confidence > tags[kw] {
+				tags[kw] = tag.Confidence
+			}
+		}
+	}
+	return
+}
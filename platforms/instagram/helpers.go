@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/swayops/sway/config"
+	"github.com/swayops/sway/internal/demographics"
 	"github.com/swayops/sway/internal/geo"
 	"github.com/swayops/sway/misc"
 )
@@ -25,6 +26,7 @@ const (
 	followersUrl = "%susers/%s/?access_token=%s"
 	postUrl      = "%susers/%s/media/recent/?access_token=%s&count=30"
 	postIdUrl    = "%smedia/%s?access_token=%s"
+	insightsUrl  = "%susers/%s/insights?metric=audience_gender_age,audience_country&period=lifetime&access_token=%s"
 )
 
 var (
@@ -81,6 +83,8 @@ type PostData struct {
 	URL       string   `json:"link"`
 	Type      string   `json:"type"`
 
+	PaidPartnership bool `json:"is_paid_partnership"`
+
 	Comments *Comments `json:"comments"`
 	Likes    *Likes    `json:"likes"`
 	Location *Location `json:"location"`
@@ -180,6 +184,8 @@ func getPostInfo(id string, cfg *config.Config) (postInfo PostInfo, err error) {
 			PostURL:     post.URL,
 			LastUpdated: int32(time.Now().Unix()),
 			Type:        post.Type,
+
+			PaidPartnership: post.PaidPartnership,
 		}
 
 		if post.Comments != nil {
@@ -287,6 +293,78 @@ func getUserInfo(id string, cfg *config.Config) (flw float64, url, dp, bio strin
 	return
 }
 
+type Insights struct {
+	Meta *Meta `json:"meta"`
+	Data []struct {
+		Name   string `json:"name"`
+		Values []struct {
+			Value map[string]float64 `json:"value"`
+		} `json:"values"`
+	} `json:"data"`
+}
+
+// getAudienceInsights pulls follower demographics for business accounts
+func getAudienceInsights(id string, cfg *config.Config) (*demographics.Demographics, error) {
+	endpoint := fmt.Sprintf(insightsUrl, cfg.Instagram.Endpoint, id, getToken(cfg.Instagram.AccessTokens))
+	var ins Insights
+	if err := misc.Request("GET", endpoint, "", &ins); err != nil {
+		return nil, err
+	}
+
+	if ins.Meta == nil || ins.Meta.Code != 200 {
+		return nil, ErrUnknown
+	}
+
+	d := &demographics.Demographics{
+		Gender:     make(map[string]float64),
+		Ages:       make(map[string]float64),
+		Countries:  make(map[string]float64),
+		GenderAges: make(map[string]float64),
+		Source:     demographics.SOURCE_INSIGHTS,
+		Verified:   true,
+		Updated:    int32(time.Now().Unix()),
+	}
+
+	for _, metric := range ins.Data {
+		if len(metric.Values) == 0 {
+			continue
+		}
+
+		var total float64
+		for _, count := range metric.Values[0].Value {
+			total += count
+		}
+
+		if total == 0 {
+			continue
+		}
+
+		for key, count := range metric.Values[0].Value {
+			share := count / total
+			switch metric.Name {
+			case "audience_gender_age":
+				// Keyed off of "F.18-24".. unknown genders are dropped
+				key = strings.ToLower(key)
+				parts := strings.SplitN(key, ".", 2)
+				if len(parts) != 2 || (parts[0] != demographics.FEMALE && parts[0] != demographics.MALE) {
+					continue
+				}
+				d.GenderAges[key] += share
+				d.Gender[parts[0]] += share
+				d.Ages[parts[1]] += share
+			case "audience_country":
+				d.Countries[strings.ToLower(key)] += share
+			}
+		}
+	}
+
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
 type PostById struct {
 	Meta *Meta     `json:"meta"`
 	Data *PostData `json:"data"`
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/swayops/sway/config"
+	"github.com/swayops/sway/internal/demographics"
 	"github.com/swayops/sway/internal/geo"
 )
 
@@ -37,6 +38,8 @@ type Instagram struct {
 
 	ProfilePicture string `json:"profile_picture,omitempty"`
 	IsBusiness     bool   `json:"isBusiness,omitempty"`
+
+	Audience *demographics.Demographics `json:"audience,omitempty"`
 }
 
 func New(name string, cfg *config.Config) (*Instagram, error) {
@@ -83,6 +86,13 @@ func (in *Instagram) UpdateData(cfg *config.Config, savePosts bool) error {
 		return err
 	}
 
+	if in.IsBusiness {
+		// Insights aren't critical so keep whatever we had on failure
+		if audience, err := getAudienceInsights(in.UserId, cfg); err == nil {
+			in.Audience = audience
+		}
+	}
+
 	if pInfo, err := getPostInfo(in.UserId, cfg); err == nil {
 		in.AvgLikes = pInfo.Likes
 		in.AvgComments = pInfo.Comments
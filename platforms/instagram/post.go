@@ -38,6 +38,9 @@ type Post struct {
 	// Type
 	Type string `json:"type,omitempty"` // "photo" or "video"
 
+	// Tagged with Instagram's "Paid partnership" label
+	PaidPartnership bool `json:"paidPartnership,omitempty"`
+
 	LastUpdated int32 `json:"lastUpdated,omitempty"`
 }
 
@@ -9,6 +9,7 @@ import (
 	"github.com/mrjones/oauth"
 
 	"github.com/swayops/sway/config"
+	"github.com/swayops/sway/internal/demographics"
 	"github.com/swayops/sway/internal/geo"
 	"github.com/swayops/sway/misc"
 )
@@ -45,6 +46,8 @@ type Twitter struct {
 
 	ProfilePicture string `json:"profile_picture,omitempty"`
 	FullName       string `json:"full_name,omitempty"`
+
+	Audience *demographics.Demographics `json:"audience,omitempty"`
 }
 
 func New(id string, cfg *config.Config) (tw *Twitter, err error) {
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/swayops/sway/config"
+	"github.com/swayops/sway/internal/demographics"
 )
 
 type YouTube struct {
@@ -25,6 +26,8 @@ type YouTube struct {
 	Images []string `json:"images,omitempty"` // List of extracted image urls from last UpdateData run
 
 	ProfilePicture string `json:"profile_picture,omitempty"`
+
+	Audience *demographics.Demographics `json:"audience,omitempty"`
 }
 
 var (
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/swayops/sway/internal/budget"
+	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/internal/influencer"
 	"github.com/swayops/sway/misc"
 	"github.com/swayops/sway/platforms/facebook"
 	"github.com/swayops/sway/platforms/instagram"
@@ -55,19 +57,23 @@ func newSwayEngine(srv *Server) error {
 
 	// Keep a live struct for all influencers in the platform
 	srv.auth.Influencers.Set(getAllInfluencers(srv))
+	indexInfluencers(srv)
 	infTicker := time.NewTicker(5 * time.Minute)
 	go func() {
 		for range infTicker.C {
 			srv.auth.Influencers.Set(getAllInfluencers(srv))
+			indexInfluencers(srv)
 		}
 	}()
 
 	// Keep a live struct for all scraps in the platform
 	srv.Scraps.Set(srv.db, srv.Cfg, getAllScraps(srv))
+	indexScraps(srv)
 	scrapsTicker := time.NewTicker(1 * time.Hour)
 	go func() {
 		for range scrapsTicker.C {
 			srv.Scraps.Set(srv.db, srv.Cfg, getAllScraps(srv))
+			indexScraps(srv)
 		}
 	}()
 
@@ -139,6 +145,56 @@ func newSwayEngine(srv *Server) error {
 		}
 	}()
 
+	// Email unread deal messages every hour
+	msgTicker := time.NewTicker(1 * time.Hour)
+	go func() {
+		for range msgTicker.C {
+			if _, err := emailUnreadMessages(srv); err != nil {
+				srv.Alert("Err emailing unread messages", err)
+			}
+		}
+	}()
+
+	// Remind advertisers about expiring usage rights once a day
+	rightsTicker := time.NewTicker(24 * time.Hour)
+	go func() {
+		for range rightsTicker.C {
+			if _, err := emailExpiringRights(srv); err != nil {
+				srv.Alert("Err emailing expiring usage rights", err)
+			}
+		}
+	}()
+
+	// Pick up carrier updates for perks in transit
+	shipTicker := time.NewTicker(2 * time.Hour)
+	go func() {
+		for range shipTicker.C {
+			if _, err := pollShipments(srv); err != nil {
+				srv.Alert("Err polling perk shipments", err)
+			}
+		}
+	}()
+
+	// Keep coupon counts in line with codes that expired
+	couponTicker := time.NewTicker(1 * time.Hour)
+	go func() {
+		for range couponTicker.C {
+			if _, err := syncCoupons(srv); err != nil {
+				srv.Alert("Err syncing coupon pools", err)
+			}
+		}
+	}()
+
+	// Save auction results in one write rather than on every accept
+	auctionTicker := time.NewTicker(5 * time.Minute)
+	go func() {
+		for range auctionTicker.C {
+			if err := flushAuctions(srv); err != nil {
+				srv.Alert("Err flushing auction results", err)
+			}
+		}
+	}()
+
 	billingTicker := time.NewTicker(24 * time.Hour)
 	go func() {
 		if err := srv.billing(); err != nil {
@@ -368,7 +424,7 @@ func depleteBudget(s *Server) ([]*Depleted, error) {
 			// THIS IS WHAT WE'LL USE FOR BILLING!
 			for _, cDeal := range inf.CompletedDeals {
 				if cDeal.Id == deal.Id {
-					if !cDeal.Paid {
+					if !cDeal.Paid && !cDeal.Pricing.IsPerformance() {
 						// If we haven't paid for it yet.. pay for it!
 						if deal.MaxYield == 0 {
 							s.Notify("No max yield for influencer: "+inf.Id, "Get it checked")
@@ -420,6 +476,18 @@ func depleteBudget(s *Server) ([]*Depleted, error) {
 					// Increment stats for this deal
 					cDeal.IncrementStats()
 					cDeal.ApproveAllClicks()
+
+					if !cDeal.Paid && cDeal.Pricing.IsPerformance() {
+						// Click and conversion based deals accrue spend
+						// until their attribution window closes
+						if spent := payPerformance(s, cDeal, &inf, store, dspFee, exchangeFee); spent > 0 {
+							depletions = append(depletions, &Depleted{
+								Influencer: fmt.Sprintf("%s (%s)", deal.InfluencerName, deal.InfluencerId),
+								Campaign:   fmt.Sprintf("%s (%s)", deal.CampaignName, deal.CampaignId),
+								PostURL:    deal.PostUrl,
+								Spent:      misc.TruncateFloat(spent, 2)})
+						}
+					}
 				}
 
 				// Save the deal in influencers and campaigns
@@ -440,6 +508,60 @@ func depleteBudget(s *Server) ([]*Depleted, error) {
 	return depletions, nil
 }
 
+func payPerformance(s *Server, deal *common.Deal, inf *influencer.Influencer, store *budget.Store, dspFee, exchangeFee float64) float64 {
+	// Pays for any new clicks/conversions within the deal's attribution
+	// window.. capped by what's left in the campaign's store
+	var (
+		spent float64
+		units = deal.GetBillableUnits()
+	)
+
+	if newUnits := units - deal.BilledUnits; newUnits > 0 {
+		rate := deal.Pricing.Rate * (1 + deal.Rights.GetUplift())
+		if rate > 0 && float64(newUnits)*rate > store.Spendable {
+			// Only bill the units we can pay for.. the rest are picked
+			// up once there's budget again
+			newUnits = int32(store.Spendable / rate)
+		}
+		spent = float64(newUnits) * rate
+		deal.BilledUnits += newUnits
+	}
+
+	if deal.IsWindowClosed() && deal.BilledUnits >= units {
+		deal.Paid = true
+	}
+
+	if spent <= 0 {
+		return 0
+	}
+
+	dspMarkup, exchangeMarkup, agencyPayout, infPayout := budget.GetMargins(spent, dspFee, exchangeFee, s.getTalentAgencyFee(inf.AgencyId))
+	inf.PendingPayout += infPayout
+	deal.Pay(infPayout, agencyPayout, dspMarkup, exchangeMarkup, inf.AgencyId)
+	budget.DeductSpendable(store, spent)
+
+	if err := s.Cfg.Loggers.Log("stats", map[string]interface{}{
+		"action":     "deplete",
+		"model":      deal.Pricing.GetModel(),
+		"units":      units,
+		"infId":      deal.InfluencerId,
+		"dealId":     deal.Id,
+		"campaignId": deal.CampaignId,
+		"agencyId":   inf.AgencyId,
+		"payouts": map[string]float64{
+			"inf":      infPayout,
+			"agency":   agencyPayout,
+			"dsp":      dspMarkup,
+			"exchange": exchangeMarkup,
+		},
+		"store": store,
+	}); err != nil {
+		log.Println("Failed to log performance deal!", deal.InfluencerId, deal.CampaignId)
+	}
+
+	return spent
+}
+
 // func auditTaxes(srv *Server) (int32, error) {
 // 	var (
 // 		sigsFound int32
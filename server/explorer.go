@@ -10,25 +10,22 @@ import (
 
 	"github.com/boltdb/bolt"
 	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/internal/disclosure"
 	"github.com/swayops/sway/internal/influencer"
 	"github.com/swayops/sway/internal/subscriptions"
 	"github.com/swayops/sway/internal/templates"
 	"github.com/swayops/sway/misc"
 	"github.com/swayops/sway/platforms"
 	"github.com/swayops/sway/platforms/facebook"
+	"github.com/swayops/sway/platforms/imagga"
 	"github.com/swayops/sway/platforms/instagram"
 	"github.com/swayops/sway/platforms/twitter"
 	"github.com/swayops/sway/platforms/youtube"
 )
 
-var (
-	requiredHash = []string{"ad", "promotion", "sponsored", "sponsoredPost", "paidPost", "endorsement", "endorsed", "advertisement", "ads"}
-)
-
 const (
-	timeoutSeconds = int32(60*60*24) * influencer.TimeoutDays
-	waitingPeriod  = int32(16) // Wait 16 hours before we accept a deal
-	minRatio       = 0.04      // Minimum comments to like ratio as a percentage
+	waitingPeriod = int32(16) // Wait 16 hours before we accept a deal
+	minRatio      = 0.04      // Minimum comments to like ratio as a percentage
 )
 
 func explore(srv *Server) (int32, error) {
@@ -98,7 +95,13 @@ func explore(srv *Server) (int32, error) {
 		}
 
 		if cmp.RequiresSubmission && !deal.IsSubmitted() {
-			// Advertiser requires submission and post is not submitted
+			// Advertiser requires submission and post is not submitted..
+			// unless the advertiser sat on it past the review SLA
+			if deal.Submission != nil && deal.Submission.IsReviewOverdue(cmp.ReviewDays) {
+				if err := autoApproveSubmission(srv, &inf, deal.Id); err != nil {
+					srv.Alert("Failed to auto approve submission for "+inf.Id, err)
+				}
+			}
 			continue
 		}
 
@@ -119,7 +122,10 @@ func explore(srv *Server) (int32, error) {
 			continue
 		}
 
-		targetLink := trimURLPrefix(deal.ShortenedLink)
+		var targetLink string
+		if deal.RequiresLink() {
+			targetLink = trimURLPrefix(deal.ShortenedLink)
+		}
 		for _, mediaPlatform := range deal.Platforms {
 			if foundPost {
 				break
@@ -192,39 +198,47 @@ func explore(srv *Server) (int32, error) {
 		// If the deal has not been approved and it has gone past the
 		// dealTimeout.. put it back in the pool!
 
-		// Lets exclude timeouts for signal for now
-		if deal.Completed == 0 && !deal.PickedUp {
-			// If the perk was sent 7 days ago now.. lets check in with the influencer
-			// NOTE: logic here is such that we would only do this once (since we're checking to see if
-			// Assigned TS fell within engine run time)
+		// Lets exclude timeouts for signal for now.. and perks that
+		// haven't arrived yet since the clock starts at delivery
+		if deal.Completed == 0 && !deal.PickedUp && !deal.Perk.IsInTransit() {
+			// Check in with the influencer on the campaign's reminder cadence
+			// (counting from when the perk was delivered or sent if there is one)
 			var alertTS int32
-			if deal.Perk != nil && deal.Perk.Status {
+			if deal.Perk != nil && deal.Perk.Shipment.IsDelivered() {
+				alertTS = deal.Perk.Shipment.Delivered
+			} else if deal.Perk != nil && deal.Perk.Status {
 				// There was a perk and it's been sent! Lets get its TS
 				alertTS = deal.GetPerkTS()
 			} else {
 				alertTS = deal.Assigned
 			}
 
-			if alertTS > 0 {
-				// If the alert cutoff TS was between 7 days ago and 7 days - engine run time ago.. email!
-				if (misc.WithinLast(alertTS, 24*7) && !misc.WithinLast(alertTS, (24*7)-EngineRunTime)) || alertTS == 1507075200 {
+			var (
+				timeout     = deal.GetTimeout()
+				headsUpHrs  = int32(cmp.Schedule.GetHeadsUpDays() * 24)
+				graceCutoff = timeout + int32(cmp.Schedule.GetGraceDays()*60*60*24)
+				now         = int32(time.Now().Unix())
+			)
+
+			if alertTS > 0 && timeout-now > headsUpHrs*60*60 {
+				// Reminders stop once we're in the heads up window
+				if due := cmp.Schedule.RemindersDue(alertTS); due > deal.Reminders || (alertTS == 1507075200 && !deal.PostAlerted) {
 					// Lets check in with the influencer to see when they plan on making the post
-					if err := postAlert(deal, inf, srv); err != nil {
+					if err := postAlert(deal, inf, srv, due); err != nil {
 						srv.Alert(fmt.Sprintf("Error emailing deal post alert to %s for deal %s", inf.Id, deal.Id), err)
 					}
 				}
 			}
 
-			// If the timeout cutoff TS (where we clear the deal) is in the next 7 days and 7 days + EngineRunTime.. email!
-			if misc.WithinHours(deal.Assigned+timeoutSeconds, 24*7, (24*7)+EngineRunTime) {
-				// Lets warn the influencer that they have 7 days left!
-				// NOTE.. the engine run time offset is so that it only runs once per engine
-				// run
+			// If the timeout cutoff TS (where we clear the deal) is within the heads up window.. email!
+			if misc.WithinHours(timeout, 0, headsUpHrs) {
+				// Lets warn the influencer that their time is almost up!
+				// NOTE: HeadsUpAlert is reset when an extension is approved
 				if err := headsupAlert(deal, inf, srv); err != nil {
 					srv.Alert(fmt.Sprintf("Error emailing deal heads up to %s for deal %s", inf.Id, deal.Id), err)
 				}
-			} else if !misc.WithinLast(deal.Assigned, influencer.TimeoutDays*24) {
-				// If the assigned date is OLDER than the last X days.. clear it!
+			} else if now > graceCutoff {
+				// If the deal is past its timeout and grace period.. clear it!
 
 				// Temporary disable clearing deals
 				srv.Notify("Deal will be cleared!", "CHECK IT OUT: Trying to clear deal for "+deal.InfluencerId)
@@ -372,26 +386,113 @@ func (srv *Server) ApproveYouTube(post *youtube.Post, d *common.Deal) error {
 	return srv.CompleteDeal(d, post.Published)
 }
 
-func hasReqHash(text string, hashtags []string) bool {
-	for _, tg := range hashtags {
-		for _, reqHash := range requiredHash {
-			if strings.EqualFold(tg, reqHash) {
-				return true
-			}
+func autoApproveSubmission(srv *Server, inf *influencer.Influencer, dealId string) error {
+	var found *common.Deal
+	for _, deal := range inf.ActiveDeals {
+		if deal.Id == dealId {
+			found = deal
+			break
 		}
 	}
 
-	if len(text) > 0 {
-		for _, reqHash := range requiredHash {
-			if containsFold(text, reqHash) {
-				return true
-			}
-		}
+	if found == nil || found.Submission == nil {
+		return errors.New("Deal and submission not found")
+	}
+
+	if err := found.Review(common.SUBMISSION_APPROVED, "", ""); err != nil {
+		return err
+	}
+	found.Submission.AutoApproved = true
+
+	if err := saveAllActiveDeals(srv, *inf); err != nil {
+		return err
+	}
+
+	if err := inf.SubmissionApproved(found, srv.Cfg); err != nil {
+		srv.Alert("Submission approved email errored out", err)
+	}
+
+	if user := srv.auth.GetUser(found.AdvertiserId); user != nil && user.Advertiser != nil && !srv.Cfg.Sandbox {
+		email := templates.NotifyEmail.Render(map[string]interface{}{"msg": fmt.Sprintf("The submission by %s for %s was not reviewed in time and has been automatically approved.", found.InfluencerName, found.CampaignName)})
+		emailAdvertiser(srv, user, email, "A submission by "+found.InfluencerName+" has been auto approved")
+	}
+
+	srv.Notify("Submission auto approved!", fmt.Sprintf("Submission by %s for %s was auto approved", inf.Id, found.CampaignId))
+	return nil
+}
+
+// getMarket returns the country whose disclosure rules the deal's post
+// is held to.. the campaign's market or where the influencer is
+func getMarket(srv *Server, inf influencer.Influencer, deal *common.Deal) string {
+	if cmp, ok := srv.Campaigns.Get(deal.CampaignId); ok && cmp.Market != "" {
+		return cmp.Market
+	}
+
+	if g := inf.GetLatestGeo(); g != nil {
+		return g.Country
+	}
+	return ""
+}
+
+// checkDisclosure checks the post's sponsorship disclosure and lets the
+// influencer know what's wrong with it if it fails
+func checkDisclosure(srv *Server, inf influencer.Influencer, deal *common.Deal, post *disclosure.Post, postURL string) bool {
+	res := disclosure.Check(getMarket(srv, inf, deal), post)
+	res.PostURL = postURL
+	deal.Disclosure = res
+
+	if res.Pass {
+		return true
 	}
 
+	if err := disclosureIssue(deal, inf, srv, res); err != nil {
+		log.Println("Error emailing rejection reason to influencer", err)
+	}
 	return false
 }
 
+// checkProduct looks for the campaign's product in the post's photo and
+// records the result on the deal. Returns nil if the campaign doesn't ask
+// for it or the photo couldn't be tagged. Checks are saved so the same
+// photo isn't tagged every run
+func checkProduct(srv *Server, inf influencer.Influencer, deal *common.Deal, image string) *common.ProductCheck {
+	cmp, ok := srv.Campaigns.Get(deal.CampaignId)
+	if !ok || !cmp.ChecksProduct() {
+		return nil
+	}
+
+	if pc := deal.ProductCheck; pc != nil && pc.Image == image && pc.MinConfidence == cmp.GetVisualConfidence() &&
+		strings.Join(pc.Expected, ",") == strings.Join(cmp.VisualTags, ",") {
+		return pc
+	}
+
+	tags, err := imagga.GetTags(image, srv.Cfg.Sandbox)
+	if err != nil {
+		// Try again next run
+		log.Println("Error tagging post image", image, err)
+		return nil
+	}
+
+	pc := common.CheckProduct(image, cmp.VisualTags, cmp.GetVisualConfidence(), tags)
+	if pc == nil {
+		return nil
+	}
+
+	deal.ProductCheck = pc
+
+	for _, infDeal := range inf.ActiveDeals {
+		if deal.Id == infDeal.Id {
+			infDeal.ProductCheck = pc
+			break
+		}
+	}
+
+	if err := saveAllActiveDeals(srv, inf); err != nil {
+		log.Println("Error saving product check", inf.Id, err)
+	}
+	return pc
+}
+
 func findTwitterMatch(srv *Server, inf influencer.Influencer, deal *common.Deal, link string) *twitter.Tweet {
 	if inf.Twitter == nil {
 		return nil
@@ -462,11 +563,12 @@ func findTwitterMatch(srv *Server, inf influencer.Influencer, deal *common.Deal,
 		}
 
 		if foundHash && foundMention && foundLink {
-			// check for required hashes!
-			if !hasReqHash(tw.Text, postTags) {
-				if err := inf.DealRejection("hashtags (#ad)", tw.PostURL, deal, srv.Cfg); err != nil {
-					log.Println("Error emailing rejection reason to influencer", err)
-				}
+			// Check the sponsorship disclosure against the market's rules
+			if !checkDisclosure(srv, inf, deal, &disclosure.Post{
+				Platform: platform.Twitter,
+				Text:     tw.Text,
+				Hashtags: postTags,
+			}, tw.PostURL) {
 				continue
 			}
 
@@ -474,6 +576,10 @@ func findTwitterMatch(srv *Server, inf influencer.Influencer, deal *common.Deal,
 				continue
 			}
 
+			if !deal.MatchesLanguage(tw.Text) {
+				continue
+			}
+
 			if !deal.SkipFraud {
 				// If we're not skipping fraud yet we need to wait for X hours
 				// before picking up the deal so we can do fraud engagement checks
@@ -607,10 +713,12 @@ func findFacebookMatch(srv *Server, inf influencer.Influencer, deal *common.Deal
 		}
 
 		if foundHash && foundMention && foundLink {
-			if !hasReqHash(post.Caption, postTags) {
-				if err := inf.DealRejection("hashtags (#ad)", post.PostURL, deal, srv.Cfg); err != nil {
-					log.Println("Error emailing rejection reason to influencer", err)
-				}
+			// Check the sponsorship disclosure against the market's rules
+			if !checkDisclosure(srv, inf, deal, &disclosure.Post{
+				Platform: platform.Facebook,
+				Text:     post.Caption,
+				Hashtags: postTags,
+			}, post.PostURL) {
 				continue
 			}
 
@@ -618,6 +726,13 @@ func findFacebookMatch(srv *Server, inf influencer.Influencer, deal *common.Deal
 				continue
 			}
 
+			if !deal.MatchesLanguage(post.Caption) {
+				continue
+			}
+
+			// Is the product actually in the photo?
+			product := checkProduct(srv, inf, deal, post.Picture)
+
 			if !deal.SkipFraud {
 				if misc.WithinLast(int32(post.Published.Unix()), waitingPeriod) {
 					if err := pickupDeal(deal, inf, srv); err != nil {
@@ -626,8 +741,15 @@ func findFacebookMatch(srv *Server, inf influencer.Influencer, deal *common.Deal
 					return nil
 				}
 
-				// Lets ALWAYS ask for approval!
+				// Lets ALWAYS ask for approval.. unless we can see the product
 				fraud := []string{"Standard approval"}
+				if product != nil {
+					if product.Pass {
+						fraud = nil
+					} else {
+						fraud = append(fraud, product.Reason)
+					}
+				}
 
 				// Does it have any fraud hashtags?
 				for _, tg := range hashBlacklist {
@@ -754,10 +876,13 @@ func findInstagramMatch(srv *Server, inf influencer.Influencer, deal *common.Dea
 		}
 
 		if foundHash && foundMention && foundLink {
-			if !hasReqHash(post.Caption, post.Hashtags) {
-				if err := inf.DealRejection("hashtags (#ad)", post.PostURL, deal, srv.Cfg); err != nil {
-					log.Println("Error emailing rejection reason to influencer", err)
-				}
+			// Check the sponsorship disclosure against the market's rules
+			if !checkDisclosure(srv, inf, deal, &disclosure.Post{
+				Platform: platform.Instagram,
+				Text:     post.Caption,
+				Hashtags: post.Hashtags,
+				Native:   post.PaidPartnership,
+			}, post.PostURL) {
 				continue
 			}
 
@@ -766,6 +891,14 @@ func findInstagramMatch(srv *Server, inf influencer.Influencer, deal *common.Dea
 				continue
 			}
 
+			if !deal.MatchesLanguage(post.Caption) {
+				rejections[post.Caption] = "LANGUAGE"
+				continue
+			}
+
+			// Is the product actually in the photo?
+			product := checkProduct(srv, inf, deal, post.Thumbnail)
+
 			if !deal.SkipFraud {
 				if misc.WithinLast(int32(post.Published), waitingPeriod) {
 					rejections[post.Caption] = "WAITING_PERIOD"
@@ -775,8 +908,15 @@ func findInstagramMatch(srv *Server, inf influencer.Influencer, deal *common.Dea
 					return nil
 				}
 
-				// Lets ALWAYS ask for approval!
+				// Lets ALWAYS ask for approval.. unless we can see the product
 				fraud := []string{"Standard approval"}
+				if product != nil {
+					if product.Pass {
+						fraud = nil
+					} else {
+						fraud = append(fraud, product.Reason)
+					}
+				}
 
 				// Does it have any fraud hashtags?
 				for _, tg := range hashBlacklist {
@@ -905,10 +1045,12 @@ func findYouTubeMatch(srv *Server, inf influencer.Influencer, deal *common.Deal,
 		}
 
 		if foundHash && foundMention && foundLink {
-			if !hasReqHash(post.Description, postTags) {
-				if err := inf.DealRejection("hashtags (#ad)", post.PostURL, deal, srv.Cfg); err != nil {
-					log.Println("Error emailing rejection reason to influencer", err)
-				}
+			// Check the sponsorship disclosure against the market's rules
+			if !checkDisclosure(srv, inf, deal, &disclosure.Post{
+				Platform: platform.YouTube,
+				Text:     post.Description,
+				Hashtags: postTags,
+			}, post.PostURL) {
 				continue
 			}
 
@@ -916,6 +1058,10 @@ func findYouTubeMatch(srv *Server, inf influencer.Influencer, deal *common.Deal,
 				continue
 			}
 
+			if !deal.MatchesLanguage(post.Title + " " + post.Description) {
+				continue
+			}
+
 			if !deal.SkipFraud {
 				if misc.WithinLast(post.Published, waitingPeriod) {
 					if err := pickupDeal(deal, inf, srv); err != nil {
@@ -1019,8 +1165,8 @@ func pickupDeal(deal *common.Deal, inf influencer.Influencer, srv *Server) error
 	return saveAllActiveDeals(srv, inf)
 }
 
-func postAlert(deal *common.Deal, inf influencer.Influencer, srv *Server) error {
-	if deal.PostAlerted {
+func postAlert(deal *common.Deal, inf influencer.Influencer, srv *Server, due int) error {
+	if deal.Reminders >= due && deal.PostAlerted {
 		return nil
 	}
 
@@ -1031,6 +1177,7 @@ func postAlert(deal *common.Deal, inf influencer.Influencer, srv *Server) error
 	for _, infDeal := range inf.ActiveDeals {
 		if deal.Id == infDeal.Id {
 			infDeal.PostAlerted = true
+			infDeal.Reminders = due
 			break
 		}
 	}
@@ -1071,6 +1218,25 @@ func headsupAlert(deal *common.Deal, inf influencer.Influencer, srv *Server) err
 	return saveAllActiveDeals(srv, inf)
 }
 
+func disclosureIssue(deal *common.Deal, inf influencer.Influencer, srv *Server, res *disclosure.Result) error {
+	for _, infDeal := range inf.ActiveDeals {
+		if deal.Id == infDeal.Id {
+			if old := infDeal.Disclosure; old != nil && old.PostURL == res.PostURL && old.Reason() == res.Reason() {
+				// Already let them know about this post
+				return nil
+			}
+			infDeal.Disclosure = res
+			break
+		}
+	}
+
+	if err := inf.DealRejection("sponsorship disclosure", res.PostURL, deal, srv.Cfg, res.Reasons...); err != nil {
+		return err
+	}
+
+	return saveAllActiveDeals(srv, inf)
+}
+
 func postIssue(deal *common.Deal, inf influencer.Influencer, srv *Server, postURL, reason string) error {
 	if deal.NotifiedRejection {
 		return nil
@@ -13,8 +13,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/swayops/sway/internal/budget"
 	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/internal/demographics"
 	"github.com/swayops/sway/internal/geo"
 	"github.com/swayops/sway/internal/influencer"
+	"github.com/swayops/sway/internal/language"
+	"github.com/swayops/sway/internal/targeting"
 	"github.com/swayops/sway/internal/templates"
 	"github.com/swayops/sway/misc"
 	"github.com/swayops/sway/platforms/pdf"
@@ -214,62 +217,23 @@ func getForecastForCmp(s *Server, cmp common.Campaign, sortBy, incomingToken, au
 			continue
 		}
 
-		if len(cmp.Categories) > 0 || len(cmp.Audiences) > 0 || len(cmp.Keywords) > 0 {
-			catFound := false
-		L1:
-			for _, cat := range cmp.Categories {
-				for _, infCat := range inf.Categories {
-					if infCat == cat {
-						catFound = true
-						break L1
-					}
-				}
-			}
+		if !inf.IsCategoryMatch(&cmp, s.Audiences) {
+			continue
+		}
 
-			// Audience check!
-			if !catFound {
-				for _, targetAudience := range cmp.Audiences {
-					if s.Audiences.IsAllowed(targetAudience, inf.EmailAddress) {
-						// There was an audience target and they're in it
-						catFound = true
-						break
-					}
-				}
-			}
+		if cmp.Targeting != "" && !targeting.Match(cmp.Targeting, inf.GetSubject(s.Audiences, nil)) {
+			continue
+		}
 
-			if !catFound {
-				for _, kw := range cmp.Keywords {
-					if catFound {
-						break
-					}
-
-					for _, infKw := range inf.Keywords {
-						if common.IsExactMatch(kw, infKw) {
-							catFound = true
-							break
-						}
-					}
-
-					if inf.Instagram != nil && inf.Instagram.Bio != "" {
-						if common.IsExactMatch(inf.Instagram.Bio, kw) {
-							catFound = true
-							break
-						}
-					}
-
-					if inf.IsSearchInUsername(kw) {
-						catFound = true
-						break
-					}
-				}
-			}
+		if !geo.IsGeoMatch(cmp.Geos, inf.GetLatestGeo()) {
+			continue
+		}
 
-			if !catFound {
-				continue
-			}
+		if !demographics.IsMatch(cmp.AudienceTargets, inf.GetDemographics()) {
+			continue
 		}
 
-		if !geo.IsGeoMatch(cmp.Geos, inf.GetLatestGeo()) {
+		if len(cmp.Languages) > 0 && !language.Speaks(inf.Languages, cmp.Languages) {
 			continue
 		}
 
@@ -790,6 +754,12 @@ func getForecast(s *Server, requireKey bool) gin.HandlerFunc {
 			return
 		}
 
+		// Catch bad expressions now rather than forecasting nobody
+		if cmp.Targeting, err = sanitizeTargeting(cmp.Targeting); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
 		start := int64(-1)
 		results := int64(-1)
 		if st := c.Query("start"); st != "" {
@@ -803,10 +773,10 @@ func getForecast(s *Server, requireKey bool) gin.HandlerFunc {
 		influencers, total, reach, token := getForecastForCmp(s, cmp, c.Query("sortBy"), c.Query("token"), c.Query("audienceID"), int(start), int(results))
 		if start != -1 && results != -1 { // keep the old behaviour
 			influencers = filterForecast(influencers, int(results))
-			misc.WriteJSON(c, 200, gin.H{"influencers": total, "reach": reach, "breakdown": influencers, "token": token})
+			misc.WriteJSON(c, 200, gin.H{"influencers": total, "reach": reach, "breakdown": influencers, "token": token, "targeting": cmp.Targeting})
 		} else {
 			// Default to totals
-			misc.WriteJSON(c, 200, gin.H{"influencers": total, "reach": reach, "token": token})
+			misc.WriteJSON(c, 200, gin.H{"influencers": total, "reach": reach, "token": token, "targeting": cmp.Targeting})
 		}
 	}
 }
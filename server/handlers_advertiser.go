@@ -157,82 +157,103 @@ func advertiserBan(s *Server) gin.HandlerFunc {
 func approveSubmission(s *Server) gin.HandlerFunc {
 	// Approves submission
 	return func(c *gin.Context) {
-		var (
-			advertiserId = c.Param("id")
-			campaignId   = c.Param("campaignId")
-			infId        = c.Param("influencerId")
-		)
+		reviewSubmissionHelper(s, c, &SubmissionReview{Status: common.SUBMISSION_APPROVED})
+	}
+}
 
-		adv := s.auth.GetAdvertiser(advertiserId)
-		if adv == nil {
-			misc.WriteJSON(c, 400, misc.StatusErr("Please provide a valid advertiser"))
-			return
-		}
+type SubmissionReview struct {
+	Status  string `json:"status"` // approved, rejected or changes
+	Comment string `json:"comment,omitempty"`
+}
 
-		if len(infId) == 0 {
-			misc.WriteJSON(c, 400, misc.StatusErr("Influencer ID undefined"))
+func reviewSubmission(s *Server) gin.HandlerFunc {
+	// Approves, rejects or requests changes for the latest submission
+	return func(c *gin.Context) {
+		var review SubmissionReview
+		defer c.Request.Body.Close()
+		if err := json.NewDecoder(c.Request.Body).Decode(&review); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr("Error unmarshalling request body"))
 			return
 		}
 
-		if len(campaignId) == 0 {
-			misc.WriteJSON(c, 400, misc.StatusErr("Campaign ID undefined"))
+		if review.Status != common.SUBMISSION_APPROVED && review.Comment == "" {
+			misc.WriteJSON(c, 400, misc.StatusErr("Please provide a comment for the influencer"))
 			return
 		}
 
-		cmp, ok := s.Campaigns.Get(campaignId)
-		if !ok {
-			misc.WriteJSON(c, 400, misc.StatusErr("Campaign not found"))
-			return
-		}
+		reviewSubmissionHelper(s, c, &review)
+	}
+}
 
-		if !cmp.RequiresSubmission {
-			misc.WriteJSON(c, 400, misc.StatusErr("Campaign does not require submission"))
-			return
-		}
+func reviewSubmissionHelper(s *Server, c *gin.Context, review *SubmissionReview) {
+	var (
+		advertiserId = c.Param("id")
+		campaignId   = c.Param("campaignId")
+		infId        = c.Param("influencerId")
+	)
+
+	adv := s.auth.GetAdvertiser(advertiserId)
+	if adv == nil {
+		misc.WriteJSON(c, 400, misc.StatusErr("Please provide a valid advertiser"))
+		return
+	}
 
-		inf, ok := s.auth.Influencers.Get(infId)
-		if !ok {
-			misc.WriteJSON(c, 500, misc.StatusErr("Internal error"))
-			return
-		}
+	if len(infId) == 0 {
+		misc.WriteJSON(c, 400, misc.StatusErr("Influencer ID undefined"))
+		return
+	}
 
-		var found *common.Deal
-		for _, deal := range inf.ActiveDeals {
-			if deal.CampaignId == campaignId {
-				found = deal
-			}
-		}
+	if len(campaignId) == 0 {
+		misc.WriteJSON(c, 400, misc.StatusErr("Campaign ID undefined"))
+		return
+	}
 
-		if found == nil || found.Submission == nil {
-			misc.WriteJSON(c, 500, misc.StatusErr("Deal and submission not found"))
-			return
-		}
+	cmp, ok := s.Campaigns.Get(campaignId)
+	if !ok {
+		misc.WriteJSON(c, 400, misc.StatusErr("Campaign not found"))
+		return
+	}
 
-		if found.Submission.Approved {
-			misc.WriteJSON(c, 500, misc.StatusErr("Submission already approved"))
-			return
-		}
+	if !cmp.RequiresSubmission {
+		misc.WriteJSON(c, 400, misc.StatusErr("Campaign does not require submission"))
+		return
+	}
 
-		found.Submission.Approved = true
+	inf, ok := s.auth.Influencers.Get(infId)
+	if !ok {
+		misc.WriteJSON(c, 500, misc.StatusErr("Internal error"))
+		return
+	}
 
-		if err := saveAllActiveDeals(s, inf); err != nil {
-			misc.WriteJSON(c, 500, misc.StatusErr(err.Error()))
-			return
+	var found *common.Deal
+	for _, deal := range inf.ActiveDeals {
+		if deal.CampaignId == campaignId {
+			found = deal
 		}
+	}
 
-		if err := inf.SubmissionApproved(found, s.Cfg); err != nil {
-			misc.WriteJSON(c, 500, misc.StatusErr(err.Error()))
-			return
-		}
+	if found == nil || found.Submission == nil {
+		misc.WriteJSON(c, 500, misc.StatusErr("Deal and submission not found"))
+		return
+	}
 
-		go func() {
-			s.Notify("Submission approved!", fmt.Sprintf("%s just approved a post for %s", found.CampaignName, inf.Name))
-			// Tell the influencer that submission has been approved
-			if err := inf.SubmissionApproved(found, s.Cfg); err != nil {
-				s.Alert("Submission approved email errored out", err)
-			}
-		}()
+	if err := found.Review(review.Status, review.Comment, adv.Name); err != nil {
+		misc.WriteJSON(c, 500, misc.StatusErr(err.Error()))
+		return
+	}
 
-		misc.WriteJSON(c, 200, misc.StatusOK(campaignId))
+	if err := saveAllActiveDeals(s, inf); err != nil {
+		misc.WriteJSON(c, 500, misc.StatusErr(err.Error()))
+		return
 	}
+
+	go func() {
+		s.Notify("Submission reviewed!", fmt.Sprintf("%s just marked a post by %s as %s", found.CampaignName, inf.Name, review.Status))
+		// Tell the influencer what the advertiser decided
+		if err := submissionReviewEmail(s, &inf, found); err != nil {
+			s.Alert("Submission review email errored out", err)
+		}
+	}()
+
+	misc.WriteJSON(c, 200, misc.StatusOK(campaignId))
 }
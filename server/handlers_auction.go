@@ -0,0 +1,76 @@
+package server
+
+import (
+	"encoding/json"
+
+	"github.com/boltdb/bolt"
+	"github.com/gin-gonic/gin"
+	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/internal/influencer"
+	"github.com/swayops/sway/misc"
+)
+
+func getWinRate(s *Server) gin.HandlerFunc {
+	// Shows the campaign how often influencers pick it over the
+	// other campaigns they were offered, broken down by segment
+	return func(c *gin.Context) {
+		var report *common.AuctionReport
+		s.db.View(func(tx *bolt.Tx) error {
+			report = common.GetAuctionReportTx(tx, c.Param("cid"), s.Cfg)
+			return nil
+		})
+
+		// Include auctions the engine hasn't flushed yet
+		report.Merge(s.Auctions.Get(c.Param("cid")))
+		report.SetWinRates()
+		misc.WriteJSON(c, 200, report)
+	}
+}
+
+func recordAuction(s *Server, auction []*common.Deal, winner string, inf *influencer.Influencer) {
+	// Every campaign the influencer was offered gets an offer and the
+	// one they accepted gets a win.. saved by flushAuctions
+	band := "followers:" + common.GetFollowerBand(inf.GetFollowers())
+	for _, deal := range auction {
+		segments := []string{band}
+		for _, pl := range deal.Platforms {
+			segments = append(segments, "platform:"+pl)
+		}
+
+		for _, cat := range inf.Categories {
+			segments = append(segments, "category:"+cat)
+		}
+
+		s.Auctions.Add(deal.CampaignId, segments, deal.CampaignId == winner)
+	}
+}
+
+func flushAuctions(s *Server) error {
+	// Saves all pending auction results in one transaction
+	pending := s.Auctions.Flush()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		for cid, delta := range pending {
+			report := common.GetAuctionReportTx(tx, cid, s.Cfg)
+			report.Merge(delta)
+
+			b, err := json.Marshal(report)
+			if err != nil {
+				return err
+			}
+
+			if err := misc.PutBucketBytes(tx, s.Cfg.Bucket.Auction, cid, b); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		s.Auctions.Restore(pending)
+		return err
+	}
+
+	return nil
+}
@@ -38,6 +38,25 @@ func getBudgetInfo(s *Server) gin.HandlerFunc {
 	}
 }
 
+func getPacing(s *Server) gin.HandlerFunc {
+	// Planned versus actual spend for the campaign's current billing cycle
+	return func(c *gin.Context) {
+		cmp := common.GetCampaign(c.Param("cid"), s.db, s.Cfg)
+		if cmp == nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(ErrCampaign.Error()))
+			return
+		}
+
+		budgetStore, err := budget.GetCampaignStoreFromDb(s.db, s.Cfg, cmp.Id, cmp.AdvertiserId)
+		if err != nil || budgetStore == nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(budget.ErrNotFound.Error()))
+			return
+		}
+
+		misc.WriteJSON(c, 200, budgetStore.GetPace(cmp, true))
+	}
+}
+
 func getStore(s *Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var (
@@ -254,7 +273,8 @@ func getTargetYield(s *Server) gin.HandlerFunc {
 			return
 		}
 
-		min, max := cmp.GetTargetYield(store.Spendable)
+		// Same range GetAvailableDeals offers at with pacing applied
+		min, max := store.GetPace(cmp, false).Loosen(cmp.GetTargetYield(store.Spendable))
 		misc.WriteJSON(c, 200, &TargetYield{Min: min, Max: max})
 	}
 }
@@ -17,7 +17,9 @@ import (
 	"github.com/swayops/sway/internal/auth"
 	"github.com/swayops/sway/internal/budget"
 	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/internal/demographics"
 	"github.com/swayops/sway/internal/geo"
+	"github.com/swayops/sway/internal/influencer"
 	"github.com/swayops/sway/internal/reporting"
 	"github.com/swayops/sway/internal/subscriptions"
 	"github.com/swayops/sway/internal/templates"
@@ -60,6 +62,7 @@ func postCampaign(s *Server) gin.HandlerFunc {
 		var (
 			cuser = auth.GetCtxUser(c)
 			cmp   common.Campaign
+			pool  *common.CouponPool
 			err   error
 		)
 
@@ -101,7 +104,7 @@ func postCampaign(s *Server) gin.HandlerFunc {
 			return
 		}
 
-		if len(cmp.Tags) == 0 && cmp.Mention == "" {
+		if len(cmp.Tags) == 0 && cmp.Mention == "" && len(cmp.Variants) == 0 {
 			misc.WriteJSON(c, 400, misc.StatusErr("Please provide a required hashtag or mention"))
 			return
 		}
@@ -113,12 +116,71 @@ func postCampaign(s *Server) gin.HandlerFunc {
 			}
 		}
 
+		for _, t := range cmp.AudienceTargets {
+			if err := t.Validate(); err != nil {
+				misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+				return
+			}
+		}
+
+		if cmp.Languages, err = sanitizeLanguages(cmp.Languages); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
+		if cmp.ExcludeRisks, err = sanitizeRisks(cmp.ExcludeRisks); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
+		cmp.Market = strings.ToLower(strings.TrimSpace(cmp.Market))
+
+		if cmp.VisualTags, err = sanitizeVisualTags(cmp.VisualTags, cmp.VisualConfidence); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
+		if cmp.Targeting, err = sanitizeTargeting(cmp.Targeting); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
 		for i, ht := range cmp.Tags {
 			cmp.Tags[i] = misc.SanitizeHash(ht)
 		}
 
 		cmp.Link = sanitizeURL(cmp.Link)
 		cmp.Mention = sanitizeMention(cmp.Mention)
+		if err := sanitizeVariants(&cmp); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
+		if err := cmp.LinkTemplate.Validate(); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
+		if err := cmp.Pricing.Validate(); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
+		if err := cmp.Bidding.Validate(); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
+		if err := cmp.Exclusivity.Validate(); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
+		if !budget.IsValidPacing(cmp.Pacing) {
+			misc.WriteJSON(c, 400, misc.StatusErr("Invalid pacing"))
+			return
+		}
+
 		cmp.Categories = common.LowerSlice(cmp.Categories)
 		cmp.Keywords = common.LowerSlice(cmp.Keywords)
 
@@ -159,6 +221,14 @@ func postCampaign(s *Server) gin.HandlerFunc {
 			return
 		}
 
+		if cmp.Perks != nil {
+			// Sets the count from the variants' stock
+			if err := cmp.Perks.ValidateVariants(); err != nil {
+				misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+				return
+			}
+		}
+
 		if cmp.Perks != nil && cmp.Perks.IsCoupon() {
 			if len(cmp.Perks.Codes) == 0 {
 				misc.WriteJSON(c, 400, misc.StatusErr("Please provide coupon codes"))
@@ -170,8 +240,11 @@ func postCampaign(s *Server) gin.HandlerFunc {
 				return
 			}
 
-			// Set count internally depending on number of coupon codes passed
-			cmp.Perks.Count = len(cmp.Perks.Codes)
+			// Set count internally depending on number of unique coupon codes
+			// passed.. codes are saved to the pool once we have an ID
+			pool = common.NewCouponPool("")
+			pool.AddCodes(cmp.Perks.Codes, 0)
+			cmp.Perks.Count = pool.Count()
 		}
 
 		// Allowing $0 budgets for product-based campaigns!
@@ -181,6 +254,11 @@ func postCampaign(s *Server) gin.HandlerFunc {
 			return
 		}
 
+		if err := cmp.Rights.Validate(cmp.Exclusivity); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
 		// Campaign always put into pending
 		cmp.Approved = 0
 		if c.Query("dbg") == "1" {
@@ -192,6 +270,10 @@ func postCampaign(s *Server) gin.HandlerFunc {
 			return
 		}
 
+		if cmp.Perks != nil {
+			cmp.Perks.RecordStock("Campaign created")
+		}
+
 		cmp.CreatedAt = time.Now().Unix()
 
 		// Before creating the campaign.. lets make sure the plan allows for it!
@@ -258,6 +340,13 @@ func postCampaign(s *Server) gin.HandlerFunc {
 					cmp.AddToTimeline(common.PERK_WAIT, false, s.Cfg)
 				}
 			}
+
+			if pool != nil {
+				pool.CampaignId = cmp.Id
+				if err = saveCouponPool(tx, &cmp, pool, s); err != nil {
+					return
+				}
+			}
 			return saveCampaign(tx, &cmp, s)
 		}); err != nil {
 			misc.AbortWithErr(c, 500, err)
@@ -290,6 +379,13 @@ func getCampaign(s *Server) gin.HandlerFunc {
 
 		// This is an edge case where we need to display perk count
 		// for the purpose of UI
+		if cmp.Perks != nil && cmp.Perks.IsCoupon() {
+			// Codes live in the campaign's pool
+			for _, cp := range common.GetCouponPool(cmp, s.db, s.Cfg).Available() {
+				cmp.Perks.Codes = append(cmp.Perks.Codes, cp.Code)
+			}
+		}
+
 		if cmp.Perks != nil && !s.Cfg.Sandbox && c.Query("dbg") != "1" {
 			for _, d := range cmp.Deals {
 				if d.Perk != nil {
@@ -297,9 +393,15 @@ func getCampaign(s *Server) gin.HandlerFunc {
 						cmp.Perks.Codes = append(cmp.Perks.Codes, d.Perk.Code)
 					}
 					cmp.Perks.Count += d.Perk.Count
+					if v := cmp.Perks.GetVariant(d.Perk.SKU); v != nil {
+						v.Count += d.Perk.Count
+					}
 				}
 			}
 			cmp.Perks.Count += cmp.Perks.PendingCount
+			for _, v := range cmp.Perks.Variants {
+				v.Count += v.PendingCount
+			}
 
 		}
 
@@ -328,12 +430,70 @@ func getRejections(s *Server) gin.HandlerFunc {
 
 		campaigns := common.NewCampaigns(nil)
 		campaigns.SetCampaign(cmp.Id, *cmp)
-		_, rejections := inf.GetAvailableDeals(campaigns, s.Audiences, s.db, "", "", nil, false, s.getTalentAgencyFee(inf.AgencyId), s.Cfg)
+		_, reasons := inf.GetAvailableDeals(campaigns, s.Audiences, s.db, "", "", nil, false, s.getTalentAgencyFee(inf.AgencyId), s.Cfg)
+
+		rejections := make(map[string]string, len(reasons))
+		for cid, r := range reasons {
+			rejections[cid] = r.String()
+		}
 
 		misc.WriteJSON(c, 200, rejections)
 	}
 }
 
+func getDiagnostics(s *Server) gin.HandlerFunc {
+	// Explains why a campaign isn't filling by running it against
+	// every influencer we have
+	return func(c *gin.Context) {
+		cmp := common.GetCampaign(c.Param("cid"), s.db, s.Cfg)
+		if cmp == nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(ErrCampaign.Error()))
+			return
+		}
+
+		// Same pool GetAvailableDeals bails out early on
+		var infs []influencer.Influencer
+		for _, inf := range s.auth.Influencers.GetAll() {
+			if !inf.IsBanned() && (inf.Audited() || s.Cfg.Sandbox) {
+				infs = append(infs, inf)
+			}
+		}
+
+		misc.WriteJSON(c, 200, influencer.Diagnose(cmp, infs, s.Campaigns, s.Audiences, s.db, s.Cfg, s.getTalentAgencyFee))
+	}
+}
+
+func getShipments(s *Server) gin.HandlerFunc {
+	// Where every perk for the campaign is at
+	return func(c *gin.Context) {
+		cmp := common.GetCampaign(c.Param("cid"), s.db, s.Cfg)
+		if cmp == nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(ErrCampaign.Error()))
+			return
+		}
+
+		if cmp.Perks == nil || cmp.Perks.IsCoupon() {
+			misc.WriteJSON(c, 400, misc.StatusErr("Campaign does not have product perks"))
+			return
+		}
+
+		misc.WriteJSON(c, 200, getShipmentBoard(cmp))
+	}
+}
+
+func getStockLedger(s *Server) gin.HandlerFunc {
+	// Every stock movement for the campaign's perks
+	return func(c *gin.Context) {
+		var ledger *common.StockLedger
+		s.db.View(func(tx *bolt.Tx) error {
+			ledger = common.GetStockLedgerTx(tx, c.Param("cid"), s.Cfg)
+			return nil
+		})
+
+		misc.WriteJSON(c, 200, ledger)
+	}
+}
+
 type Cycle struct {
 	Matched   int `json:"matched"`
 	Notified  int `json:"notified"`
@@ -561,6 +721,7 @@ type CampaignUpdate struct {
 	Status             *bool                    `json:"status,omitempty"`
 	Budget             *float64                 `json:"budget,omitempty"`
 	Monthly            *bool                    `json:"monthly,omitempty"`
+	Pacing             *string                  `json:"pacing,omitempty"`
 	TermsAndConditions *string                  `json:"terms,omitempty"`
 	Male               *bool                    `json:"male,omitempty"`
 	Female             *bool                    `json:"female,omitempty"`
@@ -570,12 +731,32 @@ type CampaignUpdate struct {
 	Task               *string                  `json:"task,omitempty"`
 	Perks              *common.Perk             `json:"perks,omitempty"` // NOTE: This struct only allows you to ADD to existing perks
 	BrandSafe          *bool                    `json:"brandSafe,omitempty"`
+	ExcludeRisks       []string                 `json:"excludeRisks,omitempty"`
 	RequiresSubmission *bool                    `json:"reqSub,omitempty"` // Does the advertiser require submission?
+	ReviewDays         *int                     `json:"reviewDays,omitempty"`
+	Schedule           *common.DealSchedule     `json:"schedule,omitempty"`
+	Rights             *common.UsageRights      `json:"rights,omitempty"`   // Only applies to deals assigned after the update
+	Variants           []*common.Variant        `json:"variants,omitempty"` // Assigned deals keep their variant's requirements
+	LinkTemplate       *common.LinkTemplate     `json:"linkTemplate,omitempty"`
 	CampaignBlacklist  map[string]bool          `json:"cmpBlacklist,omitempty"`
 
 	FollowerTarget *common.Range      `json:"followerTarget,omitempty"`
 	EngTarget      *common.Range      `json:"engTarget,omitempty"`
 	PriceTarget    *common.FloatRange `json:"priceTarget,omitempty"`
+
+	AudienceTargets []*demographics.Target `json:"audienceTargets,omitempty"`
+	Languages       []string               `json:"languages,omitempty"`
+	RequireLanguage bool                   `json:"requireLanguage,omitempty"`
+	Market          string                 `json:"market,omitempty"`
+	Targeting       string                 `json:"targeting,omitempty"`
+
+	VisualTags       []string `json:"visualTags,omitempty"`
+	VisualConfidence float64  `json:"visualConfidence,omitempty"`
+
+	// Only applies to deals assigned after the update
+	Pricing     *common.Pricing     `json:"pricing,omitempty"`
+	Bidding     *common.Bidding     `json:"bidding,omitempty"`
+	Exclusivity *common.Exclusivity `json:"exclusivity,omitempty"`
 }
 
 func putCampaign(s *Server) gin.HandlerFunc {
@@ -633,10 +814,45 @@ func putCampaign(s *Server) gin.HandlerFunc {
 			}
 		}
 
+		for _, t := range upd.AudienceTargets {
+			if err := t.Validate(); err != nil {
+				misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+				return
+			}
+		}
+
+		langs, err := sanitizeLanguages(upd.Languages)
+		if err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
+		risks, err := sanitizeRisks(upd.ExcludeRisks)
+		if err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
+		visualTags, err := sanitizeVisualTags(upd.VisualTags, upd.VisualConfidence)
+		if err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
+		expr, err := sanitizeTargeting(upd.Targeting)
+		if err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
 		if upd.Task != nil && *upd.Task != "" {
 			cmp.Task = *upd.Task
-			// Also update task in any deals
+			// Also update task in any deals (unless their variant has its own)
 			for _, d := range cmp.Deals {
+				if v := cmp.GetVariant(d.VariantId); v != nil && v.Task != "" {
+					continue
+				}
+
 				if d.IsActive() {
 					d.Task = cmp.Task
 					cmp.Deals[d.Id] = d
@@ -668,6 +884,77 @@ func putCampaign(s *Server) gin.HandlerFunc {
 			cmp.RequiresSubmission = *upd.RequiresSubmission
 		}
 
+		if upd.ReviewDays != nil {
+			cmp.ReviewDays = *upd.ReviewDays
+		}
+
+		if upd.Schedule != nil {
+			cmp.Schedule = upd.Schedule
+		}
+
+		if upd.Rights != nil {
+			cmp.Rights = upd.Rights
+		}
+
+		if upd.Variants != nil {
+			old := cmp.Variants
+			cmp.Variants = upd.Variants
+			if err := sanitizeVariants(&cmp); err != nil {
+				misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+				return
+			}
+			cmp.RetainVariants(old)
+		}
+
+		if upd.Pricing != nil {
+			if err := upd.Pricing.Validate(); err != nil {
+				misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+				return
+			}
+			cmp.Pricing = upd.Pricing
+		}
+
+		if upd.Pacing != nil {
+			if !budget.IsValidPacing(*upd.Pacing) {
+				misc.WriteJSON(c, 400, misc.StatusErr("Invalid pacing"))
+				return
+			}
+			cmp.Pacing = *upd.Pacing
+		}
+
+		if upd.Bidding != nil {
+			if err := upd.Bidding.Validate(); err != nil {
+				misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+				return
+			}
+			cmp.Bidding = upd.Bidding
+		}
+
+		if upd.Exclusivity != nil {
+			if err := upd.Exclusivity.Validate(); err != nil {
+				misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+				return
+			}
+			cmp.Exclusivity = upd.Exclusivity
+		}
+
+		if upd.Rights != nil || upd.Exclusivity != nil {
+			// Checked once both are updated since exclusive rights
+			// need the campaign's category
+			if err := cmp.Rights.Validate(cmp.Exclusivity); err != nil {
+				misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+				return
+			}
+		}
+
+		if upd.LinkTemplate != nil {
+			if err := upd.LinkTemplate.Validate(); err != nil {
+				misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+				return
+			}
+			cmp.LinkTemplate = upd.LinkTemplate
+		}
+
 		if upd.FollowerTarget != nil {
 			cmp.FollowerTarget = upd.FollowerTarget
 		}
@@ -706,6 +993,14 @@ func putCampaign(s *Server) gin.HandlerFunc {
 		cmp.FollowerTarget = upd.FollowerTarget
 		cmp.EngTarget = upd.EngTarget
 		cmp.PriceTarget = upd.PriceTarget
+		cmp.AudienceTargets = upd.AudienceTargets
+		cmp.Languages = langs
+		cmp.RequireLanguage = upd.RequireLanguage
+		cmp.ExcludeRisks = risks
+		cmp.Market = strings.ToLower(strings.TrimSpace(upd.Market))
+		cmp.VisualTags = visualTags
+		cmp.VisualConfidence = upd.VisualConfidence
+		cmp.Targeting = expr
 
 		// Copy the plan from the Advertiser
 		cmp.Plan = adv.Plan
@@ -777,78 +1072,50 @@ func putCampaign(s *Server) gin.HandlerFunc {
 			// Only update if the campaign already has perks..
 			if cmp.Perks.IsCoupon() && upd.Perks.IsCoupon() {
 				// If the saved perk is a coupon.. lets add more!
-				existingCoupons := make(map[string]int)
-				for _, cp := range cmp.Perks.Codes {
-					existingCoupons[cp] += 1
-				}
+				if err = s.db.Update(func(tx *bolt.Tx) (err error) {
+					pool := common.GetCouponPoolTx(tx, &cmp, s.Cfg)
 
-				// Get all the coupons saved in the deals
-				var inUse bool
-				for _, d := range cmp.Deals {
-					if d.Perk != nil && d.Perk.Code != "" {
-						existingCoupons[d.Perk.Code] += 1
-						inUse = true
-					}
-				}
-
-				newCouponMap := make(map[string]int)
-				for _, newCouponCode := range upd.Perks.Codes {
-					newCouponMap[newCouponCode] += 1
-				}
-
-				var (
-					filteredList []string
-					modified     bool
-				)
-				for cp, newVal := range newCouponMap {
-					oldVal, _ := existingCoupons[cp]
-					if oldVal != newVal {
-						modified = true
-						if newVal > oldVal {
-							for i := 0; i < newVal-oldVal; i++ {
-								filteredList = append(filteredList, cp)
-							}
-						}
-					}
-				}
-
-				// If modified is true that means an existing coupon was either increased
-				// in count or decreased. However, that (the above forloop and it's var modified
-				// logic) wouldn't account for a coupon completely disappearing from the
-				// newCouponMap
-				if modified || len(newCouponMap) != len(existingCoupons) {
-					dealsToAdd := len(filteredList)
-					if dealsToAdd > 0 {
+					removed, inUse := pool.Missing(upd.Perks.Codes)
+					if added, _ := pool.AddCodes(upd.Perks.Codes, 0); added > 0 {
 						// There are new coupons being added!
-						cmp.Perks.Codes = append(cmp.Perks.Codes, filteredList...)
-						cmp.Perks.Count += dealsToAdd
-
-						// Add deals for perks we added
-						if err = s.db.Update(func(tx *bolt.Tx) (err error) {
-							addDeals(&cmp, dealsToAdd, s, tx)
-							return saveCampaign(tx, &cmp, s)
-						}); err != nil {
-							misc.AbortWithErr(c, 500, err)
-							return
-						}
-					} else {
-						// Coupons are beign taken away since filtered returned nothing!
+						addDeals(&cmp, added, s, tx)
+					} else if len(removed) > 0 {
+						// Coupons are being taken away since there's nothing new
 						if inUse {
-							misc.WriteJSON(c, 400, misc.StatusErr("Cannot delete coupon codes that are in use by influencers"))
-							return
+							return common.ErrCouponInUse
 						}
 
-						cmp.Perks.Codes = upd.Perks.Codes
-						cmp.Perks.Count = len(upd.Perks.Codes)
-
-						if err = s.db.Update(func(tx *bolt.Tx) (err error) {
-							resetDeals(&cmp, cmp.Perks.Count, s, tx)
-							return saveCampaign(tx, &cmp, s)
-						}); err != nil {
-							misc.AbortWithErr(c, 500, err)
+						if err = pool.Remove(removed); err != nil {
 							return
 						}
+						resetDeals(&cmp, pool.Count(), s, tx)
+					} else {
+						return nil
+					}
+
+					if err = saveCouponPool(tx, &cmp, pool, s); err != nil {
+						return
 					}
+					return saveCampaign(tx, &cmp, s)
+				}); err == common.ErrCouponInUse {
+					misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+					return
+				} else if err != nil {
+					misc.AbortWithErr(c, 500, err)
+					return
+				}
+			} else if !cmp.Perks.IsCoupon() && !upd.Perks.IsCoupon() && (cmp.Perks.HasVariants() || upd.Perks.HasVariants()) {
+				// Same as below but per variant
+				if err = updatePerkVariants(&cmp, upd.Perks); err != nil {
+					misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+					return
+				}
+
+				if err = s.db.Update(func(tx *bolt.Tx) (err error) {
+					return saveCampaign(tx, &cmp, s)
+				}); err != nil {
+					misc.AbortWithErr(c, 500, err)
+					return
 				}
 			} else if !cmp.Perks.IsCoupon() && !upd.Perks.IsCoupon() {
 				// If the saved perk is a physical product.. lets add more!
@@ -1085,8 +1352,7 @@ func approveCampaign(s *Server) gin.HandlerFunc {
 				addDeals(&cmp, cmp.Perks.PendingCount, s, tx)
 
 				// Empty out fields and increment perk count
-				cmp.Perks.Count += cmp.Perks.PendingCount
-				cmp.Perks.PendingCount = 0
+				cmp.Perks.Receive("Received by Sway")
 
 				return saveCampaign(tx, &cmp, s)
 			}); err != nil {
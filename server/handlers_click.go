@@ -2,6 +2,7 @@ package server
 
 import (
 	"errors"
+	"html/template"
 	"log"
 	"strconv"
 	"strings"
@@ -59,9 +60,11 @@ func click(s *Server) gin.HandlerFunc {
 			foundDeal.Link = "https://www.amazon.com/gp/product/B01C2EFBZU?th=1"
 		}
 
-		// Make sure we always go to the campaign's saved link
-		foundDeal.Link = cmp.Link
-		if foundDeal.Link == "" {
+		// Make sure we always go to the campaign's saved link (or the
+		// one for the deal's variant/platform/device) with any tracking
+		// params expanded for this click
+		dest, fallback := cmp.GetDestination(foundDeal, c.Request.UserAgent())
+		if dest == "" {
 			misc.WriteJSON(c, 500, misc.StatusErr(ErrDealNotFound.Error()))
 			return
 		}
@@ -87,7 +90,7 @@ func click(s *Server) gin.HandlerFunc {
 		inf, ok := s.auth.Influencers.Get(infId)
 		if !ok {
 			log.Println("Influencer not found for click!", infId, campaignId)
-			c.Redirect(302, foundDeal.Link)
+			clickRedirect(c, dest, fallback)
 			return
 		}
 
@@ -135,7 +138,7 @@ func click(s *Server) gin.HandlerFunc {
 			// s.ClickSet.Set(ip, ua)
 
 			if err := saveAllDeals(s, inf); err != nil {
-				c.Redirect(302, foundDeal.Link)
+				clickRedirect(c, dest, fallback)
 				return
 			}
 
@@ -157,6 +160,7 @@ func click(s *Server) gin.HandlerFunc {
 				"cookie":     cookie,
 				"ip":         c.Request.RemoteAddr,
 				"ua":         c.Request.UserAgent(),
+				"url":        dest,
 			}); err != nil {
 				s.Alert("Failed to log click for "+foundDeal.Id+" in "+foundDeal.CampaignId, err)
 			}
@@ -170,7 +174,34 @@ func click(s *Server) gin.HandlerFunc {
 			}
 		}
 
-		c.Redirect(302, foundDeal.Link)
+		clickRedirect(c, dest, fallback)
+	}
+}
+
+var deepLinkTmpl = template.Must(template.New("deepLink").Parse(`<!DOCTYPE html>
+<html><head><meta name="viewport" content="width=device-width, initial-scale=1"></head>
+<body><script>
+setTimeout(function() { window.location = {{.Fallback}}; }, 1500);
+window.location = {{.Dest}};
+</script></body></html>`))
+
+func clickRedirect(c *gin.Context, dest, fallback string) {
+	if fallback == "" {
+		c.Redirect(302, dest)
+		return
+	}
+
+	if !common.IsValidLink(dest) {
+		// Saved before schemes were checked
+		c.Redirect(302, fallback)
+		return
+	}
+
+	// Deep links can't be redirected to reliably so lets try opening
+	// the app and send them to the web fallback if it isn't installed
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := deepLinkTmpl.Execute(c.Writer, map[string]string{"Dest": dest, "Fallback": fallback}); err != nil {
+		c.Redirect(302, fallback)
 	}
 }
 
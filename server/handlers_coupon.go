@@ -0,0 +1,312 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/gin-gonic/gin"
+	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/internal/influencer"
+	"github.com/swayops/sway/misc"
+)
+
+var ErrCouponCampaign = errors.New("Campaign does not have coupon perks")
+
+// saveCouponPool saves the pool and syncs the campaign's coupon count
+// with it.. the campaign still needs to be saved
+func saveCouponPool(tx *bolt.Tx, cmp *common.Campaign, pool *common.CouponPool, s *Server) error {
+	b, err := json.Marshal(pool)
+	if err != nil {
+		return err
+	}
+
+	if cmp.Perks != nil {
+		// Codes used to be saved on the campaign
+		cmp.Perks.Codes = nil
+		cmp.Perks.Count = pool.Count()
+	}
+
+	return misc.PutBucketBytes(tx, s.Cfg.Bucket.Coupon, pool.CampaignId, b)
+}
+
+type CouponUpload struct {
+	CSV     string `json:"csv"`
+	Expires string `json:"expires,omitempty"` // YYYY-MM-DD for rows without an expiry
+}
+
+type CouponUploadResult struct {
+	Added      int `json:"added"`
+	Duplicates int `json:"duplicates"`
+	Available  int `json:"available"`
+}
+
+func uploadCoupons(s *Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var upload CouponUpload
+		defer c.Request.Body.Close()
+		if err := json.NewDecoder(c.Request.Body).Decode(&upload); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr("Error unmarshalling request body: "+err.Error()))
+			return
+		}
+
+		coupons, err := common.ParseCouponCSV(strings.NewReader(upload.CSV))
+		if err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
+		if len(coupons) == 0 {
+			misc.WriteJSON(c, 400, misc.StatusErr("Please provide coupon codes"))
+			return
+		}
+
+		if upload.Expires != "" {
+			expires, err := common.ParseCouponExpiry(upload.Expires)
+			if err != nil {
+				misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+				return
+			}
+
+			for _, cp := range coupons {
+				if cp.Expires == 0 {
+					cp.Expires = expires
+				}
+			}
+		}
+
+		var (
+			cid = c.Param("cid")
+			res CouponUploadResult
+		)
+
+		if err := s.db.Update(func(tx *bolt.Tx) (err error) {
+			var cmp *common.Campaign
+			if err = json.Unmarshal(tx.Bucket([]byte(s.Cfg.Bucket.Campaign)).Get([]byte(cid)), &cmp); err != nil {
+				return ErrCampaign
+			}
+
+			if cmp.Perks == nil || !cmp.Perks.IsCoupon() {
+				return ErrCouponCampaign
+			}
+
+			pool := common.GetCouponPoolTx(tx, cmp, s.Cfg)
+			res.Added, res.Duplicates = pool.Add(coupons)
+			if res.Added > 0 {
+				// Add deals for coupons we added
+				addDeals(cmp, res.Added, s, tx)
+			}
+
+			if err = saveCouponPool(tx, cmp, pool, s); err != nil {
+				return
+			}
+
+			res.Available = cmp.Perks.Count
+			return saveCampaign(tx, cmp, s)
+		}); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
+		misc.WriteJSON(c, 200, res)
+	}
+}
+
+func getCoupons(s *Server) gin.HandlerFunc {
+	// Who has which code and how much they've been used
+	return func(c *gin.Context) {
+		cmp := common.GetCampaign(c.Param("cid"), s.db, s.Cfg)
+		if cmp == nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(ErrCampaign.Error()))
+			return
+		}
+
+		if cmp.Perks == nil || !cmp.Perks.IsCoupon() {
+			misc.WriteJSON(c, 400, misc.StatusErr(ErrCouponCampaign.Error()))
+			return
+		}
+
+		misc.WriteJSON(c, 200, common.GetCouponPool(cmp, s.db, s.Cfg))
+	}
+}
+
+type RedemptionResult struct {
+	Attributed int      `json:"attributed"`
+	Duplicates int      `json:"duplicates"`
+	Unknown    []string `json:"unknown,omitempty"` // Codes we never gave out
+}
+
+func addRedemptions(s *Server) gin.HandlerFunc {
+	// Advertisers send us orders that used one of the campaign's codes
+	// and we attribute them to the influencer the code was given to
+	return func(c *gin.Context) {
+		var redemptions []*common.Redemption
+		defer c.Request.Body.Close()
+		if err := json.NewDecoder(c.Request.Body).Decode(&redemptions); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr("Error unmarshalling request body: "+err.Error()))
+			return
+		}
+
+		cmp := common.GetCampaign(c.Param("cid"), s.db, s.Cfg)
+		if cmp == nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(ErrCampaign.Error()))
+			return
+		}
+
+		if cmp.Perks == nil || !cmp.Perks.IsCoupon() {
+			misc.WriteJSON(c, 400, misc.StatusErr(ErrCouponCampaign.Error()))
+			return
+		}
+
+		var (
+			pool = common.GetCouponPool(cmp, s.db, s.Cfg)
+			now  = int32(time.Now().Unix())
+			res  RedemptionResult
+
+			byInf    = make(map[string][]*common.Redemption)
+			redeemed = make(map[string][]*common.Redemption) // By code
+		)
+
+		for _, r := range redemptions {
+			if r == nil {
+				continue
+			}
+
+			cp := pool.Get(r.Code)
+			if cp == nil || !cp.IsAssigned() {
+				res.Unknown = append(res.Unknown, r.Code)
+				continue
+			}
+
+			if r.TS == 0 || r.TS > now {
+				r.TS = now
+			}
+
+			r.Code = cp.Code
+			byInf[cp.InfId] = append(byInf[cp.InfId], r)
+		}
+
+		for infId, rs := range byInf {
+			inf, ok := s.auth.Influencers.Get(infId)
+			if !ok {
+				log.Println("Missing influencer for redemption", infId)
+				continue
+			}
+
+			var active, completed bool
+			for _, r := range rs {
+				deal, isActive := findInfDeal(&inf, pool.Get(r.Code).DealId)
+				if deal == nil {
+					res.Unknown = append(res.Unknown, r.Code)
+					continue
+				}
+
+				if !deal.AddRedemption(r) {
+					res.Duplicates++
+					continue
+				}
+
+				if isActive {
+					active = true
+				} else {
+					completed = true
+				}
+
+				res.Attributed++
+				redeemed[r.Code] = append(redeemed[r.Code], r)
+			}
+
+			if active {
+				if err := saveAllActiveDeals(s, inf); err != nil {
+					misc.AbortWithErr(c, 500, err)
+					return
+				}
+			}
+
+			if completed {
+				if err := saveAllCompletedDeals(s, inf); err != nil {
+					misc.AbortWithErr(c, 500, err)
+					return
+				}
+			}
+		}
+
+		if len(redeemed) > 0 {
+			if err := s.db.Update(func(tx *bolt.Tx) error {
+				// Fresh copy since codes may have been given out since
+				pool := common.GetCouponPoolTx(tx, cmp, s.Cfg)
+				for code, rs := range redeemed {
+					if cp := pool.Get(code); cp != nil {
+						for _, r := range rs {
+							cp.Redemptions += 1
+							cp.Revenue += r.Value
+						}
+					}
+				}
+
+				b, err := json.Marshal(pool)
+				if err != nil {
+					return err
+				}
+				return misc.PutBucketBytes(tx, s.Cfg.Bucket.Coupon, pool.CampaignId, b)
+			}); err != nil {
+				misc.AbortWithErr(c, 500, err)
+				return
+			}
+		}
+
+		misc.WriteJSON(c, 200, res)
+	}
+}
+
+// findInfDeal returns the influencer's deal and whether it's still active
+func findInfDeal(inf *influencer.Influencer, dealId string) (*common.Deal, bool) {
+	for _, d := range inf.ActiveDeals {
+		if d.Id == dealId {
+			return d, true
+		}
+	}
+
+	for _, d := range inf.CompletedDeals {
+		if d.Id == dealId {
+			return d, false
+		}
+	}
+	return nil, false
+}
+
+// syncCoupons updates the perk count of coupon campaigns whose codes
+// have expired so they stop handing out deals
+func syncCoupons(s *Server) (int32, error) {
+	var updated int32
+	for _, cmp := range s.Campaigns.GetStore() {
+		if cmp.Perks == nil || !cmp.Perks.IsCoupon() {
+			continue
+		}
+
+		pool := common.GetCouponPool(&cmp, s.db, s.Cfg)
+		if pool.Count() == cmp.Perks.Count {
+			continue
+		}
+
+		if err := s.db.Update(func(tx *bolt.Tx) (err error) {
+			var fresh *common.Campaign
+			if err = json.Unmarshal(tx.Bucket([]byte(s.Cfg.Bucket.Campaign)).Get([]byte(cmp.Id)), &fresh); err != nil {
+				return
+			}
+
+			if err = saveCouponPool(tx, fresh, common.GetCouponPoolTx(tx, fresh, s.Cfg), s); err != nil {
+				return
+			}
+			return saveCampaign(tx, fresh, s)
+		}); err != nil {
+			return updated, err
+		}
+		updated += 1
+	}
+
+	return updated, nil
+}
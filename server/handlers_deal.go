@@ -16,6 +16,7 @@ import (
 	"github.com/swayops/sway/internal/auth"
 	"github.com/swayops/sway/internal/common"
 	"github.com/swayops/sway/internal/geo"
+	"github.com/swayops/sway/internal/search"
 	"github.com/swayops/sway/internal/templates"
 	"github.com/swayops/sway/misc"
 	"github.com/swayops/sway/platforms"
@@ -47,8 +48,15 @@ func getMatchesForKeyword(s *Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		kw := c.Param("kw")
 		matches := []*Match{}
-		for _, inf := range s.auth.Influencers.GetAll() {
-			if common.IsInList(inf.Keywords, kw) {
+
+		for _, h := range s.Search.Lookup(search.FIELD_KEYWORDS, kw) {
+			switch h.Type {
+			case "influencer":
+				inf, ok := s.auth.Influencers.Get(h.Id)
+				if !ok {
+					continue
+				}
+
 				inf = *inf.Clean()
 				matches = append(matches, &Match{
 					Id:       inf.Id,
@@ -58,12 +66,12 @@ func getMatchesForKeyword(s *Server) gin.HandlerFunc {
 					YouTube:  inf.YTUsername,
 					Twitter:  inf.TwitterUsername,
 				})
-			}
-		}
+			case "scrap":
+				sc, ok := s.Scraps.Get(strings.TrimPrefix(h.Id, "sc-"))
+				if !ok {
+					continue
+				}
 
-		scraps := s.Scraps.GetStore()
-		for _, sc := range scraps {
-			if common.IsInList(sc.Keywords, kw) {
 				m := &Match{
 					Id:   sc.Id,
 					Type: "scrap",
@@ -80,12 +88,11 @@ func getMatchesForKeyword(s *Server) gin.HandlerFunc {
 					m.Facebook = sc.Name
 				}
 
-				if sc.Twitter {
-					m.Twitter = sc.Name
+				if sc.YouTube {
+					m.YouTube = sc.Name
 				}
 
 				matches = append(matches, m)
-
 			}
 		}
 
@@ -317,7 +324,11 @@ func submitPost(s *Server) gin.HandlerFunc {
 			sub.ImageData = nil
 		}
 
-		found.Submission = &sub
+		// Each submission is a new revision of the deal's content
+		if err = found.AddSubmission(&sub); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
 
 		if err := saveAllActiveDeals(s, inf); err != nil {
 			misc.WriteJSON(c, 500, misc.StatusErr(err.Error()))
@@ -336,6 +347,61 @@ func submitPost(s *Server) gin.HandlerFunc {
 	}
 }
 
+type SubmissionComment struct {
+	Comment string `json:"comment"`
+}
+
+func commentSubmission(s *Server) gin.HandlerFunc {
+	// Influencer replying to the advertiser's review of their submission
+	return func(c *gin.Context) {
+		var (
+			campaignId = c.Param("campaignId")
+			infId      = c.Param("influencerId")
+			cmt        SubmissionComment
+		)
+
+		defer c.Request.Body.Close()
+		if err := json.NewDecoder(c.Request.Body).Decode(&cmt); err != nil || cmt.Comment == "" {
+			misc.WriteJSON(c, 400, misc.StatusErr("Please provide a valid comment"))
+			return
+		}
+
+		inf, ok := s.auth.Influencers.Get(infId)
+		if !ok {
+			misc.WriteJSON(c, 500, misc.StatusErr("Internal error"))
+			return
+		}
+
+		var found *common.Deal
+		for _, deal := range inf.ActiveDeals {
+			if deal.CampaignId == campaignId {
+				found = deal
+				break
+			}
+		}
+
+		if found == nil || found.Submission == nil {
+			misc.WriteJSON(c, 500, misc.StatusErr("Deal and submission not found"))
+			return
+		}
+
+		found.Submission.AddComment("influencer", inf.Name, cmt.Comment)
+
+		if err := saveAllActiveDeals(s, inf); err != nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(err.Error()))
+			return
+		}
+
+		// Email the advertiser
+		if user := s.auth.GetUser(found.AdvertiserId); user != nil && user.Advertiser != nil && s.Cfg.ReplyMailClient() != nil && !s.Cfg.Sandbox {
+			email := templates.NotifySubmissionCommentEmail.Render(map[string]interface{}{"Name": user.Name, "InfluencerName": found.InfluencerName, "CampaignName": found.CampaignName, "Comment": cmt.Comment})
+			emailAdvertiser(s, user, email, found.InfluencerName+" commented on their submission")
+		}
+
+		misc.WriteJSON(c, 200, found)
+	}
+}
+
 func assignDeal(s *Server) gin.HandlerFunc {
 	// Influencer accepting deal
 	// Must pass in influencer ID and deal ID
@@ -371,7 +437,14 @@ func assignDeal(s *Server) gin.HandlerFunc {
 			dbg = true
 		}
 
-		currentDeals, _ := inf.GetAvailableDeals(s.Campaigns, s.Audiences, s.db, campaignId, dealId, nil, false, s.getTalentAgencyFee(inf.AgencyId), s.Cfg)
+		// Campaigns with bidding are priced off of every offer the
+		// influencer has so lets run the full auction for them
+		forcedCampaign := campaignId
+		if bidCmp, ok := s.Campaigns.Get(campaignId); ok && bidCmp.Bidding != nil {
+			forcedCampaign = ""
+		}
+
+		currentDeals, _ := inf.GetAvailableDeals(s.Campaigns, s.Audiences, s.db, forcedCampaign, dealId, nil, false, s.getTalentAgencyFee(inf.AgencyId), s.Cfg)
 		for _, deal := range currentDeals {
 			if deal.CampaignId == campaignId && deal.Assigned == 0 && deal.InfluencerId == "" {
 				if dbg || deal.Id == dealId {
@@ -388,7 +461,10 @@ func assignDeal(s *Server) gin.HandlerFunc {
 
 		// Assign the deal & Save the Campaign
 		// DEALS are located in the INFLUENCER struct AND the CAMPAIGN struct
-		var cmp *common.Campaign
+		var (
+			cmp      *common.Campaign
+			lowStock *common.PerkVariant
+		)
 		if err := s.db.Update(func(tx *bolt.Tx) (err error) {
 			err = json.Unmarshal(tx.Bucket([]byte(s.Cfg.Bucket.Campaign)).Get([]byte(campaignId)), &cmp)
 			if err != nil {
@@ -411,8 +487,12 @@ func assignDeal(s *Server) gin.HandlerFunc {
 
 				// Now that we know there is a deal for this dude..
 				// and they have an address.. schedule a perk order!
+				// Perks with variants need the influencer's pick
+				var variant *common.PerkVariant
+				if variant, err = cmp.Perks.Reserve(c.Query("sku"), foundDeal.Id, inf.Id); err != nil {
+					return err
+				}
 
-				cmp.Perks.Count -= 1
 				foundDeal.Perk = &common.Perk{
 					Name:         cmp.Perks.Name,
 					Instructions: cmp.Perks.Instructions,
@@ -424,6 +504,14 @@ func assignDeal(s *Server) gin.HandlerFunc {
 					Status:       false,
 				}
 
+				if variant != nil {
+					foundDeal.Perk.SKU = variant.SKU
+					foundDeal.Perk.Variant = variant.Label()
+					if cmp.Perks.IsLowStock(variant) {
+						lowStock = variant
+					}
+				}
+
 				if cmp.Perks.Count == 0 && cmp.Monthly {
 					// Lets email the advertiser letting them know there are no more
 					// perks available if it's a monthly (recurring) campaign
@@ -441,25 +529,45 @@ func assignDeal(s *Server) gin.HandlerFunc {
 				// If it's a coupon code.. we do not need admin approval
 				// so lets set the status to true
 				if cmp.Perks.IsCoupon() {
-					if len(cmp.Perks.Codes) == 0 {
-						return errors.New("Deal is no longer available!")
+					pool := common.GetCouponPoolTx(tx, cmp, s.Cfg)
+					var coupon *common.Coupon
+					if coupon, err = pool.Assign(foundDeal.Id, inf.Id); err != nil {
+						return err
 					}
 
 					foundDeal.Perk.Status = true
-					// Give it last element of the slice
-					idx := len(cmp.Perks.Codes) - 1
-					foundDeal.Perk.Code = cmp.Perks.Codes[idx]
-
-					// Lets also delete the coupon code
-					cmp.Perks.Codes = cmp.Perks.Codes[:idx]
+					foundDeal.Perk.Code = coupon.Code
+					foundDeal.Perk.Expires = coupon.Expires
+					if err = saveCouponPool(tx, cmp, pool, s); err != nil {
+						return err
+					}
 				} else {
 					s.Notify("Perk requested!", fmt.Sprintf("%s just requested a perk (%s) to be mailed to them! Please check admin dash.", inf.Name, cmp.Perks.Name))
 				}
 			}
 
+			// Accepting a private invite keeps its custom terms
+			invite := cmp.GetLiveInvite(infId)
+			if invite != nil && invite.DealId == foundDeal.Id {
+				invite.Apply(foundDeal)
+				invite.Status = common.INVITE_ACCEPTED
+				invite.UpdatedAt = time.Now().Unix()
+			} else {
+				invite = nil
+			}
+
 			foundDeal.InfluencerId = infId
 			foundDeal.InfluencerName = inf.Name
 			foundDeal.Assigned = int32(time.Now().Unix())
+			foundDeal.SetTimeout(cmp.Schedule)
+			if v := cmp.PickVariant(); v != nil && invite == nil {
+				// Invites have their own terms
+				v.Apply(foundDeal)
+			}
+
+			if foundDeal.Rights != nil {
+				foundDeal.Rights.Accepted = foundDeal.Assigned
+			}
 
 			if len(foundDeal.Platforms) == 0 {
 				return errors.New("Unforunately, the requested deal is no longer available!")
@@ -494,6 +602,14 @@ func assignDeal(s *Server) gin.HandlerFunc {
 				s.Notify("Deal accepted!", fmt.Sprintf("%s just accepted a deal for %s", inf.Name, cmp.Name))
 				assignDealEmail(s, cmp, foundDeal, &inf)
 			}
+
+			if forcedCampaign == "" {
+				recordAuction(s, currentDeals, campaignId, &inf)
+			}
+
+			if cmp != nil && lowStock != nil {
+				lowStockEmail(s, cmp, lowStock)
+			}
 		}()
 
 		misc.WriteJSON(c, 200, foundDeal)
@@ -517,6 +633,125 @@ func getDealsAssignedToInfluencer(s *Server) gin.HandlerFunc {
 	}
 }
 
+type ExtensionRequest struct {
+	Days   int    `json:"days"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func requestExtension(s *Server) gin.HandlerFunc {
+	// Influencer asking for more time on their deal.. only one
+	// request is allowed per deal
+	return func(c *gin.Context) {
+		var (
+			infId      = c.Param("influencerId")
+			campaignId = c.Param("campaignId")
+			req        ExtensionRequest
+		)
+
+		defer c.Request.Body.Close()
+		if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr("Error unmarshalling request body"))
+			return
+		}
+
+		inf, ok := s.auth.Influencers.Get(infId)
+		if !ok {
+			misc.WriteJSON(c, 500, misc.StatusErr(auth.ErrInvalidID.Error()))
+			return
+		}
+
+		cmp, ok := s.Campaigns.Get(campaignId)
+		if !ok {
+			misc.WriteJSON(c, 400, misc.StatusErr("Campaign not found"))
+			return
+		}
+
+		var found *common.Deal
+		for _, deal := range inf.ActiveDeals {
+			if deal.CampaignId == campaignId {
+				found = deal
+				break
+			}
+		}
+
+		if found == nil {
+			misc.WriteJSON(c, 500, misc.StatusErr("Deal not found"))
+			return
+		}
+
+		if err := found.RequestExtension(req.Days, req.Reason, cmp.Schedule); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
+		if err := saveAllActiveDeals(s, inf); err != nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(err.Error()))
+			return
+		}
+
+		// Email the advertiser
+		if user := s.auth.GetUser(found.AdvertiserId); user != nil && user.Advertiser != nil && !s.Cfg.Sandbox {
+			email := templates.NotifyEmail.Render(map[string]interface{}{"msg": fmt.Sprintf("%s has requested a %d day extension for campaign %s: %s", inf.Name, req.Days, cmp.Name, req.Reason)})
+			emailAdvertiser(s, user, email, inf.Name+" has requested an extension")
+		}
+
+		s.Notify("Extension requested!", fmt.Sprintf("%s has requested a %d day extension for %s", inf.Id, req.Days, campaignId))
+
+		misc.WriteJSON(c, 200, found)
+	}
+}
+
+func reviewExtension(s *Server) gin.HandlerFunc {
+	// Advertiser approving or denying an influencer's extension request
+	return func(c *gin.Context) {
+		var (
+			campaignId = c.Param("cid")
+			infId      = c.Param("influencerId")
+		)
+
+		approved, err := strconv.ParseBool(c.Param("state"))
+		if err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr("Invalid state"))
+			return
+		}
+
+		inf, ok := s.auth.Influencers.Get(infId)
+		if !ok {
+			misc.WriteJSON(c, 500, misc.StatusErr(auth.ErrInvalidID.Error()))
+			return
+		}
+
+		var found *common.Deal
+		for _, deal := range inf.ActiveDeals {
+			if deal.CampaignId == campaignId {
+				found = deal
+				break
+			}
+		}
+
+		if found == nil {
+			misc.WriteJSON(c, 500, misc.StatusErr("Deal not found"))
+			return
+		}
+
+		if err := found.ReviewExtension(approved); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
+		if err := saveAllActiveDeals(s, inf); err != nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(err.Error()))
+			return
+		}
+
+		if err := inf.ExtensionUpdate(found, s.Cfg); err != nil {
+			s.Alert("Failed to email extension update to influencer "+inf.Id, err)
+		}
+
+		misc.WriteJSON(c, 200, misc.StatusOK(campaignId))
+	}
+}
+
 func unassignDeal(s *Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		dealId := c.Param("dealId")
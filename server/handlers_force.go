@@ -178,6 +178,7 @@ func forceApprovePost(s *Server) gin.HandlerFunc {
 		foundDeal.InfluencerId = infId
 		foundDeal.InfluencerName = inf.Name
 		foundDeal.Assigned = int32(time.Now().Unix())
+		foundDeal.SetTimeout(cmp.Schedule)
 
 		// NOTE: Not touching the campaigns perks! Look into this
 
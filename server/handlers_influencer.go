@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,9 +16,13 @@ import (
 	"github.com/swayops/sway/internal/auth"
 	"github.com/swayops/sway/internal/budget"
 	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/internal/demographics"
 	"github.com/swayops/sway/internal/geo"
 	"github.com/swayops/sway/internal/influencer"
 	"github.com/swayops/sway/internal/reporting"
+	"github.com/swayops/sway/internal/safety"
+	"github.com/swayops/sway/internal/search"
+	"github.com/swayops/sway/internal/shipping"
 	"github.com/swayops/sway/misc"
 	"github.com/swayops/sway/platforms"
 	"github.com/swayops/sway/platforms/facebook"
@@ -424,12 +429,14 @@ func getInfluencersByCategory(s *Server) gin.HandlerFunc {
 		var influencers []influencer.Influencer
 		targetCat := c.Param("category")
 
-		for _, inf := range s.auth.Influencers.GetAll() {
-			for _, infCat := range inf.Categories {
-				if infCat == targetCat {
-					inf.Clean()
-					influencers = append(influencers, inf)
-				}
+		for _, h := range s.Search.Lookup(search.FIELD_CATEGORIES, targetCat) {
+			if h.Type != "influencer" {
+				continue
+			}
+
+			if inf, ok := s.auth.Influencers.Get(h.Id); ok {
+				inf.Clean()
+				influencers = append(influencers, inf)
 			}
 		}
 		misc.WriteJSON(c, 200, influencers)
@@ -643,6 +650,35 @@ func skipYield(s *Server) gin.HandlerFunc {
 	}
 }
 
+func skipExclusivity(s *Server) gin.HandlerFunc {
+	// Lets the influencer take the campaign's deals despite competitor conflicts
+	return func(c *gin.Context) {
+		var (
+			infId = c.Param("influencerId")
+		)
+
+		inf, ok := s.auth.Influencers.Get(infId)
+		if !ok {
+			misc.WriteJSON(c, 500, misc.StatusErr(auth.ErrInvalidID.Error()))
+			return
+		}
+
+		cid := c.Params.ByName("campaignId")
+		if cid != "" && !misc.Contains(inf.SkipExclusivity, cid) {
+			inf.SkipExclusivity = append(inf.SkipExclusivity, cid)
+
+			if err := s.db.Update(func(tx *bolt.Tx) (err error) {
+				return saveInfluencer(s, tx, inf)
+			}); err != nil {
+				misc.WriteJSON(c, 500, misc.StatusErr(err.Error()))
+				return
+			}
+		}
+
+		misc.WriteJSON(c, 200, misc.StatusOK(infId))
+	}
+}
+
 // func setSignature(s *Server) gin.HandlerFunc {
 // 	// Manually set sig id
 // 	return func(c *gin.Context) {
@@ -984,6 +1020,87 @@ func getIncompleteInfluencers(s *Server) gin.HandlerFunc {
 	}
 }
 
+type SafetyReview struct {
+	InfluencerId string         `json:"infId"`
+	Name         string         `json:"name,omitempty"`
+	Followers    int64          `json:"followers,omitempty"`
+	Pending      []string       `json:"pending"` // Borderline risk categories
+	Report       *safety.Report `json:"report"`
+}
+
+func getSafetyReview(s *Server) gin.HandlerFunc {
+	// Influencers with borderline brand safety scores.. biggest first
+	return func(c *gin.Context) {
+		var out []*SafetyReview
+		for _, inf := range s.auth.Influencers.GetAll() {
+			if inf.IsBanned() {
+				continue
+			}
+
+			if pending := inf.Safety.Pending(); len(pending) > 0 {
+				out = append(out, &SafetyReview{
+					InfluencerId: inf.Id,
+					Name:         inf.Name,
+					Followers:    inf.GetFollowers(),
+					Pending:      pending,
+					Report:       inf.Safety,
+				})
+			}
+		}
+
+		sort.Slice(out, func(i, j int) bool {
+			return out[i].Followers > out[j].Followers
+		})
+
+		misc.WriteJSON(c, 200, out)
+	}
+}
+
+func setSafetyReview(s *Server) gin.HandlerFunc {
+	// Takes a map of risk category to whether the influencer should
+	// stay flagged for it
+	return func(c *gin.Context) {
+		var decisions map[string]bool
+		defer c.Request.Body.Close()
+		if err := json.NewDecoder(c.Request.Body).Decode(&decisions); err != nil || len(decisions) == 0 {
+			misc.WriteJSON(c, 400, misc.StatusErr("Please submit valid review decisions"))
+			return
+		}
+
+		infId := c.Param("influencerId")
+		inf, ok := s.auth.Influencers.Get(infId)
+		if !ok {
+			misc.WriteJSON(c, 500, misc.StatusErr(auth.ErrInvalidID.Error()))
+			return
+		}
+
+		// Copy since the report is shared with the cache
+		var rep safety.Report
+		if inf.Safety != nil {
+			rep = *inf.Safety
+			rep.Overrides = make(map[string]bool, len(inf.Safety.Overrides))
+			for cat, flagged := range inf.Safety.Overrides {
+				rep.Overrides[cat] = flagged
+			}
+		}
+
+		if err := rep.Review(decisions); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+		inf.Safety = &rep
+
+		if err := s.db.Update(func(tx *bolt.Tx) (err error) {
+			return saveInfluencer(s, tx, inf)
+		}); err != nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(err.Error()))
+			return
+		}
+
+		misc.WriteJSON(c, 200, misc.StatusOK(infId))
+	}
+}
+
 func getCategories(s *Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		misc.WriteJSON(c, 200, s.Categories)
@@ -1288,6 +1405,7 @@ func blockCampaign(s *Server) gin.HandlerFunc {
 
 type PerkWithCmpInfo struct {
 	DealID       string `json:"dealID"`
+	Assigned     int32  `json:"assigned"`
 	InfluencerID string `json:"infID"`
 	AdvertiserID string `json:"advID"`
 	CampaignID   string `json:"cmpID"`
@@ -1310,14 +1428,13 @@ func getPendingPerks(s *Server) gin.HandlerFunc {
 					return nil
 				}
 
-				if !cmp.IsValid() {
-					return nil
-				}
-
+				// Every deal is its own shipment.. perks are still owed
+				// for deals picked up before the campaign was turned off
 				for _, d := range cmp.Deals {
 					if d.IsActive() && d.Perk != nil && !d.Perk.Status {
 						perks = append(perks, PerkWithCmpInfo{
 							DealID:       d.Id,
+							Assigned:     d.Assigned,
 							InfluencerID: d.InfluencerId,
 							AdvertiserID: cmp.AdvertiserId,
 							CampaignID:   cmp.Id,
@@ -1335,6 +1452,11 @@ func getPendingPerks(s *Server) gin.HandlerFunc {
 			return
 		}
 
+		// Oldest first so nobody waits too long
+		sort.Slice(perks, func(i, j int) bool {
+			return perks[i].Assigned < perks[j].Assigned
+		})
+
 		misc.WriteJSON(c, 200, perks)
 	}
 }
@@ -1411,23 +1533,129 @@ func approvePerk(s *Server) gin.HandlerFunc {
 			return
 		}
 
+		// Optional.. ships every pending perk for the campaign if
+		// no deal is passed
+		var (
+			dealId   = c.Param("dealId")
+			carrier  = c.Query("carrier")
+			tracking = c.Query("tracking")
+		)
+
+		if tracking != "" && dealId == "" {
+			misc.WriteJSON(c, 400, misc.StatusErr("Please provide a deal ID with the tracking number"))
+			return
+		}
+
+		var shipped, tracked []*common.Deal
 		for _, d := range inf.ActiveDeals {
-			if d.CampaignId == cid && d.Perk != nil {
+			if d.CampaignId != cid || d.Perk == nil || (dealId != "" && d.Id != dealId) {
+				continue
+			}
+
+			if !d.Perk.Status {
+				ship, err := shipping.New(carrier, tracking)
+				if err != nil {
+					misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+					return
+				}
+
 				d.Perk.Status = true
+				d.Perk.Shipment = ship
 				d.PerkIncr()
-				inf.PerkNotify(d, s.Cfg)
+				shipped = append(shipped, d)
+			} else if tracking != "" && !d.Perk.Shipment.IsTracked() {
+				// Tracking number came in after the perk was sent out
+				var err error
+				if d.Perk.Shipment == nil {
+					d.Perk.Shipment, err = shipping.New(carrier, tracking)
+				} else {
+					err = d.Perk.Shipment.AddTracking(carrier, tracking)
+				}
+
+				if err != nil {
+					misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+					return
+				}
+				tracked = append(tracked, d)
 			}
 		}
 
-		if err := saveAllActiveDeals(s, inf); err != nil {
+		if len(shipped) == 0 && len(tracked) == 0 {
+			// Already shipped.. nothing to do
+			misc.WriteJSON(c, 200, misc.StatusOK(infId))
+			return
+		}
+
+		if err := s.db.Update(func(tx *bolt.Tx) (err error) {
+			var cmp *common.Campaign
+			if err = json.Unmarshal(tx.Bucket([]byte(s.Cfg.Bucket.Campaign)).Get([]byte(cid)), &cmp); err != nil {
+				return
+			}
+
+			for _, d := range tracked {
+				cmp.Deals[d.Id] = d
+			}
+
+			for _, d := range shipped {
+				cmp.Deals[d.Id] = d
+				if cmp.Perks != nil {
+					cmp.Perks.Ship(d.Perk, d.Id)
+				}
+			}
+
+			if len(shipped) > 0 {
+				// Lets add to timeline
+				cmp.AddToTimeline(common.PERKS_MAILED, true, s.Cfg)
+			}
+
+			if err = saveCampaign(tx, cmp, s); err != nil {
+				return
+			}
+			return saveInfluencer(s, tx, inf)
+		}); err != nil {
 			misc.WriteJSON(c, 500, misc.StatusErr(err.Error()))
 			return
 		}
 
+		for _, d := range shipped {
+			inf.PerkNotify(d, s.Cfg)
+		}
+
 		misc.WriteJSON(c, 200, misc.StatusOK(infId))
 	}
 }
 
+func shipmentHook(s *Server) gin.HandlerFunc {
+	// Carriers push tracking updates here
+	return func(c *gin.Context) {
+		carrier, err := shipping.Get(c.Param("carrier"))
+		if err != nil {
+			misc.WriteJSON(c, 404, misc.StatusErr(err.Error()))
+			return
+		}
+
+		// Anyone can post here so only signed events get through
+		if err = carrier.Verify(c.Request); err != nil {
+			misc.WriteJSON(c, 401, misc.StatusErr(err.Error()))
+			return
+		}
+
+		events, err := carrier.ParseWebhook(c.Request)
+		if err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr("Error parsing webhook: "+err.Error()))
+			return
+		}
+
+		updated, err := applyShipmentEvents(s, carrier.Name(), events)
+		if err != nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(err.Error()))
+			return
+		}
+
+		misc.WriteJSON(c, 200, gin.H{"updated": updated})
+	}
+}
+
 type Inventory struct {
 	ID        string `json:"id,omitempty"`
 	Facebook  string `json:"facebook,omitempty"`
@@ -1441,8 +1669,9 @@ type Inventory struct {
 func getInventoryByState(s *Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Gets influencers and scraps that are in a particular state
+		// (and city if one is passed)
 		var targetGeo []*geo.GeoRecord
-		targetGeo = append(targetGeo, &geo.GeoRecord{State: c.Param("state"), Country: "US"})
+		targetGeo = append(targetGeo, &geo.GeoRecord{State: c.Param("state"), City: c.Param("city"), Country: "US"})
 
 		var inv []*Inventory
 		for _, inf := range s.auth.Influencers.GetAll() {
@@ -1492,6 +1721,69 @@ func getInventoryByState(s *Server) gin.HandlerFunc {
 	}
 }
 
+func setDemographics(s *Server, isAdmin bool) gin.HandlerFunc {
+	// Saves audience demographics for one of the influencer's platforms..
+	// only admins can mark them as verified
+	return func(c *gin.Context) {
+		inf, ok := s.auth.Influencers.Get(c.Param("influencerId"))
+		if !ok {
+			misc.WriteJSON(c, 500, misc.StatusErr(auth.ErrInvalidID.Error()))
+			return
+		}
+
+		var d demographics.Demographics
+		defer c.Request.Body.Close()
+		if err := json.NewDecoder(c.Request.Body).Decode(&d); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr("Error unmarshalling request body:"+err.Error()))
+			return
+		}
+
+		if isAdmin {
+			d.Source = demographics.SOURCE_ADMIN
+		} else {
+			d.Source, d.Verified = demographics.SOURCE_INFLUENCER, false
+		}
+
+		if err := inf.SetDemographics(c.Param("platform"), &d); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
+		if err := s.db.Update(func(tx *bolt.Tx) (err error) {
+			return saveInfluencer(s, tx, inf)
+		}); err != nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(err.Error()))
+			return
+		}
+
+		misc.WriteJSON(c, 200, misc.StatusOK(inf.Id))
+	}
+}
+
+func getInventoryByCity(s *Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Counts influencers and scraps per city in a particular state
+		var (
+			targetGeo = []*geo.GeoRecord{&geo.GeoRecord{State: c.Param("state"), Country: "US"}}
+			counts    = make(map[string]int)
+		)
+
+		for _, inf := range s.auth.Influencers.GetAll() {
+			if loc := inf.GetLatestGeo(); geo.IsGeoMatch(targetGeo, loc) && loc.City != "" {
+				counts[strings.Title(strings.ToLower(loc.City))] += 1
+			}
+		}
+
+		for _, sc := range s.Scraps.GetStore() {
+			if geo.IsGeoMatch(targetGeo, sc.Geo) && sc.Geo.City != "" {
+				counts[strings.Title(strings.ToLower(sc.Geo.City))] += 1
+			}
+		}
+
+		misc.WriteJSON(c, 200, counts)
+	}
+}
+
 func getAllHandles(s *Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		platform := c.Param("platform")
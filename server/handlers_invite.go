@@ -0,0 +1,226 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/gin-gonic/gin"
+	"github.com/swayops/sway/internal/auth"
+	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/internal/influencer"
+	"github.com/swayops/sway/misc"
+)
+
+var ErrNoInviteDeals = errors.New("Not enough available deals for all invites")
+
+func sendInvites(s *Server) gin.HandlerFunc {
+	// Sends private invites to a list of influencers (usually picked
+	// from forecast results). Each invite reserves a deal for the invitee
+	// until its deadline
+	return func(c *gin.Context) {
+		cid := c.Param("cid")
+
+		var invites []*common.DealInvite
+		defer c.Request.Body.Close()
+		if err := json.NewDecoder(c.Request.Body).Decode(&invites); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr("Error unmarshalling request body"))
+			return
+		}
+
+		if len(invites) == 0 {
+			misc.WriteJSON(c, 400, misc.StatusErr("Please provide at least one invite"))
+			return
+		}
+
+		now := time.Now().Unix()
+		infs := make(map[string]influencer.Influencer)
+		for _, invite := range invites {
+			inf, ok := s.auth.Influencers.Get(invite.InfluencerId)
+			if !ok {
+				misc.WriteJSON(c, 400, misc.StatusErr("Invalid influencer ID: "+invite.InfluencerId))
+				return
+			}
+
+			if invite.Deadline <= now {
+				misc.WriteJSON(c, 400, misc.StatusErr("Please provide an invite deadline in the future"))
+				return
+			}
+
+			if invite.Payout < 0 {
+				misc.WriteJSON(c, 400, misc.StatusErr("Please provide a valid payout"))
+				return
+			}
+
+			infs[inf.Id] = inf
+		}
+
+		var cmp *common.Campaign
+		if err := s.db.Update(func(tx *bolt.Tx) (err error) {
+			if err = json.Unmarshal(tx.Bucket([]byte(s.Cfg.Bucket.Campaign)).Get([]byte(cid)), &cmp); err != nil {
+				return
+			}
+
+			if cmp.Archived {
+				return errors.New("Campaign is no longer active")
+			}
+
+			if cmp.Invites == nil {
+				cmp.Invites = make(map[string]*common.DealInvite)
+			}
+
+			// Deals which are already being held for someone
+			held := cmp.GetReservedDeals("")
+			for _, invite := range invites {
+				if invite.Payout == 0 && !cmp.IsProductBasedBudget() {
+					return errors.New("Please provide a payout for " + invite.InfluencerId)
+				}
+
+				inf := infs[invite.InfluencerId]
+				if r := inf.GetExclusion(cmp); r != "" {
+					return fmt.Errorf("%s can't be invited: %s", invite.InfluencerId, r.Label())
+				}
+
+				if old := cmp.GetLiveInvite(invite.InfluencerId); old != nil {
+					// Re-sending an invite updates the terms but keeps the held deal
+					invite.DealId = old.DealId
+				} else {
+					invite.DealId = ""
+					for _, deal := range cmp.Deals {
+						if deal.IsAvailable() && !held[deal.Id] {
+							invite.DealId = deal.Id
+							break
+						}
+					}
+
+					if invite.DealId == "" {
+						return ErrNoInviteDeals
+					}
+				}
+
+				held[invite.DealId] = true
+
+				invite.Id = misc.PseudoUUID()
+				invite.Status = common.INVITE_PENDING
+				invite.CreatedAt = now
+				invite.UpdatedAt = now
+				cmp.Invites[invite.InfluencerId] = invite
+			}
+
+			return saveCampaign(tx, cmp, s)
+		}); err != nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(err.Error()))
+			return
+		}
+
+		go func() {
+			for _, invite := range invites {
+				inf := infs[invite.InfluencerId]
+				if err := inf.DealInvite(cmp, invite, s.Cfg); err != nil {
+					s.Alert("Failed to email deal invite to influencer "+inf.Id, err)
+				}
+			}
+		}()
+
+		misc.WriteJSON(c, 200, misc.StatusOK(cid))
+	}
+}
+
+func getInvites(s *Server) gin.HandlerFunc {
+	// Returns all invites sent out by the campaign along with their status
+	return func(c *gin.Context) {
+		cmp := common.GetCampaign(c.Param("cid"), s.db, s.Cfg)
+		if cmp == nil {
+			misc.WriteJSON(c, 500, misc.StatusErr("Invalid campaign"))
+			return
+		}
+
+		invites := make([]*common.DealInvite, 0, len(cmp.Invites))
+		for _, invite := range cmp.Invites {
+			invite.Status = invite.GetStatus()
+			invites = append(invites, invite)
+		}
+
+		misc.WriteJSON(c, 200, invites)
+	}
+}
+
+type InviteWithCmpInfo struct {
+	CampaignID    string `json:"cmpID"`
+	CampaignName  string `json:"cmpName"`
+	CampaignImage string `json:"cmpImg,omitempty"`
+	Company       string `json:"company,omitempty"`
+	common.DealInvite
+}
+
+func getInfluencerInvites(s *Server) gin.HandlerFunc {
+	// Returns all pending invites for the influencer. Accepting is done
+	// via assignDeal with the reserved deal ID
+	return func(c *gin.Context) {
+		infId := c.Param("influencerId")
+		if _, ok := s.auth.Influencers.Get(infId); !ok {
+			misc.WriteJSON(c, 500, misc.StatusErr(auth.ErrInvalidID.Error()))
+			return
+		}
+
+		invites := []*InviteWithCmpInfo{}
+		for _, cmp := range s.Campaigns.GetStore() {
+			invite := cmp.GetLiveInvite(infId)
+			if invite == nil {
+				continue
+			}
+
+			invites = append(invites, &InviteWithCmpInfo{
+				CampaignID:    cmp.Id,
+				CampaignName:  cmp.Name,
+				CampaignImage: cmp.ImageURL,
+				Company:       cmp.Company,
+				DealInvite:    *invite,
+			})
+		}
+
+		misc.WriteJSON(c, 200, invites)
+	}
+}
+
+func declineInvite(s *Server) gin.HandlerFunc {
+	// Influencer declining a private invite.. releases the held deal
+	return func(c *gin.Context) {
+		var (
+			infId      = c.Param("influencerId")
+			campaignId = c.Param("campaignId")
+		)
+
+		inf, ok := s.auth.Influencers.Get(infId)
+		if !ok {
+			misc.WriteJSON(c, 500, misc.StatusErr(auth.ErrInvalidID.Error()))
+			return
+		}
+
+		var cmp *common.Campaign
+		if err := s.db.Update(func(tx *bolt.Tx) (err error) {
+			if err = json.Unmarshal(tx.Bucket([]byte(s.Cfg.Bucket.Campaign)).Get([]byte(campaignId)), &cmp); err != nil {
+				return
+			}
+
+			invite := cmp.GetLiveInvite(infId)
+			if invite == nil {
+				return errors.New("Invite not found")
+			}
+
+			invite.Status = common.INVITE_DECLINED
+			invite.UpdatedAt = time.Now().Unix()
+
+			return saveCampaign(tx, cmp, s)
+		}); err != nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(err.Error()))
+			return
+		}
+
+		s.Notify("Invite declined!", fmt.Sprintf("%s just declined an invite for %s", inf.Name, cmp.Name))
+
+		misc.WriteJSON(c, 200, misc.StatusOK(campaignId))
+	}
+}
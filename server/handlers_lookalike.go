@@ -0,0 +1,101 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/internal/influencer"
+	"github.com/swayops/sway/misc"
+)
+
+const (
+	DEFAULT_LOOKALIKES = 100
+	MAX_SEEDS          = 25
+)
+
+var ErrSeeds = errors.New("Please provide valid seed influencer IDs")
+
+type LookalikeRequest struct {
+	Seeds    []string `json:"seeds"` // Influencer IDs or "sc-" prefixed scrap IDs
+	Max      int      `json:"max,omitempty"`
+	MinScore float64  `json:"minScore,omitempty"`
+}
+
+func getLookalikes(s *Server) gin.HandlerFunc {
+	// Finds influencers and scraps that are most like the seeds. The
+	// returned token can be used like a forecast token to dump the
+	// results into an audience
+	return func(c *gin.Context) {
+		var req LookalikeRequest
+		defer c.Request.Body.Close()
+		if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr("Error unmarshalling request body:"+err.Error()))
+			return
+		}
+
+		if len(req.Seeds) == 0 || len(req.Seeds) > MAX_SEEDS {
+			misc.WriteJSON(c, 400, misc.StatusErr(ErrSeeds.Error()))
+			return
+		}
+
+		if req.Max <= 0 {
+			req.Max = DEFAULT_LOOKALIKES
+		}
+
+		var seeds []*influencer.Profile
+		for _, id := range req.Seeds {
+			if scId := strings.TrimPrefix(id, "sc-"); scId != id {
+				if sc, ok := s.Scraps.Get(scId); ok {
+					seeds = append(seeds, sc.GetProfile())
+					continue
+				}
+			} else if inf, ok := s.auth.Influencers.Get(id); ok {
+				seeds = append(seeds, inf.GetProfile())
+				continue
+			}
+
+			misc.WriteJSON(c, 400, misc.StatusErr(ErrSeeds.Error()))
+			return
+		}
+
+		var candidates []*influencer.Profile
+		for _, inf := range s.auth.Influencers.GetAll() {
+			if !inf.IsBanned() {
+				candidates = append(candidates, inf.GetProfile())
+			}
+		}
+
+		for _, sc := range s.Scraps.GetStore() {
+			if !sc.Ignore {
+				candidates = append(candidates, sc.GetProfile())
+			}
+		}
+
+		matches := influencer.GetLookalikes(seeds, candidates, req.MinScore, req.Max)
+
+		var (
+			users []ForecastUser
+			reach int64
+		)
+		for _, m := range matches {
+			id := m.Id
+			if m.Type == "scrap" {
+				id = "sc-" + id
+			}
+
+			users = append(users, ForecastUser{
+				ID:              id,
+				Name:            strings.Title(m.Name),
+				Email:           m.Email,
+				Followers:       m.Followers,
+				StringFollowers: common.Commanize(m.Followers),
+			})
+			reach += m.Followers
+		}
+
+		misc.WriteJSON(c, 200, gin.H{"lookalikes": matches, "reach": reach, "token": s.Forecasts.Set(users, reach)})
+	}
+}
@@ -40,6 +40,20 @@ func getCampaignReport(s *Server) gin.HandlerFunc {
 	}
 }
 
+func getDisclosureReport(s *Server) gin.HandlerFunc {
+	// Sponsorship disclosure checks for the campaign's posts.. also
+	// exported as a sheet in the campaign report
+	return func(c *gin.Context) {
+		cmp := common.GetCampaign(c.Param("cid"), s.db, s.Cfg)
+		if cmp == nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(ErrCampaign.Error()))
+			return
+		}
+
+		misc.WriteJSON(c, 200, reporting.GetDisclosureAudit(cmp))
+	}
+}
+
 type AdminStats struct {
 	AdAgencies  int `json:"adAgencies"`  // Total # of Ad Agencies
 	Advertisers int `json:"advertisers"` // Total # of Advertisers
@@ -352,6 +366,10 @@ type FeedCell struct {
 
 	Bonus bool `json:"bonus,omitempty"`
 
+	// Usage rights licensed for this asset and when they run out
+	Rights       *common.UsageRights `json:"rights,omitempty"`
+	RightsExpiry int32               `json:"rightsExpiry,omitempty"`
+
 	// Used by dash to display proper image
 	SocialImage string `json:"socialImage,omitempty"`
 
@@ -435,6 +453,8 @@ func getAdvertiserContentFeed(s *Server, requireKey bool) gin.HandlerFunc {
 								CampaignName: cmp.Name,
 								Username:     deal.InfluencerName,
 								InfluencerID: deal.InfluencerId,
+								Rights:       deal.Rights,
+								RightsExpiry: deal.GetRightsExpiry(),
 							}
 
 							total := deal.TotalStats()
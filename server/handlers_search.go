@@ -0,0 +1,40 @@
+package server
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	"github.com/swayops/sway/internal/search"
+	"github.com/swayops/sway/misc"
+)
+
+func indexInfluencers(s *Server) {
+	var docs []*search.Document
+	for _, inf := range s.auth.Influencers.GetAll() {
+		docs = append(docs, inf.GetSearchDoc())
+	}
+	s.Search.Replace("influencer", docs)
+}
+
+func indexScraps(s *Server) {
+	var docs []*search.Document
+	for _, sc := range s.Scraps.GetStore() {
+		docs = append(docs, sc.GetSearchDoc())
+	}
+	s.Search.Replace("scrap", docs)
+}
+
+func searchInfluencers(s *Server) gin.HandlerFunc {
+	// Full text search over influencers and scraps. Facet counts
+	// cover all matches rather than just the returned page
+	return func(c *gin.Context) {
+		var q search.Query
+		defer c.Request.Body.Close()
+		if err := json.NewDecoder(c.Request.Body).Decode(&q); err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr("Error unmarshalling request body:"+err.Error()))
+			return
+		}
+
+		misc.WriteJSON(c, 200, s.Search.Search(&q))
+	}
+}
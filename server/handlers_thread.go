@@ -0,0 +1,374 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/gin-gonic/gin"
+	"github.com/swayops/sway/internal/auth"
+	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/internal/influencer"
+	"github.com/swayops/sway/internal/templates"
+	"github.com/swayops/sway/misc"
+)
+
+const (
+	maxAttachments = 5
+	// Give the recipient some time to read in-app before we email them
+	unreadGracePeriod = int64(60 * 60)
+)
+
+var ErrThreadDeal = errors.New("Deal not found")
+
+type MessageLoad struct {
+	Text        string   `json:"text"`
+	Attachments []string `json:"attachments,omitempty"` // data:image/ strings
+}
+
+func getInfluencerThread(s *Server) gin.HandlerFunc {
+	// Influencer's view of the thread for their deal with the campaign
+	return func(c *gin.Context) {
+		inf, ok := s.auth.Influencers.Get(c.Param("influencerId"))
+		if !ok {
+			misc.WriteJSON(c, 500, misc.StatusErr(auth.ErrInvalidID.Error()))
+			return
+		}
+
+		deal := getInfluencerDeal(&inf, c.Param("campaignId"))
+		if deal == nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(ErrThreadDeal.Error()))
+			return
+		}
+
+		threadHelper(s, c, deal, common.AUTHOR_INFLUENCER)
+	}
+}
+
+func postInfluencerMessage(s *Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		inf, ok := s.auth.Influencers.Get(c.Param("influencerId"))
+		if !ok {
+			misc.WriteJSON(c, 500, misc.StatusErr(auth.ErrInvalidID.Error()))
+			return
+		}
+
+		deal := getInfluencerDeal(&inf, c.Param("campaignId"))
+		if deal == nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(ErrThreadDeal.Error()))
+			return
+		}
+
+		messageHelper(s, c, deal, common.AUTHOR_INFLUENCER, inf.Name)
+	}
+}
+
+func getDealThread(s *Server) gin.HandlerFunc {
+	// Advertiser's view of the thread for one of their campaign's deals
+	return func(c *gin.Context) {
+		deal := getAssignedDeal(s, c.Param("cid"), c.Param("dealId"))
+		if deal == nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(ErrThreadDeal.Error()))
+			return
+		}
+
+		threadHelper(s, c, deal, common.AUTHOR_ADVERTISER)
+	}
+}
+
+func postDealMessage(s *Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deal := getAssignedDeal(s, c.Param("cid"), c.Param("dealId"))
+		if deal == nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(ErrThreadDeal.Error()))
+			return
+		}
+
+		var name string
+		if u := auth.GetCtxUser(c); u != nil {
+			name = u.Name
+		}
+
+		messageHelper(s, c, deal, common.AUTHOR_ADVERTISER, name)
+	}
+}
+
+func getThread(s *Server) gin.HandlerFunc {
+	// Admin view of a thread including removed messages
+	return func(c *gin.Context) {
+		thread := common.GetThread(c.Param("dealId"), c.Param("influencerId"), s.db, s.Cfg)
+		if thread == nil {
+			misc.WriteJSON(c, 500, misc.StatusErr("Thread not found"))
+			return
+		}
+
+		misc.WriteJSON(c, 200, thread)
+	}
+}
+
+func removeMessage(s *Server) gin.HandlerFunc {
+	// Admin moderation.. hides the message from both parties
+	return func(c *gin.Context) {
+		var (
+			dealId = c.Param("dealId")
+			infId  = c.Param("influencerId")
+			msgId  = c.Param("msgId")
+		)
+
+		if err := s.db.Update(func(tx *bolt.Tx) error {
+			thread := common.GetThreadTx(tx, dealId, infId, s.Cfg)
+			if thread == nil {
+				return errors.New("Thread not found")
+			}
+
+			for _, msg := range thread.Messages {
+				if msg.Id == msgId {
+					msg.Removed = true
+					return saveThread(tx, thread, s)
+				}
+			}
+
+			return errors.New("Message not found")
+		}); err != nil {
+			misc.WriteJSON(c, 500, misc.StatusErr(err.Error()))
+			return
+		}
+
+		misc.WriteJSON(c, 200, misc.StatusOK(msgId))
+	}
+}
+
+func getInfluencerDeal(inf *influencer.Influencer, cid string) *common.Deal {
+	for _, deal := range inf.ActiveDeals {
+		if deal.CampaignId == cid {
+			return deal
+		}
+	}
+
+	for _, deal := range inf.CompletedDeals {
+		if deal.CampaignId == cid {
+			return deal
+		}
+	}
+
+	return nil
+}
+
+func getAssignedDeal(s *Server, cid, dealId string) *common.Deal {
+	cmp := common.GetCampaign(cid, s.db, s.Cfg)
+	if cmp == nil {
+		return nil
+	}
+
+	deal, ok := cmp.Deals[dealId]
+	if !ok || deal == nil || deal.InfluencerId == "" {
+		return nil
+	}
+
+	return deal
+}
+
+func threadHelper(s *Server, c *gin.Context, deal *common.Deal, reader string) {
+	var thread *common.Thread
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		if thread = common.GetThreadTx(tx, deal.Id, deal.InfluencerId, s.Cfg); thread == nil {
+			thread = common.NewThread(deal)
+			return nil
+		}
+
+		if u := auth.GetCtxUser(c); u != nil && u.Admin {
+			// Admins peeking shouldn't set read receipts
+			return nil
+		}
+
+		if thread.MarkRead(reader) {
+			return saveThread(tx, thread, s)
+		}
+		return nil
+	}); err != nil {
+		misc.WriteJSON(c, 500, misc.StatusErr(err.Error()))
+		return
+	}
+
+	misc.WriteJSON(c, 200, thread.Visible())
+}
+
+func messageHelper(s *Server, c *gin.Context, deal *common.Deal, author, name string) {
+	var load MessageLoad
+	defer c.Request.Body.Close()
+	if err := json.NewDecoder(c.Request.Body).Decode(&load); err != nil {
+		misc.WriteJSON(c, 400, misc.StatusErr("Error unmarshalling request body"))
+		return
+	}
+
+	load.Text = strings.TrimSpace(load.Text)
+	if load.Text == "" && len(load.Attachments) == 0 {
+		misc.WriteJSON(c, 400, misc.StatusErr("Please provide a message"))
+		return
+	}
+
+	if len(load.Attachments) > maxAttachments {
+		misc.WriteJSON(c, 400, misc.StatusErr(fmt.Sprintf("Only %d attachments are allowed per message", maxAttachments)))
+		return
+	}
+
+	if u := auth.GetCtxUser(c); u != nil && u.Admin {
+		author = common.AUTHOR_ADMIN
+	}
+
+	// Attachments go through the same image pipeline as submissions
+	var (
+		attachments []string
+		ts          = strconv.FormatInt(time.Now().UnixNano(), 10)
+	)
+	for idx, imgData := range load.Attachments {
+		if !strings.HasPrefix(imgData, "data:image/") {
+			misc.WriteJSON(c, 400, misc.StatusErr("Please provide a valid image"))
+			return
+		}
+
+		id := deal.Id + "-" + ts + "-" + strconv.Itoa(idx)
+		filename, err := saveImageToDisk(filepath.Join(s.Cfg.ImagesDir, s.Cfg.Bucket.Thread, id), imgData, id, "", 0, 0)
+		if err != nil {
+			misc.WriteJSON(c, 400, misc.StatusErr(err.Error()))
+			return
+		}
+
+		attachments = append(attachments, getImageUrl(s, s.Cfg.Bucket.Thread, "dash", filename, false))
+	}
+
+	var msg *common.Message
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		thread := common.GetThreadTx(tx, deal.Id, deal.InfluencerId, s.Cfg)
+		if thread == nil {
+			thread = common.NewThread(deal)
+		}
+
+		msg = thread.AddMessage(author, name, load.Text, attachments)
+		return saveThread(tx, thread, s)
+	}); err != nil {
+		misc.WriteJSON(c, 500, misc.StatusErr(err.Error()))
+		return
+	}
+
+	misc.WriteJSON(c, 200, msg)
+}
+
+func saveThread(tx *bolt.Tx, thread *common.Thread, s *Server) error {
+	b, err := json.Marshal(thread)
+	if err != nil {
+		return err
+	}
+
+	return misc.PutBucketBytes(tx, s.Cfg.Bucket.Thread, thread.Key(), b)
+}
+
+func emailUnreadMessages(s *Server) (int32, error) {
+	// Emails both sides of every thread about messages they
+	// haven't read yet
+	var (
+		emailed int32
+		threads []*common.Thread
+	)
+
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(s.Cfg.Bucket.Thread)).ForEach(func(k, v []byte) error {
+			var thread common.Thread
+			if err := json.Unmarshal(v, &thread); err != nil {
+				log.Println("error when unmarshalling thread", string(v))
+				return nil
+			}
+			threads = append(threads, &thread)
+			return nil
+		})
+	}); err != nil {
+		return emailed, err
+	}
+
+	cutoff := time.Now().Unix() - unreadGracePeriod
+	for _, thread := range threads {
+		cmp, ok := s.Campaigns.Get(thread.CampaignId)
+		if !ok {
+			continue
+		}
+
+		inf, ok := s.auth.Influencers.Get(thread.InfluencerId)
+		if !ok {
+			continue
+		}
+
+		var updated bool
+		if unread := getNotifiable(thread, common.AUTHOR_INFLUENCER, cutoff); len(unread) > 0 {
+			if err := inf.MessageNotify(cmp.Name, cmp.Company, len(unread), s.Cfg); err != nil {
+				s.Alert("Failed to email unread messages to influencer "+inf.Id, err)
+			} else {
+				setNotified(unread)
+				updated = true
+				emailed += 1
+			}
+		}
+
+		if unread := getNotifiable(thread, common.AUTHOR_ADVERTISER, cutoff); len(unread) > 0 {
+			if user := s.auth.GetUser(thread.AdvertiserId); user != nil && user.Advertiser != nil && !s.Cfg.Sandbox {
+				email := templates.NotifyUnreadMessagesEmail.Render(map[string]interface{}{"Name": user.Name, "Count": len(unread), "From": inf.Name, "CampaignName": cmp.Name, "URL": "https://dash.swayops.com/login"})
+				emailAdvertiser(s, user, email, "You have unread messages from "+inf.Name)
+				setNotified(unread)
+				updated = true
+				emailed += 1
+			}
+		}
+
+		if !updated {
+			continue
+		}
+
+		if err := s.db.Update(func(tx *bolt.Tx) error {
+			// Merge the notified flags into the latest copy of the thread
+			latest := common.GetThreadTx(tx, thread.DealId, thread.InfluencerId, s.Cfg)
+			if latest == nil {
+				return nil
+			}
+
+			notified := make(map[string]bool)
+			for _, msg := range thread.Messages {
+				if msg.Notified {
+					notified[msg.Id] = true
+				}
+			}
+
+			for _, msg := range latest.Messages {
+				if notified[msg.Id] {
+					msg.Notified = true
+				}
+			}
+
+			return saveThread(tx, latest, s)
+		}); err != nil {
+			return emailed, err
+		}
+	}
+
+	return emailed, nil
+}
+
+func getNotifiable(thread *common.Thread, reader string, cutoff int64) []*common.Message {
+	var out []*common.Message
+	for _, msg := range thread.GetUnread(reader) {
+		if msg.TS < cutoff {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+func setNotified(msgs []*common.Message) {
+	for _, msg := range msgs {
+		msg.Notified = true
+	}
+}
@@ -20,7 +20,10 @@ import (
 	"github.com/swayops/sway/internal/auth"
 	"github.com/swayops/sway/internal/common"
 	"github.com/swayops/sway/internal/influencer"
+	"github.com/swayops/sway/internal/language"
+	"github.com/swayops/sway/internal/safety"
 	"github.com/swayops/sway/internal/subscriptions"
+	"github.com/swayops/sway/internal/targeting"
 	"github.com/swayops/sway/internal/templates"
 	"github.com/swayops/sway/misc"
 )
@@ -54,14 +57,15 @@ func clearDeal(s *Server, dealId, influencerId, campaignId string, timeout bool)
 			// If there's a perk given to this deal.. lets
 			// add it back to the campaign count
 			if cmp.Perks != nil && deal.Perk != nil {
-				// Add the count back
-				cmp.Perks.Count += deal.Perk.Count
-
+				// Add the count (and coupon code) back
+				cmp.Perks.Release(deal.Perk, dealId)
 				if cmp.Perks.IsCoupon() && deal.Perk.Code != "" {
-					// Add the coupon code back!
-					cmp.Perks.Codes = append(cmp.Perks.Codes, deal.Perk.Code)
+					pool := common.GetCouponPoolTx(tx, &cmp, s.Cfg)
+					pool.Release(deal.Perk.Code)
+					if err = saveCouponPool(tx, &cmp, pool, s); err != nil {
+						return
+					}
 				}
-
 			}
 
 			// Flush all attribuets for the deal
@@ -163,7 +167,7 @@ func addDeals(cmp *common.Campaign, maxDeals int, s *Server, tx *bolt.Tx) *commo
 			AdvertiserId: cmp.AdvertiserId,
 		}
 
-		if cmp.Link != "" {
+		if cmp.HasLink() {
 			// Only shorten if the
 			shortenedID := common.ShortenID(d, tx, s.Cfg)
 			if shortenedID == "" {
@@ -202,6 +206,92 @@ func resetDeals(cmp *common.Campaign, maxDeals int, s *Server, tx *bolt.Tx) *com
 	return cmp
 }
 
+var (
+	ErrPerkIncrease = errors.New("Perk count can only be increased")
+	ErrPerkVariants = errors.New("Perk variants can't be added or removed once the campaign is created")
+)
+
+// updatePerkVariants applies the advertiser's new per variant counts. Like
+// perks without variants, additions wait in pending until admin receives
+// the product and decreases are only allowed before any perks are booked
+func updatePerkVariants(cmp *common.Campaign, upd *common.Perk) error {
+	if !cmp.Perks.HasVariants() || !upd.HasVariants() {
+		return ErrPerkVariants
+	}
+
+	var (
+		booked    = common.Booked(cmp.Deals)
+		anyBooked bool
+		seen      = make(map[string]bool, len(upd.Variants))
+	)
+
+	for _, count := range booked {
+		if count > 0 {
+			anyBooked = true
+		}
+	}
+
+	for _, u := range upd.Variants {
+		if u == nil {
+			return common.ErrVariants
+		}
+
+		sku := strings.TrimSpace(u.SKU)
+		if sku == "" || seen[sku] || u.Count < 0 {
+			return common.ErrVariants
+		}
+		seen[sku] = true
+
+		v := cmp.Perks.GetVariant(sku)
+		if v == nil {
+			cmp.Perks.Variants = append(cmp.Perks.Variants, &common.PerkVariant{SKU: sku, Options: u.Options, PendingCount: u.Count})
+			continue
+		}
+
+		if len(u.Options) > 0 {
+			v.Options = u.Options
+		}
+
+		// Frontend's count includes booked and pending perks
+		total := v.Count + v.PendingCount + booked[sku]
+		if u.Count >= total {
+			v.PendingCount += u.Count - total
+			continue
+		}
+
+		toDelete := total - u.Count
+		if toDelete <= v.PendingCount {
+			v.PendingCount -= toDelete
+			continue
+		}
+
+		toDelete -= v.PendingCount
+		if anyBooked || toDelete > v.Count {
+			return ErrPerkIncrease
+		}
+
+		v.PendingCount = 0
+		if err := cmp.Perks.Adjust(sku, toDelete, "Lowered by advertiser"); err != nil {
+			return err
+		}
+
+		// Delete the extra deals we made
+		for dealKey, deal := range cmp.Deals {
+			if toDelete == 0 {
+				break
+			}
+
+			if deal.IsAvailable() {
+				delete(cmp.Deals, dealKey)
+				toDelete--
+			}
+		}
+	}
+
+	cmp.Perks.UpdateCount()
+	return nil
+}
+
 func getAdvertiserFees(a *auth.Auth, advId string) (float64, float64) {
 	if g := a.GetAdvertiser(advId); g != nil {
 		return g.DspFee, g.ExchangeFee
@@ -261,6 +351,7 @@ func saveInfluencer(s *Server, tx *bolt.Tx, inf influencer.Influencer) error {
 
 	// Save in the cache
 	s.auth.Influencers.SetInfluencer(inf.Id, inf)
+	s.Search.Put(inf.GetSearchDoc())
 
 	// Save in the DB
 	return u.StoreWithData(s.auth, tx, &auth.Influencer{Influencer: &inf})
@@ -338,6 +429,7 @@ func saveInfluencerWithUser(s *Server, tx *bolt.Tx, inf influencer.Influencer, u
 
 	// Save in the cache
 	s.auth.Influencers.SetInfluencer(inf.Id, inf)
+	s.Search.Put(inf.GetSearchDoc())
 
 	// Save in the DB
 	return user.Update(user).StoreWithData(s.auth, tx, &auth.Influencer{Influencer: &inf})
@@ -386,6 +478,10 @@ func saveCampaign(tx *bolt.Tx, cmp *common.Campaign, s *Server) error {
 		return err
 	}
 
+	if err = saveStock(tx, cmp.Id, cmp.Perks.FlushStock(), s); err != nil {
+		return err
+	}
+
 	if !s.Cfg.Sandbox {
 		// Update the campaign store as well so things don't mess up
 		// until the next cache update!
@@ -397,6 +493,22 @@ func saveCampaign(tx *bolt.Tx, cmp *common.Campaign, s *Server) error {
 	return misc.PutBucketBytes(tx, s.Cfg.Bucket.Campaign, cmp.Id, b)
 }
 
+// saveStock adds the perk stock movements to the campaign's ledger
+func saveStock(tx *bolt.Tx, cid string, entries []*common.StockEntry, s *Server) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ledger := common.GetStockLedgerTx(tx, cid, s.Cfg)
+	ledger.Entries = append(ledger.Entries, entries...)
+
+	b, err := json.Marshal(ledger)
+	if err != nil {
+		return err
+	}
+	return misc.PutBucketBytes(tx, s.Cfg.Bucket.Stock, cid, b)
+}
+
 func (s *Server) getTalentAgencyFee(id string) float64 {
 	var agencyFee float64
 	if err := s.db.View(func(tx *bolt.Tx) error {
@@ -587,6 +699,64 @@ func sanitizeURL(incoming string) string {
 	return clean
 }
 
+func sanitizeVariants(cmp *common.Campaign) error {
+	for _, v := range cmp.Variants {
+		if v == nil {
+			return errors.New("Please provide valid variants")
+		}
+
+		v.Tags = misc.SanitizeHashes(v.Tags)
+		v.Mention = sanitizeMention(v.Mention)
+		v.Link = sanitizeURL(v.Link)
+	}
+
+	return cmp.SetVariantIds()
+}
+
+func sanitizeLanguages(langs []string) ([]string, error) {
+	langs = common.LowerSlice(langs)
+	for _, code := range langs {
+		if !language.IsSupported(code) {
+			return nil, errors.New("Unsupported language: " + code)
+		}
+	}
+	return langs, nil
+}
+
+func sanitizeRisks(cats []string) ([]string, error) {
+	cats = common.LowerSlice(cats)
+	for _, cat := range cats {
+		if !safety.IsCategory(cat) {
+			return nil, errors.New("Unknown risk category: " + cat)
+		}
+	}
+	return cats, nil
+}
+
+// sanitizeVisualTags lowercases and dedupes the product's visual tags
+func sanitizeVisualTags(tags []string, confidence float64) ([]string, error) {
+	if confidence < 0 || confidence > 100 {
+		return nil, common.ErrVisualConfidence
+	}
+
+	var out []string
+	for _, tg := range common.LowerSlice(tags) {
+		if tg = strings.TrimSpace(tg); tg != "" && !common.IsInList(out, tg) {
+			out = append(out, tg)
+		}
+	}
+	return out, nil
+}
+
+// sanitizeTargeting validates the targeting expression and returns it
+// normalized.. an empty expression turns targeting off
+func sanitizeTargeting(expr string) (string, error) {
+	if strings.TrimSpace(expr) == "" {
+		return "", nil
+	}
+	return targeting.Normalize(expr)
+}
+
 func trimURLPrefix(raw string) string {
 	raw = strings.TrimPrefix(raw, "https://")
 	raw = strings.TrimPrefix(raw, "http://")
@@ -910,6 +1080,22 @@ func emailStatusUpdate(s *Server, cid string) {
 	)
 }
 
+// lowStockEmail lets the advertiser know a perk variant is
+// running low (or out) so they can send more
+func lowStockEmail(s *Server, cmp *common.Campaign, v *common.PerkVariant) {
+	if s.Cfg.Sandbox {
+		return
+	}
+
+	user := s.auth.GetUser(cmp.AdvertiserId)
+	if user == nil || user.Advertiser == nil {
+		return
+	}
+
+	email := templates.NotifyLowStockEmail.Render(map[string]interface{}{"ID": cmp.Id, "Campaign": cmp.Name, "Perk": cmp.Perks.Name, "Variant": v.Label(), "Count": v.Count, "Name": user.Advertiser.Name})
+	emailAdvertiser(s, user, email, "You're running low on "+cmp.Perks.Name+" for the campaign "+cmp.Name)
+}
+
 func assignDealEmail(s *Server, cmp *common.Campaign, deal *common.Deal, inf *influencer.Influencer) {
 	// Emails influencer's with deal instructions
 	if err := inf.DealInstructions(cmp, deal, s.Cfg); err != nil {
@@ -917,6 +1103,14 @@ func assignDealEmail(s *Server, cmp *common.Campaign, deal *common.Deal, inf *in
 	}
 }
 
+func submissionReviewEmail(s *Server, inf *influencer.Influencer, deal *common.Deal) error {
+	// Emails influencer the outcome of the advertiser's review
+	if deal.Submission != nil && deal.Submission.Approved {
+		return inf.SubmissionApproved(deal, s.Cfg)
+	}
+	return inf.SubmissionReviewed(deal, s.Cfg)
+}
+
 // saveUserImage saves the user image to disk and sets User.ImageURL to the url for it if the image is a data:image/
 func saveUserImage(s *Server, u *auth.User) error {
 	if strings.HasPrefix(u.ImageURL, "data:image/") {
@@ -1088,7 +1282,12 @@ func getPerkHandout(d *common.Deal, cmp *common.Campaign) string {
 		capitalPlatforms = append(capitalPlatforms, strings.Title(pl))
 	}
 
-	return templates.Handout.Render(map[string]interface{}{"Name": d.InfluencerName, "Company": cmp.Company, "Task": d.Task, "Instructions": d.GetInstructions(), "Platforms": capitalPlatforms})
+	var variant string
+	if d.Perk != nil {
+		variant = d.Perk.Variant
+	}
+
+	return templates.Handout.Render(map[string]interface{}{"Name": d.InfluencerName, "Company": cmp.Company, "Task": d.Task, "Instructions": d.GetInstructions(), "Platforms": capitalPlatforms, "Variant": variant})
 }
 
 type InfCategory struct {
@@ -161,6 +161,7 @@ func saveScrap(s *Server, sc influencer.Scrap) error {
 		}
 
 		s.Scraps.SetScrap(sc.Id, sc)
+		s.Search.Put(sc.GetSearchDoc())
 
 		return nil
 	}); err != nil {
@@ -195,6 +196,7 @@ func saveScraps(s *Server, scs []influencer.Scrap) error {
 				continue
 			}
 			s.Scraps.SetScrap(sc.Id, sc)
+			s.Search.Put(sc.GetSearchDoc())
 
 		}
 		return nil
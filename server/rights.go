@@ -0,0 +1,49 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/swayops/sway/internal/templates"
+)
+
+func emailExpiringRights(s *Server) (int32, error) {
+	// Reminds advertisers about content licenses that are about
+	// to run out so they can pull any ads using the content
+	var emailed int32
+	for _, inf := range s.auth.Influencers.GetAll() {
+		var updated bool
+		for _, deal := range inf.CompletedDeals {
+			if deal.Rights == nil || deal.Rights.Notified || !deal.Rights.IsExpiring(deal.Completed) {
+				continue
+			}
+
+			user := s.auth.GetUser(deal.AdvertiserId)
+			if user == nil || user.Advertiser == nil {
+				continue
+			}
+
+			if !s.Cfg.Sandbox {
+				expiry := time.Unix(int64(deal.GetRightsExpiry()), 0).Format("January 2, 2006")
+				email := templates.NotifyEmail.Render(map[string]interface{}{"msg": fmt.Sprintf("Your usage rights for the post by %s for campaign %s expire on %s. Please make sure the content is no longer used after that date.", deal.InfluencerName, deal.CampaignName, expiry)})
+				emailAdvertiser(s, user, email, "Usage rights expiring for "+deal.InfluencerName)
+			}
+
+			deal.Rights.Notified = true
+			updated = true
+			emailed += 1
+		}
+
+		if !updated {
+			continue
+		}
+
+		if err := saveAllCompletedDeals(s, inf); err != nil {
+			log.Println("Error saving rights notification", inf.Id, err)
+			return emailed, err
+		}
+	}
+
+	return emailed, nil
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/swayops/sway/internal/auth"
 	"github.com/swayops/sway/internal/common"
 	"github.com/swayops/sway/internal/influencer"
+	"github.com/swayops/sway/internal/search"
 	"github.com/swayops/sway/internal/templates"
 	"github.com/swayops/sway/misc"
 )
@@ -47,6 +48,7 @@ type Server struct {
 	Audiences *common.Audiences
 	Scraps    *influencer.Scraps
 	Forecasts *Forecasts
+	Search    *search.Index
 
 	Categories []*InfCategory // List of available categories and their reach
 
@@ -54,6 +56,8 @@ type Server struct {
 
 	ClickSet *common.Set
 
+	Auctions *common.AuctionLog // Auction results waiting to be saved
+
 	Stats ServerStats // stores most recent server (engine) stats
 }
 
@@ -104,8 +108,10 @@ func New(cfg *config.Config, r *gin.Engine) (*Server, error) {
 		Audiences: common.NewAudiences(),
 		LimitSet:  common.NewLimitSet(),
 		ClickSet:  common.NewSet(),
+		Auctions:  common.NewAuctionLog(),
 		Forecasts: NewForecasts(),
 		Scraps:    influencer.NewScraps(),
+		Search:    search.NewIndex(),
 		Stats:     NewStats(),
 	}
 
@@ -336,6 +342,7 @@ func (srv *Server) initializeRoutes(r gin.IRouter) {
 	r.GET("/forecast/user/:id", getForecastUser(srv))
 
 	r.GET("/optout/:email", optoutScrap(srv))
+	r.POST("/shipmentHook/:carrier", shipmentHook(srv))
 	r.GET("/value/:platform/:handle", influencerValue(srv))
 
 	// Key based auth
@@ -380,6 +387,7 @@ func (srv *Server) initializeRoutes(r gin.IRouter) {
 	adminGroup.POST("/addBonus", addBonus(srv))
 	adminGroup.GET("/skipGeo/:influencerId/:campaignId", skipGeo(srv))
 	adminGroup.GET("/skipYield/:influencerId/:campaignId", skipYield(srv))
+	adminGroup.GET("/skipExclusivity/:influencerId/:campaignId", skipExclusivity(srv))
 
 	adminGroup.GET("/getAllHandles/:platform", getAllHandles(srv))
 
@@ -416,6 +424,7 @@ func (srv *Server) initializeRoutes(r gin.IRouter) {
 	verifyGroup.GET("/billingInfo/:id", getBillingInfo(srv))
 	verifyGroup.GET("/getAdvertiserTimeline/:id", getAdvertiserTimeline(srv))
 	verifyGroup.GET("/approveSubmission/:id/:campaignId/:influencerId", approveSubmission(srv))
+	verifyGroup.POST("/reviewSubmission/:id/:campaignId/:influencerId", reviewSubmission(srv))
 
 	adminGroup.GET("/balance/:id", getBalance(srv))
 	adminGroup.GET("/getCampaignStore", getCampaignStore(srv))
@@ -435,7 +444,11 @@ func (srv *Server) initializeRoutes(r gin.IRouter) {
 	verifyGroup.POST("/getForecast", getForecast(srv, false))
 	verifyGroup.POST("/getForecastExport/:filename", getForecastExport(srv))
 	verifyGroup.GET("/inventory/:state", getInventoryByState(srv))
+	verifyGroup.GET("/inventory/:state/:city", getInventoryByState(srv))
+	verifyGroup.GET("/inventoryByCity/:state", getInventoryByCity(srv))
 	verifyGroup.GET("/getMatchesForKeyword/:kw", getMatchesForKeyword(srv))
+	verifyGroup.POST("/searchInfluencers", searchInfluencers(srv))
+	verifyGroup.POST("/getLookalikes", getLookalikes(srv))
 	verifyGroup.GET("/getKeywords", getKeywords(srv))
 	verifyGroup.GET("/unassignDeal/:influencerId/:campaignId/:dealId", unassignDeal(srv))
 	verifyGroup.GET("/dirtyHack", dirtyHack(srv))
@@ -456,6 +469,9 @@ func (srv *Server) initializeRoutes(r gin.IRouter) {
 	// verifyGroup.GET("/emailTaxForm/:influencerId", infScope, emailTaxForm(srv))
 	verifyGroup.GET("/sendInstructions/:influencerId/:campaignId/:dealId", infScope, infOwnership, sendInstructions(srv))
 	verifyGroup.POST("/submitPost/:influencerId/:campaignId", infScope, submitPost(srv))
+	verifyGroup.POST("/commentSubmission/:influencerId/:campaignId", infScope, infOwnership, commentSubmission(srv))
+	verifyGroup.POST("/requestExtension/:influencerId/:campaignId", infScope, infOwnership, requestExtension(srv))
+	verifyGroup.POST("/demographics/:influencerId/:platform", infScope, infOwnership, setDemographics(srv, false))
 
 	// Influencers
 	createRoutes(verifyGroup, srv, "/influencer", "id", scopes["inf"], auth.InfluencerItem, getInfluencer,
@@ -463,6 +479,7 @@ func (srv *Server) initializeRoutes(r gin.IRouter) {
 
 	adminGroup.GET("/getInfluencersByCategory/:category", getInfluencersByCategory(srv))
 	adminGroup.PUT("/setAudit/:influencerId", setAudit(srv))
+	adminGroup.POST("/setDemographics/:influencerId/:platform", setDemographics(srv, true))
 	adminGroup.GET("/setAgency/:influencerId/:agencyId", setAgency(srv))
 	verifyGroup.GET("/getCategories", getCategories(srv))
 	verifyGroup.GET("/requestCheck/:influencerId", infScope, infOwnership, requestCheck(srv))
@@ -481,10 +498,40 @@ func (srv *Server) initializeRoutes(r gin.IRouter) {
 	verifyGroup.GET("/getAdvertiserStats/:id/:start/:end", getAdvertiserStats(srv))
 	verifyGroup.GET("/getCampaignReport/:cid/:from/:to/:filename", advScope, campOwnership, getCampaignReport(srv))
 	verifyGroup.GET("/getCampaignStats/:cid/:days", advScope, campOwnership, getCampaignStats(srv))
+	verifyGroup.GET("/getDisclosureReport/:cid", advScope, campOwnership, getDisclosureReport(srv))
 	verifyGroup.GET("/getCampaignInfluencerStats/:cid/:infId/:days", advScope, campOwnership, getCampaignInfluencerStats(srv))
+	verifyGroup.GET("/getWinRate/:cid", advScope, campOwnership, getWinRate(srv))
+	verifyGroup.GET("/getPacing/:cid", advScope, campOwnership, getPacing(srv))
+	verifyGroup.GET("/getDiagnostics/:cid", advScope, campOwnership, getDiagnostics(srv))
+	verifyGroup.GET("/getShipments/:cid", advScope, campOwnership, getShipments(srv))
+	verifyGroup.GET("/getStockLedger/:cid", advScope, campOwnership, getStockLedger(srv))
+
+	// Coupon pools
+	verifyGroup.POST("/uploadCoupons/:cid", advScope, campOwnership, uploadCoupons(srv))
+	verifyGroup.GET("/getCoupons/:cid", advScope, campOwnership, getCoupons(srv))
+	verifyGroup.POST("/couponRedemptions/:cid", advScope, campOwnership, addRedemptions(srv))
 	verifyGroup.GET("/getInfluencerStats/:influencerId/:days", getInfluencerStats(srv))
 	adminGroup.GET("/getAdminStats", getAdminStats(srv))
 
+	// Private invites
+	verifyGroup.POST("/sendInvites/:cid", advScope, campOwnership, sendInvites(srv))
+	verifyGroup.GET("/getInvites/:cid", advScope, campOwnership, getInvites(srv))
+	verifyGroup.GET("/getInfluencerInvites/:influencerId", infScope, infOwnership, getInfluencerInvites(srv))
+	// Accepting an invite is just assigning the deal reserved by it
+	verifyGroup.GET("/acceptInvite/:influencerId/:campaignId/:dealId/:platform", infScope, infOwnership, assignDeal(srv))
+	verifyGroup.GET("/declineInvite/:influencerId/:campaignId", infScope, infOwnership, declineInvite(srv))
+
+	// Deal messaging
+	verifyGroup.GET("/getMessages/:influencerId/:campaignId", infScope, infOwnership, getInfluencerThread(srv))
+	verifyGroup.POST("/sendMessage/:influencerId/:campaignId", infScope, infOwnership, postInfluencerMessage(srv))
+	verifyGroup.GET("/getDealMessages/:cid/:dealId", advScope, campOwnership, getDealThread(srv))
+	verifyGroup.POST("/sendDealMessage/:cid/:dealId", advScope, campOwnership, postDealMessage(srv))
+	adminGroup.GET("/getThread/:dealId/:influencerId", getThread(srv))
+	adminGroup.GET("/removeMessage/:dealId/:influencerId/:msgId", removeMessage(srv))
+
+	// Deal extensions (requested via /requestExtension)
+	verifyGroup.POST("/reviewExtension/:cid/:influencerId/:state", advScope, campOwnership, reviewExtension(srv))
+
 	adminGroup.GET("/forceBill/:id", forceBill(srv))
 	adminGroup.GET("/forceDeduction/:id/:amount", forceDeduction(srv))
 	adminGroup.GET("/forceRefund", forceRefund(srv))
@@ -495,11 +542,16 @@ func (srv *Server) initializeRoutes(r gin.IRouter) {
 	// Get influencers who haven't had biodata filled by admin
 	adminGroup.GET("/getIncompleteInfluencers", getIncompleteInfluencers(srv))
 
+	// Brand safety review queue for borderline risk scores
+	adminGroup.GET("/getSafetyReview", getSafetyReview(srv))
+	adminGroup.POST("/safetyReview/:influencerId", setSafetyReview(srv))
+
 	// Perks
 	adminGroup.GET("/getPendingCampaigns", getPendingCampaigns(srv))
 	adminGroup.GET("/approveCampaign/:id", approveCampaign(srv))
 	adminGroup.GET("/getPendingPerks", getPendingPerks(srv))
 	adminGroup.GET("/approvePerk/:influencerId/:campaignId", approvePerk(srv))
+	adminGroup.GET("/approvePerk/:influencerId/:campaignId/:dealId", approvePerk(srv))
 
 	adminGroup.GET("/forceApprove/:influencerId/:campaignId", forceApproveAny(srv))
 	adminGroup.POST("/forceApprovePost", forceApprovePost(srv))
@@ -683,6 +735,9 @@ func (srv *Server) Close() error {
 	log.Println("exiting...")
 
 	// srv.r.Close() // not implemented in gin nor net/http
+	if err := flushAuctions(srv); err != nil {
+		log.Println("Failed to flush auctions", err)
+	}
 	srv.db.Close()
 	srv.Cfg.Loggers.Close()
 
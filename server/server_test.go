@@ -1,13 +1,16 @@
 package server
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
 	"testing"
-	// "time"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/swayops/resty"
@@ -18,9 +21,11 @@ import (
 	"github.com/swayops/sway/internal/geo"
 	"github.com/swayops/sway/internal/influencer"
 	"github.com/swayops/sway/internal/reporting"
+	"github.com/swayops/sway/internal/shipping"
 	"github.com/swayops/sway/internal/subscriptions"
 	"github.com/swayops/sway/misc"
 	// "github.com/swayops/sway/platforms/hellosign"
+	"github.com/swayops/sway/platforms/instagram"
 	"github.com/swayops/sway/platforms/lob"
 	"github.com/swayops/sway/platforms/swipe"
 )
@@ -31,6 +36,33 @@ var (
 	adminTalentAgencyReq = M{"email": TalentAdminEmail, "pass": adminPass}
 )
 
+// postShipmentEvents posts the events to the shipment webhook signed
+// like the fake carrier expects
+func postShipmentEvents(t *testing.T, rst *resty.Client, events []*shipping.Event) int {
+	body, err := json.Marshal(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	carrier, err := shipping.Get(shipping.FAKE)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("POST", ts.URL+"/shipmentHook/fake", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(shipping.FAKE_SIGNATURE, carrier.(*shipping.Fake).Sign(body))
+
+	resp, err := rst.HTTPClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
 func TestAdminLogin(t *testing.T) {
 	rst := getClient()
 	defer putClient(rst)
@@ -1627,6 +1659,12 @@ SKIP_APPROVE_1:
 		t.Fatal("Pending perk still leftover!")
 	}
 
+	// approving an already shipped perk is a no-op
+	r = rst.DoTesting(t, "GET", "/approvePerk/"+inf.ExpID+"/4", nil, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+	}
+
 SKIP_APPROVE_2:
 
 	// make sure status is now true on campaign and influencer
@@ -1662,6 +1700,70 @@ SKIP_APPROVE_2:
 		return
 	}
 
+	if tgDeal.Perk.IsInTransit() {
+		t.Fatal("Untracked perk shouldn't be in transit!")
+	}
+
+	// tracking number comes in after the perk was sent
+	r = rst.DoTesting(t, "GET", "/approvePerk/"+inf.ExpID+"/4/"+tgDeal.Id+"?carrier=fake&tracking=TRACK123", nil, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+	}
+
+	r = rst.DoTesting(t, "GET", "/campaign/4?deals=true", nil, &cmpLoad)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+	}
+
+	if cmpDeal = cmpLoad.Deals[tgDeal.Id]; !cmpDeal.Perk.Shipment.IsTracked() || cmpDeal.Perk.Shipment.TrackingNumber != "TRACK123" {
+		t.Fatal("Tracking number not saved for shipment!")
+	}
+
+	r = rst.DoTesting(t, "GET", "/influencer/"+inf.ExpID, nil, &load)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+	}
+
+	if tgDeal = load.ActiveDeals[0]; !tgDeal.Perk.IsInTransit() {
+		t.Fatal("Perk should be in transit!")
+	}
+
+	// carrier lets us know it's been delivered.. unsigned events are rejected
+	delivered := []*shipping.Event{{TrackingNumber: "TRACK123", Status: shipping.STATUS_DELIVERED}}
+	r = rst.DoTesting(t, "POST", "/shipmentHook/fake", delivered, nil)
+	if r.Status != 401 {
+		t.Fatal("Unsigned shipment event should be rejected!", r.Status)
+	}
+
+	if status := postShipmentEvents(t, rst, delivered); status != 200 {
+		t.Fatal("Bad status code!", status)
+	}
+
+	r = rst.DoTesting(t, "GET", "/influencer/"+inf.ExpID, nil, &load)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+	}
+
+	tgDeal = load.ActiveDeals[0]
+	if !tgDeal.Perk.Shipment.IsDelivered() || tgDeal.Perk.IsInTransit() {
+		t.Fatal("Perk should be delivered!")
+	}
+
+	// timeout starts from delivery
+	if tgDeal.Timeout <= tgDeal.Perk.Shipment.Delivered || tgDeal.GetTimeoutDays() != common.DEFAULT_TIMEOUT_DAYS {
+		t.Fatal("Deal timeout not reset from delivery!", tgDeal.Timeout, tgDeal.Perk.Shipment.Delivered)
+	}
+
+	var board ShipmentBoard
+	r = rst.DoTesting(t, "GET", "/getShipments/4", nil, &board)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+	}
+
+	if board.Counts[shipping.STATUS_DELIVERED] != 1 || len(board.Shipments) != 1 {
+		t.Fatal("Unexpected shipment board!", board.Counts)
+	}
+
 	// force approve
 	r = rst.DoTesting(t, "GET", "/forceApprove/"+inf.ExpID+"/4", nil, nil)
 	if r.Status != 200 {
@@ -2011,6 +2113,74 @@ SKIP_APPROVE_2:
 		t.Fatal("Unexpected number of perks!")
 		return
 	}
+
+	// Bulk upload codes with a header, a dupe and an expiry
+	var upload CouponUploadResult
+	r = rst.DoTesting(t, "POST", "/uploadCoupons/"+st.ID, &CouponUpload{
+		CSV: "code,expires\nBULK1,2099-01-01\nBULK2\n321coupon\n",
+	}, &upload)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!", string(r.Value))
+		return
+	}
+
+	if upload.Added != 2 || upload.Duplicates != 1 {
+		t.Fatalf("Bad upload result: %+v", upload)
+		return
+	}
+
+	if upload.Available != updCampaign.Perks.Count+3 {
+		t.Fatalf("Bad available count: %+v", upload)
+		return
+	}
+
+	r = rst.DoTesting(t, "POST", "/uploadCoupons/"+st.ID, &CouponUpload{CSV: "BAD CODE\n"}, nil)
+	if r.Status != 400 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	// Redemptions should go to the influencer who has the code
+	var redeemed RedemptionResult
+	r = rst.DoTesting(t, "POST", "/couponRedemptions/"+st.ID, []*common.Redemption{
+		{Code: "lastcoupon", OrderId: "order1", Value: 40},
+		{Code: "LASTCOUPON", OrderId: "order1", Value: 40},
+		{Code: "BULK1", OrderId: "order2", Value: 10},
+		{Code: "NOTACODE", OrderId: "order3", Value: 10},
+	}, &redeemed)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	if redeemed.Attributed != 1 || redeemed.Duplicates != 1 || len(redeemed.Unknown) != 2 {
+		t.Fatalf("Bad redemption result: %+v", redeemed)
+		return
+	}
+
+	var pool common.CouponPool
+	r = rst.DoTesting(t, "GET", "/getCoupons/"+st.ID, nil, &pool)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	if cp := pool.Get("LASTCOUPON"); cp == nil || cp.InfId != inf.ExpID || cp.Redemptions != 1 || cp.Revenue != 40 {
+		t.Fatalf("Bad coupon: %+v", cp)
+		return
+	}
+
+	var couponBreakdown map[string]*reporting.Totals
+	r = rst.DoTesting(t, "GET", "/getCampaignStats/"+st.ID+"/10", nil, &couponBreakdown)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	if tot := couponBreakdown["total"]; tot == nil || tot.Redemptions != 1 || tot.Revenue != 40 {
+		t.Fatalf("Bad redemption stats: %+v", tot)
+		return
+	}
 }
 
 func getDeals(cid string, deals []*common.Deal) []*common.Deal {
@@ -6072,6 +6242,38 @@ func TestSubmission(t *testing.T) {
 		return
 	}
 
+	// Request changes from the influencer and submit a second revision
+	review := SubmissionReview{Status: common.SUBMISSION_CHANGES, Comment: "Please mention the sale!"}
+	r = rst.DoTesting(t, "POST", "/reviewSubmission/"+tgDeal.AdvertiserId+"/"+tgDeal.CampaignId+"/"+inf.ExpID, &review, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	sub.Message = "This is the message this campaign wants #mmmm and there's a sale!"
+	r = rst.DoTesting(t, "POST", "/submitPost/"+inf.ExpID+"/"+tgDeal.CampaignId, &sub, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad value!")
+		return
+	}
+
+	var dealRev common.Deal
+	r = rst.DoTesting(t, "GET", "/getDeal/"+inf.ExpID+"/"+tgDeal.CampaignId+"/"+tgDeal.Id, nil, &dealRev)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	if dealRev.Submission == nil || dealRev.Submission.Version != 2 || dealRev.Submission.Approved {
+		t.Fatal("Bad submission revision!")
+		return
+	}
+
+	if len(dealRev.Revisions) != 1 || dealRev.Revisions[0].Status != common.SUBMISSION_CHANGES || len(dealRev.Revisions[0].Comments) != 1 {
+		t.Fatal("Bad revision history!")
+		return
+	}
+
 	// Approve the proposal via advertiser (make sure it is now set to approved and there is a submission)
 	r = rst.DoTesting(t, "GET", "/approveSubmission/"+tgDeal.AdvertiserId+"/"+tgDeal.CampaignId+"/"+inf.ExpID, nil, nil)
 	if r.Status != 200 {
@@ -6107,10 +6309,7 @@ func TestSubmission(t *testing.T) {
 	}
 }
 
-func TestBilling(t *testing.T) {
-	if *genData {
-		t.Skip("not needed for generating data")
-	}
+func TestDealMessages(t *testing.T) {
 	rst := getClient()
 	defer putClient(rst)
 
@@ -6118,86 +6317,449 @@ func TestBilling(t *testing.T) {
 	r := rst.DoTesting(t, "POST", "/signIn", &adminReq, nil)
 	if r.Status != 200 {
 		t.Fatal("Bad status code!")
+		return
 	}
 
-	cids := make(map[string]budget.Store)
-	addedCids := []string{}
+	adv := getSignupUser()
+	adv.Advertiser = &auth.Advertiser{
+		DspFee:   0.2,
+		AgencyID: "2",
+		CCLoad:   creditCard,
+		SubLoad:  getSubscription(3, 100, true),
+	}
 
-	// Do multiple deals for multiple talent agencies, influencers,
-	// and advertisers
-	for i := 0; i < 8; i++ {
-		ag := getSignupUser()
-		ag.TalentAgency = &auth.TalentAgency{
-			Fee: 0.1,
-		}
+	var st Status
+	r = rst.DoTesting(t, "POST", "/signUp", adv, &st)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
 
-		r = rst.DoTesting(t, "POST", "/signUp", &ag, nil)
-		if r.Status != 200 {
-			t.Fatal("Bad status code!")
-		}
+	cmp := common.Campaign{
+		Status:       true,
+		AdvertiserId: st.ID,
+		Budget:       DEFAULT_BUDGET,
+		Name:         "Messaging Campaign!",
+		Twitter:      true,
+		Male:         true,
+		Female:       true,
+		Link:         "http://www.cnn.com?s=t",
+		Task:         "POST THAT DOPE SHIT",
+		Tags:         []string{"#mmmm"},
+	}
 
-		inf := getSignupUser()
-		inf.InfluencerLoad = &auth.InfluencerLoad{ // ugly I know
-			InfluencerLoad: influencer.InfluencerLoad{
-				Male:       true,
-				Geo:        &geo.GeoRecord{},
-				InviteCode: common.GetCodeFromID(ag.ExpID),
-				TwitterId:  "breakingnews",
-				Address: &lob.AddressLoad{
-					AddressOne: "8 Saint Elias",
-					City:       "Trabuco Canyon",
-					State:      "CA",
-					Country:    "US",
-				},
-			},
-		}
+	var status Status
+	r = rst.DoTesting(t, "POST", "/campaign?dbg=1", &cmp, &status)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!", string(r.Value))
+		return
+	}
+	cid := status.ID
 
-		r = rst.DoTesting(t, "POST", "/signUp", &inf, nil)
-		if r.Status != 200 {
-			t.Fatal("Bad status code!")
-		}
+	inf := getSignupUser()
+	inf.InfluencerLoad = &auth.InfluencerLoad{
+		InfluencerLoad: influencer.InfluencerLoad{
+			Male:      true,
+			Geo:       &geo.GeoRecord{},
+			TwitterId: "cnn",
+		},
+	}
+	r = rst.DoTesting(t, "POST", "/signUp", &inf, nil)
+	if r.Status != 200 {
+		t.Fatalf("Bad status code! %s", r.Value)
+		return
+	}
 
-		adAg := getSignupUserWithName("Some Ad Agency Name" + strconv.Itoa(i))
-		adAg.AdAgency = &auth.AdAgency{Status: true, IsIO: false}
-		r = rst.DoTesting(t, "POST", "/signUp", adAg, nil)
-		if r.Status != 200 {
-			t.Fatal("Bad status code!")
-		}
+	var deals []*common.Deal
+	r = rst.DoTesting(t, "GET", "/getDeals/"+inf.ExpID+"/0/0", nil, &deals)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
 
-		cid := doDeal(rst, t, inf.ExpID, adAg.ExpID, false)
+	deals = getDeals(cid, deals)
+	if len(deals) == 0 {
+		t.Fatal("Unexpected number of deals!")
+		return
+	}
+	tgDeal := deals[0]
 
-		// Approve the deal
-		r = rst.DoTesting(t, "GET", "/forceApprove/"+inf.ExpID+"/"+cid+"", nil, nil)
-		if r.Status != 200 {
-			t.Fatal("Bad status code!")
-			return
-		}
+	// No thread until the deal is assigned
+	r = rst.DoTesting(t, "POST", "/sendDealMessage/"+cid+"/"+tgDeal.Id, &MessageLoad{Text: "Hello!"}, nil)
+	if r.Status == 200 {
+		t.Fatal("Unexpected status code!")
+		return
+	}
 
-		addedCids = append(addedCids, cid)
+	r = rst.DoTesting(t, "GET", "/assignDeal/"+inf.ExpID+"/"+tgDeal.CampaignId+"/"+tgDeal.Id+"/twitter", nil, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
 	}
 
-	// Deplete budgets
-	r = rst.DoTesting(t, "GET", "/forceDeplete", nil, nil)
+	r = rst.DoTesting(t, "POST", "/sendDealMessage/"+cid+"/"+tgDeal.Id, &MessageLoad{Text: "Hello!"}, nil)
 	if r.Status != 200 {
 		t.Fatal("Bad status code!")
 		return
 	}
 
-	for _, cid := range addedCids {
-		store := budget.Store{}
-		r = rst.DoTesting(t, "GET", "/getBudgetInfo/"+cid, nil, &store)
-		if r.Status != 200 {
-			t.Fatal("Bad status code!")
-		}
+	var msg common.Message
+	r = rst.DoTesting(t, "POST", "/sendMessage/"+inf.ExpID+"/"+cid, &MessageLoad{Text: "Hey there!"}, &msg)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
 
-		if store.Spent == 0 {
-			t.Fatal("Spent not incremented correctly in budget")
-		}
+	var thread common.Thread
+	r = rst.DoTesting(t, "GET", "/getMessages/"+inf.ExpID+"/"+cid, nil, &thread)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
 
-		var breakdown map[string]*reporting.Totals
-		r = rst.DoTesting(t, "GET", "/getCampaignStats/"+cid+"/10", nil, &breakdown)
-		if r.Status != 200 {
-			t.Fatal("Bad status code!")
+	if len(thread.Messages) != 2 || thread.InfluencerId != inf.ExpID || thread.DealId != tgDeal.Id {
+		t.Fatal("Bad thread!")
+		return
+	}
+
+	// Moderate the influencer's message
+	r = rst.DoTesting(t, "GET", "/removeMessage/"+tgDeal.Id+"/"+inf.ExpID+"/"+msg.Id, nil, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	var advThread common.Thread
+	r = rst.DoTesting(t, "GET", "/getDealMessages/"+cid+"/"+tgDeal.Id, nil, &advThread)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	if len(advThread.Messages) != 1 || advThread.Messages[0].Text != "Hello!" {
+		t.Fatal("Removed message still visible!")
+		return
+	}
+
+	var adminThread common.Thread
+	r = rst.DoTesting(t, "GET", "/getThread/"+tgDeal.Id+"/"+inf.ExpID, nil, &adminThread)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	if len(adminThread.Messages) != 2 || !adminThread.Messages[1].Removed {
+		t.Fatal("Bad admin thread!")
+		return
+	}
+}
+
+func TestInvites(t *testing.T) {
+	rst := getClient()
+	defer putClient(rst)
+
+	// Sign in as admin
+	r := rst.DoTesting(t, "POST", "/signIn", &adminReq, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	adv := getSignupUser()
+	adv.Advertiser = &auth.Advertiser{
+		DspFee:   0.2,
+		AgencyID: "2",
+		CCLoad:   creditCard,
+		SubLoad:  getSubscription(3, 100, true),
+	}
+
+	var st Status
+	r = rst.DoTesting(t, "POST", "/signUp", adv, &st)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	// Only targets men so the invitee wouldn't see it otherwise
+	cmp := common.Campaign{
+		Status:       true,
+		AdvertiserId: st.ID,
+		Budget:       DEFAULT_BUDGET,
+		Name:         "Invite Campaign!",
+		Twitter:      true,
+		Male:         true,
+		Link:         "http://www.cnn.com?s=t",
+		Task:         "POST THAT DOPE SHIT",
+		Tags:         []string{"#mmmm"},
+	}
+
+	var status Status
+	r = rst.DoTesting(t, "POST", "/campaign?dbg=1", &cmp, &status)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!", string(r.Value))
+		return
+	}
+	cid := status.ID
+
+	invitee := getSignupUser()
+	invitee.InfluencerLoad = &auth.InfluencerLoad{
+		InfluencerLoad: influencer.InfluencerLoad{
+			Female:    true,
+			Geo:       &geo.GeoRecord{},
+			TwitterId: "cnn",
+		},
+	}
+	r = rst.DoTesting(t, "POST", "/signUp", &invitee, nil)
+	if r.Status != 200 {
+		t.Fatalf("Bad status code! %s", r.Value)
+		return
+	}
+
+	other := getSignupUser()
+	other.InfluencerLoad = &auth.InfluencerLoad{
+		InfluencerLoad: influencer.InfluencerLoad{
+			Male:      true,
+			Geo:       &geo.GeoRecord{},
+			TwitterId: "cnn",
+		},
+	}
+	r = rst.DoTesting(t, "POST", "/signUp", &other, nil)
+	if r.Status != 200 {
+		t.Fatalf("Bad status code! %s", r.Value)
+		return
+	}
+
+	getCmpDeals := func(infId string) []*common.Deal {
+		var deals []*common.Deal
+		r := rst.DoTesting(t, "GET", "/getDeals/"+infId+"/0/0", nil, &deals)
+		if r.Status != 200 {
+			t.Fatal("Bad status code!")
+		}
+		return getDeals(cid, deals)
+	}
+
+	if len(getCmpDeals(invitee.ExpID)) != 0 {
+		t.Fatal("Invitee should not match the campaign's targeting!")
+		return
+	}
+
+	invites := []*common.DealInvite{
+		{InfluencerId: invitee.ExpID, Payout: 10, Deadline: time.Now().Add(7 * 24 * time.Hour).Unix()},
+	}
+	r = rst.DoTesting(t, "POST", "/sendInvites/"+cid, &invites, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!", string(r.Value))
+		return
+	}
+
+	var sent []*common.DealInvite
+	r = rst.DoTesting(t, "GET", "/getInvites/"+cid, nil, &sent)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	if len(sent) != 1 || sent[0].DealId == "" || sent[0].Status != common.INVITE_PENDING {
+		t.Fatalf("Bad invites: %+v", sent)
+		return
+	}
+	held := sent[0].DealId
+
+	// Invites skip targeting and only offer the held deal
+	deals := getCmpDeals(invitee.ExpID)
+	if len(deals) != 1 || deals[0].Id != held {
+		t.Fatalf("Bad invitee deals: %+v", deals)
+		return
+	}
+
+	// Nobody else gets the held deal
+	deals = getCmpDeals(other.ExpID)
+	if len(deals) == 0 {
+		t.Fatal("Unexpected number of deals!")
+		return
+	}
+
+	for _, d := range deals {
+		if d.Id == held {
+			t.Fatal("Held deal offered to someone else!")
+			return
+		}
+	}
+
+	r = rst.DoTesting(t, "GET", "/assignDeal/"+other.ExpID+"/"+cid+"/"+held+"/twitter", nil, nil)
+	if r.Status == 200 {
+		t.Fatal("Held deal assigned to someone else!")
+		return
+	}
+
+	// Exclusions still apply to invitees
+	brandSafe := true
+	cmpUpdate := CampaignUpdate{
+		Status:    &cmp.Status,
+		Budget:    &cmp.Budget,
+		Male:      &cmp.Male,
+		Female:    &cmp.Female,
+		Name:      &cmp.Name,
+		BrandSafe: &brandSafe,
+	}
+
+	r = rst.DoTesting(t, "PUT", "/campaign/"+cid, &cmpUpdate, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	if len(getCmpDeals(invitee.ExpID)) != 0 {
+		t.Fatal("Excluded invitee got a deal!")
+		return
+	}
+
+	r = rst.DoTesting(t, "POST", "/sendInvites/"+cid, &invites, nil)
+	if r.Status == 200 {
+		t.Fatal("Excluded influencer was invited!")
+		return
+	}
+
+	brandSafe = false
+	r = rst.DoTesting(t, "PUT", "/campaign/"+cid, &cmpUpdate, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	// Declining releases the held deal
+	r = rst.DoTesting(t, "GET", "/declineInvite/"+invitee.ExpID+"/"+cid, nil, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	r = rst.DoTesting(t, "GET", "/getInvites/"+cid, nil, &sent)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	if len(sent) != 1 || sent[0].Status != common.INVITE_DECLINED {
+		t.Fatalf("Bad invites: %+v", sent)
+		return
+	}
+
+	if len(getCmpDeals(invitee.ExpID)) != 0 {
+		t.Fatal("Targeting should apply again after declining!")
+		return
+	}
+
+	var released bool
+	for _, d := range getCmpDeals(other.ExpID) {
+		if d.Id == held {
+			released = true
+		}
+	}
+
+	if !released {
+		t.Fatal("Declined deal was not released!")
+		return
+	}
+
+	r = rst.DoTesting(t, "GET", "/declineInvite/"+invitee.ExpID+"/"+cid, nil, nil)
+	if r.Status == 200 {
+		t.Fatal("Declined the same invite twice!")
+		return
+	}
+}
+
+func TestBilling(t *testing.T) {
+	if *genData {
+		t.Skip("not needed for generating data")
+	}
+	rst := getClient()
+	defer putClient(rst)
+
+	// Sign in as admin
+	r := rst.DoTesting(t, "POST", "/signIn", &adminReq, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+	}
+
+	cids := make(map[string]budget.Store)
+	addedCids := []string{}
+
+	// Do multiple deals for multiple talent agencies, influencers,
+	// and advertisers
+	for i := 0; i < 8; i++ {
+		ag := getSignupUser()
+		ag.TalentAgency = &auth.TalentAgency{
+			Fee: 0.1,
+		}
+
+		r = rst.DoTesting(t, "POST", "/signUp", &ag, nil)
+		if r.Status != 200 {
+			t.Fatal("Bad status code!")
+		}
+
+		inf := getSignupUser()
+		inf.InfluencerLoad = &auth.InfluencerLoad{ // ugly I know
+			InfluencerLoad: influencer.InfluencerLoad{
+				Male:       true,
+				Geo:        &geo.GeoRecord{},
+				InviteCode: common.GetCodeFromID(ag.ExpID),
+				TwitterId:  "breakingnews",
+				Address: &lob.AddressLoad{
+					AddressOne: "8 Saint Elias",
+					City:       "Trabuco Canyon",
+					State:      "CA",
+					Country:    "US",
+				},
+			},
+		}
+
+		r = rst.DoTesting(t, "POST", "/signUp", &inf, nil)
+		if r.Status != 200 {
+			t.Fatal("Bad status code!")
+		}
+
+		adAg := getSignupUserWithName("Some Ad Agency Name" + strconv.Itoa(i))
+		adAg.AdAgency = &auth.AdAgency{Status: true, IsIO: false}
+		r = rst.DoTesting(t, "POST", "/signUp", adAg, nil)
+		if r.Status != 200 {
+			t.Fatal("Bad status code!")
+		}
+
+		cid := doDeal(rst, t, inf.ExpID, adAg.ExpID, false)
+
+		// Approve the deal
+		r = rst.DoTesting(t, "GET", "/forceApprove/"+inf.ExpID+"/"+cid+"", nil, nil)
+		if r.Status != 200 {
+			t.Fatal("Bad status code!")
+			return
+		}
+
+		addedCids = append(addedCids, cid)
+	}
+
+	// Deplete budgets
+	r = rst.DoTesting(t, "GET", "/forceDeplete", nil, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	for _, cid := range addedCids {
+		store := budget.Store{}
+		r = rst.DoTesting(t, "GET", "/getBudgetInfo/"+cid, nil, &store)
+		if r.Status != 200 {
+			t.Fatal("Bad status code!")
+		}
+
+		if store.Spent == 0 {
+			t.Fatal("Spent not incremented correctly in budget")
+		}
+
+		var breakdown map[string]*reporting.Totals
+		r = rst.DoTesting(t, "GET", "/getCampaignStats/"+cid+"/10", nil, &breakdown)
+		if r.Status != 200 {
+			t.Fatal("Bad status code!")
 			return
 		}
 		// Campaign stats and budget should have EVERYTHING (markups + spend)
@@ -6249,3 +6811,429 @@ func TestBilling(t *testing.T) {
 		}
 	}
 }
+
+func TestPerformanceBilling(t *testing.T) {
+	rst := getClient()
+	defer putClient(rst)
+
+	// Sign in as admin
+	r := rst.DoTesting(t, "POST", "/signIn", &adminReq, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	adv := getSignupUser()
+	adv.Advertiser = &auth.Advertiser{
+		DspFee:   0.2,
+		AgencyID: "2",
+		CCLoad:   creditCard,
+		SubLoad:  getSubscription(3, 100, true),
+	}
+
+	var st Status
+	r = rst.DoTesting(t, "POST", "/signUp", adv, &st)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	// Flat fee deals are paid once
+	cmp := common.Campaign{
+		Status:       true,
+		AdvertiserId: st.ID,
+		Budget:       DEFAULT_BUDGET,
+		Name:         "Flat Campaign!",
+		Twitter:      true,
+		Male:         true,
+		Female:       true,
+		Link:         "http://www.cnn.com?s=t",
+		Task:         "POST THAT DOPE SHIT",
+		Tags:         []string{"#mmmm"},
+		Pricing:      &common.Pricing{Model: common.PRICING_FLAT, Rate: 50},
+	}
+
+	var status Status
+	r = rst.DoTesting(t, "POST", "/campaign?dbg=1", &cmp, &status)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!", string(r.Value))
+		return
+	}
+	cid := status.ID
+
+	inf := getSignupUser()
+	inf.InfluencerLoad = &auth.InfluencerLoad{
+		InfluencerLoad: influencer.InfluencerLoad{
+			Male:      true,
+			Geo:       &geo.GeoRecord{},
+			TwitterId: "cnn",
+		},
+	}
+	r = rst.DoTesting(t, "POST", "/signUp", &inf, nil)
+	if r.Status != 200 {
+		t.Fatalf("Bad status code! %s", r.Value)
+		return
+	}
+
+	var deals []*common.Deal
+	r = rst.DoTesting(t, "GET", "/getDeals/"+inf.ExpID+"/0/0", nil, &deals)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	deals = getDeals(cid, deals)
+	if len(deals) == 0 || deals[0].MaxYield != 50 {
+		t.Fatal("Unexpected deals!")
+		return
+	}
+
+	r = rst.DoTesting(t, "GET", "/assignDeal/"+inf.ExpID+"/"+cid+"/"+deals[0].Id+"/twitter?dbg=1", nil, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	r = rst.DoTesting(t, "GET", "/forceApprove/"+inf.ExpID+"/"+cid, nil, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	for i := 0; i < 2; i++ {
+		r = rst.DoTesting(t, "GET", "/forceDeplete", nil, nil)
+		if r.Status != 200 {
+			t.Fatal("Bad status code!")
+			return
+		}
+
+		var store budget.Store
+		r = rst.DoTesting(t, "GET", "/getBudgetInfo/"+cid, nil, &store)
+		if r.Status != 200 {
+			t.Fatal("Bad status code!")
+			return
+		}
+
+		if store.Spent != 50 {
+			t.Fatalf("Bad spend after run %d: %v", i+1, store.Spent)
+			return
+		}
+	}
+
+	var load influencer.Influencer
+	r = rst.DoTesting(t, "GET", "/influencer/"+inf.ExpID, nil, &load)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	if len(load.CompletedDeals) != 1 || !load.CompletedDeals[0].Paid {
+		t.Fatal("Flat fee deal not paid!")
+		return
+	}
+
+	// CPC deals build up spend over runs until the attribution window closes
+	var (
+		now   = int32(time.Now().Unix())
+		day   = int32(24 * 60 * 60)
+		perf  = &influencer.Influencer{Id: "perf"}
+		store = &budget.Store{Spendable: 100}
+		deal  = &common.Deal{
+			Id:           "perf",
+			CampaignId:   "perf",
+			InfluencerId: perf.Id,
+			Completed:    now - day,
+			Pricing:      &common.Pricing{Model: common.PRICING_CPC, Rate: 2, AttributionDays: 30},
+			Reporting:    map[string]*common.Stats{},
+		}
+	)
+
+	addClicks := func(n int, ts int32) {
+		key := common.GetDate()
+		if deal.Reporting[key] == nil {
+			deal.Reporting[key] = &common.Stats{}
+		}
+
+		for i := 0; i < n; i++ {
+			deal.Reporting[key].ApprovedClicks = append(deal.Reporting[key].ApprovedClicks, &common.Click{TS: ts})
+		}
+	}
+
+	runs := []struct {
+		name      string
+		clicks    int
+		ts        int32
+		spendable float64
+		spent     float64
+		billed    int32
+	}{
+		{"first clicks", 3, now, 100, 6, 3},
+		{"more clicks", 2, now, 94, 4, 5},
+		{"no new clicks", 0, now, 90, 0, 5},
+		// Clicks from before the post don't count
+		{"before the post", 4, now - 2*day, 90, 0, 5},
+		// Only what's left in the store gets billed
+		{"capped", 10, now, 9, 8, 9},
+		{"empty store", 0, now, 0, 0, 9},
+		// The leftover units are picked up once there's budget again
+		{"leftover", 0, now, 50, 12, 15},
+	}
+
+	for _, run := range runs {
+		addClicks(run.clicks, run.ts)
+		store.Spendable = run.spendable
+
+		spent := payPerformance(srv, deal, perf, store, 0.2, 0.2)
+		if spent != run.spent || deal.BilledUnits != run.billed {
+			t.Fatalf("%s: wanted %v (%d units), got %v (%d units)", run.name, run.spent, run.billed, spent, deal.BilledUnits)
+			return
+		}
+
+		if deal.Paid {
+			t.Fatalf("%s: deal paid before the window closed", run.name)
+			return
+		}
+	}
+
+	if perf.PendingPayout <= 0 || perf.PendingPayout >= 30 {
+		t.Fatalf("Bad pending payout: %v", perf.PendingPayout)
+		return
+	}
+
+	// Once the window closes the deal is only paid when every unit is billed
+	deal.Completed = now - 40*day
+	deal.BilledUnits = 0
+	deal.Reporting = map[string]*common.Stats{}
+	addClicks(2, deal.Completed+day)
+
+	store.Spendable = 1
+	if spent := payPerformance(srv, deal, perf, store, 0.2, 0.2); spent != 0 || deal.Paid {
+		t.Fatalf("Deal paid with units left to bill: %v %+v", spent, deal)
+		return
+	}
+
+	store.Spendable = 100
+	if spent := payPerformance(srv, deal, perf, store, 0.2, 0.2); spent != 4 || !deal.Paid || deal.BilledUnits != 2 {
+		t.Fatalf("Deal not paid after its window closed: %v %+v", spent, deal)
+		return
+	}
+}
+
+func TestExclusivity(t *testing.T) {
+	rst := getClient()
+	defer putClient(rst)
+
+	// Sign in as admin
+	r := rst.DoTesting(t, "POST", "/signIn", &adminReq, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	newAdvertiser := func() string {
+		adv := getSignupUser()
+		adv.Advertiser = &auth.Advertiser{
+			DspFee:   0.2,
+			AgencyID: "2",
+			CCLoad:   creditCard,
+			SubLoad:  getSubscription(3, 100, true),
+		}
+
+		var st Status
+		r := rst.DoTesting(t, "POST", "/signUp", adv, &st)
+		if r.Status != 200 {
+			t.Fatal("Bad status code!")
+		}
+		return st.ID
+	}
+
+	newCampaign := func(advId string, ex *common.Exclusivity) string {
+		cmp := common.Campaign{
+			Status:       true,
+			AdvertiserId: advId,
+			Budget:       DEFAULT_BUDGET,
+			Name:         "Exclusive Campaign!",
+			Twitter:      true,
+			Male:         true,
+			Female:       true,
+			Link:         "http://www.cnn.com?s=t",
+			Task:         "POST THAT DOPE SHIT",
+			Tags:         []string{"#mmmm"},
+			Exclusivity:  ex,
+		}
+
+		var status Status
+		r := rst.DoTesting(t, "POST", "/campaign?dbg=1", &cmp, &status)
+		if r.Status != 200 {
+			t.Fatal("Bad status code!", string(r.Value))
+		}
+		return status.ID
+	}
+
+	var (
+		advId   = newAdvertiser()
+		cid     = newCampaign(advId, &common.Exclusivity{Category: "beverage", Days: 30})
+		sameCid = newCampaign(advId, &common.Exclusivity{Category: "beverage"})
+		// Only labels its category.. the longest window of the two wins
+		otherCid = newCampaign(newAdvertiser(), &common.Exclusivity{Category: "beverage"})
+	)
+
+	inf := getSignupUser()
+	inf.InfluencerLoad = &auth.InfluencerLoad{
+		InfluencerLoad: influencer.InfluencerLoad{
+			Male:      true,
+			Geo:       &geo.GeoRecord{},
+			TwitterId: "cnn",
+		},
+	}
+	r = rst.DoTesting(t, "POST", "/signUp", &inf, nil)
+	if r.Status != 200 {
+		t.Fatalf("Bad status code! %s", r.Value)
+		return
+	}
+
+	getCmpDeals := func(cid string) []*common.Deal {
+		var deals []*common.Deal
+		r := rst.DoTesting(t, "GET", "/getDeals/"+inf.ExpID+"/0/0", nil, &deals)
+		if r.Status != 200 {
+			t.Fatal("Bad status code!")
+		}
+		return getDeals(cid, deals)
+	}
+
+	for _, id := range []string{cid, sameCid, otherCid} {
+		if len(getCmpDeals(id)) == 0 {
+			t.Fatal("Unexpected number of deals!")
+			return
+		}
+	}
+
+	r = rst.DoTesting(t, "GET", "/assignDeal/"+inf.ExpID+"/"+cid+"/0/twitter?dbg=1", nil, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	// Active deals always conflict but the same advertiser isn't a competitor
+	if len(getCmpDeals(otherCid)) != 0 {
+		t.Fatal("Competitor offered during an active deal!")
+		return
+	}
+
+	if len(getCmpDeals(sameCid)) == 0 {
+		t.Fatal("Same advertiser should not conflict!")
+		return
+	}
+
+	r = rst.DoTesting(t, "GET", "/forceApprove/"+inf.ExpID+"/"+cid, nil, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	// Still within the 30 day window after completing
+	if len(getCmpDeals(otherCid)) != 0 {
+		t.Fatal("Competitor offered within the window!")
+		return
+	}
+
+	r = rst.DoTesting(t, "GET", "/assignDeal/"+inf.ExpID+"/"+otherCid+"/0/twitter?dbg=1", nil, nil)
+	if r.Status == 200 {
+		t.Fatal("Competitor deal assigned within the window!")
+		return
+	}
+
+	// Admin override lets the influencer take the competitor's deal
+	r = rst.DoTesting(t, "GET", "/skipExclusivity/"+inf.ExpID+"/"+otherCid, nil, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	if len(getCmpDeals(otherCid)) == 0 {
+		t.Fatal("Override did not apply!")
+		return
+	}
+
+	r = rst.DoTesting(t, "GET", "/assignDeal/"+inf.ExpID+"/"+otherCid+"/0/twitter?dbg=1", nil, nil)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	var load influencer.Influencer
+	r = rst.DoTesting(t, "GET", "/influencer/"+inf.ExpID, nil, &load)
+	if r.Status != 200 {
+		t.Fatal("Bad status code!")
+		return
+	}
+
+	if len(load.ActiveDeals) != 1 || load.ActiveDeals[0].CampaignId != otherCid || len(load.CompletedDeals) != 1 {
+		t.Fatalf("Unexpected deals: %+v %+v", load.ActiveDeals, load.CompletedDeals)
+		return
+	}
+}
+
+func TestProductApproval(t *testing.T) {
+	cmp := common.Campaign{
+		Id:         "productApproval",
+		Perks:      &common.Perk{Name: "Shoes", Type: 1, Count: 5},
+		VisualTags: []string{"shoe"},
+	}
+	srv.Campaigns.SetCampaign(cmp.Id, cmp)
+
+	var (
+		published = int32(time.Now().Add(-24 * time.Hour).Unix())
+		image     = "http://insta.com/shoe.jpg"
+		inf       = influencer.Influencer{
+			Id: "productApproval",
+			Instagram: &instagram.Instagram{
+				AvgLikes:    100,
+				AvgComments: 1,
+			},
+		}
+	)
+
+	tests := []struct {
+		name    string
+		caption string
+		check   *common.ProductCheck
+		approve bool
+	}{
+		// Seeing the product skips the standard approval
+		{"product seen", "#ad New kicks #mmmm", &common.ProductCheck{Pass: true}, true},
+		{"product missing", "#ad New kicks #mmmm", &common.ProductCheck{Reason: "Product not found in image (expected shoe)"}, false},
+		// Couldn't tag the image so it's up to us
+		{"not checked", "#ad New kicks #mmmm", nil, false},
+		// Fraud checks still apply
+		{"fraud hashtag", "#ad New kicks #mmmm #follow4follow", &common.ProductCheck{Pass: true}, false},
+	}
+
+	for _, ts := range tests {
+		if ts.check != nil {
+			ts.check.Image, ts.check.Expected, ts.check.MinConfidence = image, cmp.VisualTags, cmp.GetVisualConfidence()
+		}
+
+		deal := &common.Deal{
+			Id:           "1",
+			CampaignId:   cmp.Id,
+			InfluencerId: inf.Id,
+			Assigned:     published - 3600,
+			Tags:         []string{"mmmm"},
+			ProductCheck: ts.check,
+		}
+
+		inf.Instagram.LatestPosts = []*instagram.Post{{
+			Caption:   ts.caption,
+			PostURL:   "http://insta.com/p/1",
+			Thumbnail: image,
+			Published: published,
+			Likes:     100,
+			Comments:  1,
+		}}
+
+		if post := findInstagramMatch(srv, inf, deal, ""); (post != nil) != ts.approve {
+			t.Errorf("%s: wanted approved %v, got %+v", ts.name, ts.approve, post)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"log"
+	"sort"
+
+	"github.com/swayops/sway/internal/common"
+	"github.com/swayops/sway/internal/shipping"
+)
+
+// applyShipmentEvents updates the deals shipped with the given carrier and
+// returns how many were updated. Delivered perks restart the deal's timeout
+// since the influencer can't post without the product
+func applyShipmentEvents(s *Server, carrier string, events []*shipping.Event) (int32, error) {
+	byNumber := make(map[string][]*shipping.Event)
+	for _, ev := range events {
+		if ev != nil && ev.TrackingNumber != "" {
+			byNumber[ev.TrackingNumber] = append(byNumber[ev.TrackingNumber], ev)
+		}
+	}
+
+	if len(byNumber) == 0 {
+		return 0, nil
+	}
+
+	var updated int32
+	for _, inf := range s.auth.Influencers.GetAll() {
+		var changed bool
+		for _, deal := range inf.ActiveDeals {
+			// Overdue shipments still get updates if the carrier finds them
+			if deal.Perk == nil || !deal.Perk.Shipment.IsPending() || deal.Perk.Shipment.Carrier != carrier {
+				continue
+			}
+
+			evs, ok := byNumber[deal.Perk.Shipment.TrackingNumber]
+			if !ok {
+				continue
+			}
+
+			if updateShipment(s, deal, evs) {
+				changed = true
+				updated += 1
+			}
+		}
+
+		if !changed {
+			continue
+		}
+
+		if err := saveAllActiveDeals(s, inf); err != nil {
+			log.Println("Error saving shipment update", inf.Id, err)
+			return updated, err
+		}
+	}
+
+	return updated, nil
+}
+
+func updateShipment(s *Server, deal *common.Deal, events []*shipping.Event) bool {
+	var (
+		ship    = deal.Perk.Shipment
+		changed bool
+	)
+
+	for _, ev := range events {
+		if ship.Apply(ev) {
+			changed = true
+		}
+	}
+
+	if changed && ship.IsDelivered() {
+		// Campaign may no longer be in the cache.. default schedule then
+		var schedule *common.DealSchedule
+		if cmp, ok := s.Campaigns.Get(deal.CampaignId); ok {
+			schedule = cmp.Schedule
+		}
+		deal.SetTimeoutFrom(ship.Delivered, schedule)
+	}
+
+	return changed
+}
+
+// pollShipments asks carriers for updates on every perk still in transit..
+// carriers that send webhooks won't have much to add
+func pollShipments(s *Server) (int32, error) {
+	var updated int32
+	for _, inf := range s.auth.Influencers.GetAll() {
+		var changed bool
+		for _, deal := range inf.ActiveDeals {
+			if deal.Perk == nil || !deal.Perk.Shipment.IsPending() {
+				continue
+			}
+
+			carrier, err := shipping.Get(deal.Perk.Shipment.Carrier)
+			if err != nil {
+				// Carrier was removed since we shipped
+				continue
+			}
+
+			events, err := carrier.Track(deal.Perk.Shipment.TrackingNumber)
+			if err != nil {
+				log.Println("Error tracking shipment", deal.Id, err)
+				continue
+			}
+
+			if updateShipment(s, deal, events) {
+				changed = true
+				updated += 1
+			}
+		}
+
+		if !changed {
+			continue
+		}
+
+		if err := saveAllActiveDeals(s, inf); err != nil {
+			log.Println("Error saving shipment update", inf.Id, err)
+			return updated, err
+		}
+	}
+
+	return updated, nil
+}
+
+// ShipmentRow is a single perk on the advertiser's shipment board
+type ShipmentRow struct {
+	DealId         string `json:"dealId,omitempty"`
+	InfluencerId   string `json:"infId,omitempty"`
+	InfluencerName string `json:"infName,omitempty"`
+	SKU            string `json:"sku,omitempty"`
+	Variant        string `json:"variant,omitempty"`
+	Status         string `json:"status,omitempty"`
+	Assigned       int32  `json:"assigned,omitempty"`
+	Timeout        int32  `json:"timeout,omitempty"`
+
+	*shipping.Shipment
+}
+
+type ShipmentBoard struct {
+	CampaignId string         `json:"campaignId,omitempty"`
+	Counts     map[string]int `json:"counts,omitempty"` // By status
+	Shipments  []*ShipmentRow `json:"shipments,omitempty"`
+}
+
+func getShipmentBoard(cmp *common.Campaign) *ShipmentBoard {
+	board := &ShipmentBoard{
+		CampaignId: cmp.Id,
+		Counts:     make(map[string]int),
+	}
+
+	for _, deal := range cmp.Deals {
+		// Deal perks only carry the category
+		if deal.Perk == nil || deal.Perk.Category == "Coupon" {
+			continue
+		}
+
+		if !deal.IsActive() && !deal.IsComplete() {
+			continue
+		}
+
+		row := &ShipmentRow{
+			DealId:         deal.Id,
+			InfluencerId:   deal.InfluencerId,
+			InfluencerName: deal.InfluencerName,
+			SKU:            deal.Perk.SKU,
+			Variant:        deal.Perk.Variant,
+			Status:         deal.Perk.GetShipmentStatus(),
+			Assigned:       deal.Assigned,
+			Shipment:       deal.Perk.Shipment,
+		}
+
+		if deal.IsActive() {
+			row.Timeout = deal.GetTimeout()
+		}
+
+		board.Counts[row.Status] += 1
+		board.Shipments = append(board.Shipments, row)
+	}
+
+	// Newest first
+	sort.Slice(board.Shipments, func(i, j int) bool {
+		return board.Shipments[i].Assigned > board.Shipments[j].Assigned
+	})
+
+	return board
+}